@@ -0,0 +1,407 @@
+package tscnparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AssetKind is the kind of asset a path references
+type AssetKind string
+
+const (
+	AssetTexture  AssetKind = "texture"
+	AssetScene    AssetKind = "scene" // prefabs and instanced scenes
+	AssetScript   AssetKind = "script"
+	AssetResource AssetKind = "resource" // other resources referenced by properties
+)
+
+// Path rule types
+const (
+	PathRuleReplace   = "replace"   // replaces every occurrence of Old in the path with New
+	PathRulePrefix    = "prefix"    // replaces the prefix Old with New
+	PathRuleSuffix    = "suffix"    // replaces the suffix Old with New
+	PathRuleRegex     = "regex"     // replaces matches of the regular expression Old with New, which may use $1
+	PathRuleGlob      = "glob"      // replaces paths matching the glob Old with New, see PathRule
+	PathRuleExtension = "extension" // replaces the extension Old, e.g. ".png", with New
+	PathRuleTable     = "table"     // replaces a path found in Table with its entry
+)
+
+// PathRule rewrites asset paths. In a glob, * matches within a path segment, ** across
+// segments and ? a single character; each * in New is replaced by the text matched by the
+// next wildcard, and a New without * replaces the whole path.
+type PathRule struct {
+	Type  string            `json:"type,omitempty"` // see the PathRule* constants, PathRuleReplace when empty
+	Old   string            `json:"old,omitempty"`
+	New   string            `json:"new"`
+	Table map[string]string `json:"table,omitempty"`
+	Kinds []AssetKind       `json:"kinds,omitempty"` // kinds of assets the rule applies to, all when empty
+}
+
+// PathRules is the rules file format, the format of the replacements file of the test program
+type PathRules struct {
+	Replacements []PathRule `json:"replacements"`
+}
+
+// PathRewrite is a path rewritten by PathMapper.Apply
+type PathRewrite struct {
+	Location string    `json:"location"` // JSON Pointer of the field in the map JSON
+	Kind     AssetKind `json:"kind"`
+	Old      string    `json:"old"`
+	New      string    `json:"new"`
+}
+
+// PathMapper rewrites the asset paths of map data with a list of rules, applied in order
+// to the result of the previous ones. Node paths, names and other strings are left alone.
+type PathMapper struct {
+	rules []pathRule
+}
+
+type pathRule struct {
+	PathRule
+	re *regexp.Regexp // regex and glob rules
+}
+
+// NewPathMapper checks and compiles rules
+func NewPathMapper(rules []PathRule) (*PathMapper, error) {
+	m := &PathMapper{}
+	for i, rule := range rules {
+		compiled := pathRule{PathRule: rule}
+		var err error
+		switch rule.Type {
+		case "", PathRuleReplace, PathRulePrefix, PathRuleSuffix, PathRuleExtension:
+			if rule.Old == "" {
+				// An empty old string matches everything, the replacements file skipped it
+				continue
+			}
+		case PathRuleRegex:
+			compiled.re, err = regexp.Compile(rule.Old)
+		case PathRuleGlob:
+			compiled.re, err = globRegexp(rule.Old)
+		case PathRuleTable:
+		default:
+			err = fmt.Errorf("unknown type %q", rule.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("path rule %d: %w", i+1, err)
+		}
+		m.rules = append(m.rules, compiled)
+	}
+	return m, nil
+}
+
+// LoadPathMapper reads rules from a JSON file in the PathRules format
+func LoadPathMapper(file string) (*PathMapper, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var rules PathRules
+	if err := json.Unmarshal(content, &rules); err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	m, err := NewPathMapper(rules.Replacements)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return m, nil
+}
+
+// Map returns an asset path rewritten by the rules
+func (m *PathMapper) Map(kind AssetKind, p string) string {
+	for _, rule := range m.rules {
+		if len(rule.Kinds) > 0 && !containsKind(rule.Kinds, kind) {
+			continue
+		}
+		p = rule.apply(p)
+	}
+	return p
+}
+
+func (r pathRule) apply(p string) string {
+	switch r.Type {
+	case "", PathRuleReplace:
+		return strings.ReplaceAll(p, r.Old, r.New)
+	case PathRulePrefix:
+		if rest, ok := strings.CutPrefix(p, r.Old); ok {
+			return r.New + rest
+		}
+	case PathRuleSuffix:
+		if rest, ok := strings.CutSuffix(p, r.Old); ok {
+			return rest + r.New
+		}
+	case PathRuleExtension:
+		if path.Ext(p) == r.Old {
+			return strings.TrimSuffix(p, r.Old) + r.New
+		}
+	case PathRuleRegex:
+		return r.re.ReplaceAllString(p, r.New)
+	case PathRuleGlob:
+		match := r.re.FindStringSubmatch(p)
+		if match == nil {
+			return p
+		}
+		if !strings.Contains(r.New, "*") {
+			return r.New
+		}
+		captures := match[1:]
+		return globPlaceholderRe.ReplaceAllStringFunc(r.New, func(string) string {
+			if len(captures) == 0 {
+				return ""
+			}
+			capture := captures[0]
+			captures = captures[1:]
+			return capture
+		})
+	case PathRuleTable:
+		if replacement, ok := r.Table[p]; ok {
+			return replacement
+		}
+	}
+	return p
+}
+
+// globPlaceholderRe matches the placeholders of the wildcards in the New of a glob rule
+var globPlaceholderRe = regexp.MustCompile(`\*+`)
+
+// globRegexp compiles a glob to a regular expression matching whole paths, with a group per wildcard
+func globRegexp(glob string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString("(.*)")
+			i++
+		case glob[i] == '*':
+			expr.WriteString("([^/]*)")
+		case glob[i] == '?':
+			expr.WriteString("([^/])")
+		default:
+			expr.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	expr.WriteString("$")
+	return regexp.Compile(expr.String())
+}
+
+func containsKind(kinds []AssetKind, kind AssetKind) bool {
+	for _, k := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply rewrites the asset paths of the data in place and returns what it rewrote, in the
+// order of the fields in the JSON
+func (m *PathMapper) Apply(data *MapData) []PathRewrite {
+	var rewrites []PathRewrite
+	walkAssetPaths(data, func(kind AssetKind, location string, p *string) {
+		if *p == "" {
+			return
+		}
+		if mapped := m.Map(kind, *p); mapped != *p {
+			rewrites = append(rewrites, PathRewrite{Location: location, Kind: kind, Old: *p, New: mapped})
+			*p = mapped
+		}
+	})
+	return rewrites
+}
+
+// assetKindOf guesses the kind of asset a path references from its extension
+func assetKindOf(p string, fallback AssetKind) AssetKind {
+	switch strings.ToLower(path.Ext(p)) {
+	case ".png", ".jpg", ".jpeg", ".webp", ".svg", ".bmp", ".tga", ".exr", ".hdr", ".ktx":
+		return AssetTexture
+	case ".tscn", ".scn":
+		return AssetScene
+	case ".gd", ".cs":
+		return AssetScript
+	}
+	return fallback
+}
+
+// assetVisitor visits a field holding an asset path at its JSON Pointer location
+type assetVisitor func(kind AssetKind, location string, p *string)
+
+// walkAssetPaths visits every asset path field of the data: textures, scenes, scripts and
+// resources referenced by properties
+func walkAssetPaths(data *MapData, visit assetVisitor) {
+	tilemap := &data.TileMap
+	for i := range tilemap.TileSet.Sources {
+		source := &tilemap.TileSet.Sources[i]
+		location := fmt.Sprintf("/tilemap/tileset/sources/%d", i)
+		visit(AssetTexture, location+"/texture_path", &source.TexturePath)
+		walkTextureRef(source.TextureRef, location+"/texture_ref", visit)
+	}
+	walkProperties(tilemap.Metadata, "/tilemap/metadata", visit)
+
+	for i := range data.Decorators {
+		decorator := &data.Decorators[i]
+		location := fmt.Sprintf("/decorators/%d", i)
+		// The texture of the prefab, or the instanced scene when it has no prefab
+		visit(assetKindOf(decorator.Path, AssetTexture), location+"/path", &decorator.Path)
+		walkTextureRef(decorator.TextureRef, location+"/texture_ref", visit)
+		walkAnimations(decorator.Animations, location+"/animations", visit)
+		walkProperties(decorator.Metadata, location+"/metadata", visit)
+		walkNodeScript(&decorator.NodeScript, location, visit)
+	}
+	for i := range data.Sprites {
+		sprite := &data.Sprites[i]
+		location := fmt.Sprintf("/sprites/%d", i)
+		visit(AssetScene, location+"/path", &sprite.Path)
+		walkProperties(sprite.Properties, location+"/properties", visit)
+		walkProperties(sprite.Metadata, location+"/metadata", visit)
+		walkNodeScript(&sprite.NodeScript, location, visit)
+	}
+	for i := range data.Prefabs {
+		prefab := &data.Prefabs[i]
+		location := fmt.Sprintf("/prefabs/%d", i)
+		visit(AssetScene, location+"/path", &prefab.Path)
+		visit(AssetTexture, location+"/texture", &prefab.Texture)
+		walkTextureRef(prefab.TextureRef, location+"/texture_ref", visit)
+		walkAnimations(prefab.Animations, location+"/animations", visit)
+		walkProperties(prefab.Properties, location+"/properties", visit)
+		walkProperties(prefab.Metadata, location+"/metadata", visit)
+		walkNodeScript(&prefab.NodeScript, location, visit)
+	}
+	for i := range data.Triggers {
+		location := fmt.Sprintf("/triggers/%d", i)
+		walkProperties(data.Triggers[i].Metadata, location+"/metadata", visit)
+		walkNodeScript(&data.Triggers[i].NodeScript, location, visit)
+	}
+	for i := range data.Markers {
+		location := fmt.Sprintf("/markers/%d", i)
+		walkProperties(data.Markers[i].Metadata, location+"/metadata", visit)
+		walkNodeScript(&data.Markers[i].NodeScript, location, visit)
+	}
+	for i := range data.Paths {
+		location := fmt.Sprintf("/paths/%d", i)
+		walkProperties(data.Paths[i].Metadata, location+"/metadata", visit)
+		walkNodeScript(&data.Paths[i].NodeScript, location, visit)
+	}
+	for i := range data.Geometry {
+		geometry := &data.Geometry[i]
+		location := fmt.Sprintf("/geometry/%d", i)
+		walkTextureRef(geometry.Texture, location+"/texture", visit)
+		walkProperties(geometry.Metadata, location+"/metadata", visit)
+		walkNodeScript(&geometry.NodeScript, location, visit)
+	}
+	if lighting := data.Lighting; lighting != nil {
+		for i := range lighting.Lights {
+			light := &lighting.Lights[i]
+			location := fmt.Sprintf("/lighting/lights/%d", i)
+			walkTextureRef(light.Texture, location+"/texture", visit)
+			walkProperties(light.Metadata, location+"/metadata", visit)
+			walkNodeScript(&light.NodeScript, location, visit)
+		}
+		for i := range lighting.Occluders {
+			location := fmt.Sprintf("/lighting/occluders/%d", i)
+			walkProperties(lighting.Occluders[i].Metadata, location+"/metadata", visit)
+			walkNodeScript(&lighting.Occluders[i].NodeScript, location, visit)
+		}
+	}
+	for i := range data.Cameras {
+		camera := &data.Cameras[i]
+		location := fmt.Sprintf("/cameras/%d", i)
+		visit(AssetScene, location+"/scene", &camera.Scene)
+		walkProperties(camera.Metadata, location+"/metadata", visit)
+		walkNodeScript(&camera.NodeScript, location, visit)
+	}
+	for i := range data.Parallax {
+		layer := &data.Parallax[i]
+		location := fmt.Sprintf("/parallax/%d", i)
+		for j := range layer.Sprites {
+			sprite := &layer.Sprites[j]
+			spriteLocation := fmt.Sprintf("%s/sprites/%d", location, j)
+			walkTextureRef(sprite.Texture, spriteLocation+"/texture", visit)
+			walkAnimations(sprite.Animations, spriteLocation+"/animations", visit)
+			walkProperties(sprite.Metadata, spriteLocation+"/metadata", visit)
+			walkNodeScript(&sprite.NodeScript, spriteLocation, visit)
+		}
+		walkProperties(layer.Metadata, location+"/metadata", visit)
+		walkNodeScript(&layer.NodeScript, location, visit)
+	}
+	for i := range data.Connections {
+		walkValues(data.Connections[i].Binds, fmt.Sprintf("/connections/%d/binds", i), visit)
+	}
+	for i := range data.Scripts {
+		script := &data.Scripts[i]
+		location := fmt.Sprintf("/scripts/%d", i)
+		visit(AssetScript, location+"/path", &script.Path)
+		// extends is a class name or a quoted script path
+		if unquoted, err := strconv.Unquote(script.Extends); err == nil {
+			extends := unquoted
+			visit(AssetScript, location+"/extends", &extends)
+			if extends != unquoted {
+				script.Extends = strconv.Quote(extends)
+			}
+		}
+		walkProperties(script.Defaults, location+"/defaults", visit)
+	}
+}
+
+func walkTextureRef(ref *TextureRef, location string, visit assetVisitor) {
+	if ref != nil {
+		visit(AssetTexture, location+"/path", &ref.Path)
+	}
+}
+
+func walkAnimations(animations []SpriteAnimation, location string, visit assetVisitor) {
+	for i := range animations {
+		for j := range animations[i].Frames {
+			frame := &animations[i].Frames[j]
+			visit(AssetTexture, fmt.Sprintf("%s/%d/frames/%d/texture/path", location, i, j), &frame.Texture.Path)
+		}
+	}
+}
+
+func walkNodeScript(script *NodeScript, location string, visit assetVisitor) {
+	visit(AssetScript, location+"/script", &script.Script)
+	walkProperties(script.Exports, location+"/exports", visit)
+}
+
+func walkProperties(properties Properties, location string, visit assetVisitor) {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		properties[key] = walkValue(properties[key], location+"/"+jsonPointerEscaper.Replace(key), visit)
+	}
+}
+
+func walkValues(values Values, location string, visit assetVisitor) {
+	for i, value := range values {
+		values[i] = walkValue(value, location+"/"+strconv.Itoa(i), visit)
+	}
+}
+
+// walkValue visits the resource references in a property value and returns the value
+// with their paths rewritten
+func walkValue(value any, location string, visit assetVisitor) any {
+	switch v := value.(type) {
+	case ResourceRef:
+		if v.Path != "" {
+			visit(assetKindOf(v.Path, AssetResource), location+"/path", &v.Path)
+		}
+		return v
+	case []any:
+		walkValues(v, location, visit)
+	case map[string]any:
+		walkProperties(v, location, visit)
+	case Properties:
+		walkProperties(v, location, visit)
+	case Variant:
+		walkValues(v.Args, location+"/args", visit)
+	}
+	return value
+}
@@ -0,0 +1,103 @@
+package tscnparser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPathRules(t *testing.T) {
+	tests := []struct {
+		rule       PathRule
+		kind       AssetKind
+		path, want string
+	}{
+		{PathRule{Old: ".tscn", New: ""}, AssetScene, "res://scenes/door.tscn", "res://scenes/door"},
+		{PathRule{Type: PathRulePrefix, Old: "res://", New: "assets/"}, AssetTexture, "res://a/res://b.png", "assets/a/res://b.png"},
+		{PathRule{Type: PathRulePrefix, Old: "res://x/", New: ""}, AssetTexture, "res://a.png", "res://a.png"},
+		{PathRule{Type: PathRuleSuffix, Old: "_hd.png", New: ".png"}, AssetTexture, "res://a_hd.png", "res://a.png"},
+		{PathRule{Type: PathRuleExtension, Old: ".png", New: ".webp"}, AssetTexture, "res://a.png/b.png", "res://a.png/b.webp"},
+		{PathRule{Type: PathRuleExtension, Old: ".png", New: ".webp"}, AssetTexture, "res://a.pngx", "res://a.pngx"},
+		{PathRule{Type: PathRuleRegex, Old: `^res://textures/(\w+)/`, New: "tex/$1-"}, AssetTexture, "res://textures/ground/a.png", "tex/ground-a.png"},
+		{PathRule{Type: PathRuleGlob, Old: "res://scenes/*.tscn", New: "prefabs/*.prefab"}, AssetScene, "res://scenes/door.tscn", "prefabs/door.prefab"},
+		{PathRule{Type: PathRuleGlob, Old: "res://scenes/*.tscn", New: "prefabs/*.prefab"}, AssetScene, "res://scenes/a/door.tscn", "res://scenes/a/door.tscn"},
+		{PathRule{Type: PathRuleGlob, Old: "res://**/?.png", New: "*/*.png"}, AssetTexture, "res://a/b/c.png", "a/b/c.png"},
+		{PathRule{Type: PathRuleGlob, Old: "res://*.gd", New: "script.lua"}, AssetScript, "res://player.gd", "script.lua"},
+		{PathRule{Type: PathRuleTable, Table: map[string]string{"res://a.png": "b.png"}}, AssetTexture, "res://a.png", "b.png"},
+		{PathRule{Old: "res://", New: "", Kinds: []AssetKind{AssetScript}}, AssetTexture, "res://a.png", "res://a.png"},
+		{PathRule{Old: "res://", New: "", Kinds: []AssetKind{AssetScript}}, AssetScript, "res://a.gd", "a.gd"},
+	}
+	for _, tt := range tests {
+		mapper, err := NewPathMapper([]PathRule{tt.rule})
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.rule, err)
+		}
+		if got := mapper.Map(tt.kind, tt.path); got != tt.want {
+			t.Errorf("%+v on %s %s = %s, want %s", tt.rule, tt.kind, tt.path, got, tt.want)
+		}
+	}
+
+	for _, rule := range []PathRule{{Type: "suffixes", Old: "a"}, {Type: PathRuleRegex, Old: "("}} {
+		if _, err := NewPathMapper([]PathRule{rule}); err == nil {
+			t.Errorf("%+v: expected an error", rule)
+		}
+	}
+}
+
+func TestPathMapperApply(t *testing.T) {
+	data := &MapData{
+		TileMap: TileMapData{TileSet: TileSet{Sources: []TileSource{{TexturePath: "res://tiles.png", TextureRef: &TextureRef{Path: "res://tiles.png"}}}}},
+		Sprites: []SpriteNode{{
+			Name:       "door.tscn",
+			Parent:     "rooms/door.tscn",
+			Path:       "res://scenes/door.tscn",
+			Properties: Properties{"key": ResourceRef{Kind: "ExtResource", ID: "1", Path: "res://scenes/key.tscn"}, "label": "door.tscn"},
+			NodeScript: NodeScript{Script: "res://door.gd"},
+		}},
+		Markers: []Marker{{Name: "spawn.tscn", Path: "spawn.tscn"}},
+		Scripts: []Script{{Path: "res://door.gd", Extends: `"res://base.gd"`}, {Path: "res://other.gd", Extends: "Node2D"}},
+	}
+	dir := t.TempDir()
+	rules := filepath.Join(dir, "replacements.json")
+	if err := os.WriteFile(rules, []byte(`{"replacements": [
+		{"old": ".tscn", "new": ""},
+		{"type": "prefix", "old": "res://", "new": ""},
+		{"type": "extension", "old": ".gd", "new": ".lua", "kinds": ["script"]}
+	]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	mapper, err := LoadPathMapper(rules)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewrites := mapper.Apply(data)
+
+	sprite := data.Sprites[0]
+	if sprite.Name != "door.tscn" || sprite.Parent != "rooms/door.tscn" || sprite.Properties["label"] != "door.tscn" {
+		t.Errorf("rewrote a name or value: %+v", sprite)
+	}
+	if marker := data.Markers[0]; marker.Name != "spawn.tscn" || marker.Path != "spawn.tscn" {
+		t.Errorf("rewrote a node path: %+v", marker)
+	}
+	if sprite.Path != "scenes/door" || sprite.Properties["key"].(ResourceRef).Path != "scenes/key" || sprite.Script != "door.lua" {
+		t.Errorf("sprite paths: %+v", sprite)
+	}
+	if data.Scripts[0].Extends != `"base.lua"` || data.Scripts[1].Extends != "Node2D" {
+		t.Errorf("extends: %q, %q", data.Scripts[0].Extends, data.Scripts[1].Extends)
+	}
+
+	want := []PathRewrite{
+		{"/tilemap/tileset/sources/0/texture_path", AssetTexture, "res://tiles.png", "tiles.png"},
+		{"/tilemap/tileset/sources/0/texture_ref/path", AssetTexture, "res://tiles.png", "tiles.png"},
+		{"/sprites/0/path", AssetScene, "res://scenes/door.tscn", "scenes/door"},
+		{"/sprites/0/properties/key/path", AssetScene, "res://scenes/key.tscn", "scenes/key"},
+		{"/sprites/0/script", AssetScript, "res://door.gd", "door.lua"},
+		{"/scripts/0/path", AssetScript, "res://door.gd", "door.lua"},
+		{"/scripts/0/extends", AssetScript, "res://base.gd", "base.lua"},
+		{"/scripts/1/path", AssetScript, "res://other.gd", "other.lua"},
+	}
+	if !reflect.DeepEqual(rewrites, want) {
+		t.Errorf("rewrites:\n%v\nwant:\n%v", rewrites, want)
+	}
+}
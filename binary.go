@@ -0,0 +1,869 @@
+package tscnparser
+
+import (
+	"bufio"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Binary map format. A file starts with a header:
+//
+//	magic       "TSMB"
+//	version     1 byte, BinaryFormatVersion
+//	compression 1 byte, see BinaryCompression
+//
+// followed by the MapData as a single value, deflated if compressed. Values start with a
+// wire type byte and follow the JSON encoding: structs are objects keyed by their JSON
+// field names (so fields can be added without breaking older files), omitempty fields are
+// left out, and Properties keep their Go types. Integers are varints, integral floats are
+// varints too, and integer slices such as tile_data are varint streams of the deltas
+// between entries a stride apart. Strings are written once and referenced by their index
+// in the string table afterwards.
+
+// BinaryFormatVersion is the version of the binary map format written by EncodeBinary
+const BinaryFormatVersion = 1
+
+const binaryMagic = "TSMB"
+
+// BinaryCompression is the compression of a binary map after its header
+type BinaryCompression uint8
+
+const (
+	BinaryCompressionNone    BinaryCompression = 0
+	BinaryCompressionDeflate BinaryCompression = 1
+)
+
+// BinaryHeader is the header of a binary map
+type BinaryHeader struct {
+	Version     int
+	Compression BinaryCompression
+}
+
+// Wire types of binary values
+const (
+	wireNil      = iota
+	wireFalse    // bool
+	wireTrue     // bool
+	wireInt      // zigzag varint
+	wireUint     // varint
+	wireFloatInt // integral float, zigzag varint
+	wireFloat32  // float exactly representable as a float32, 4 bytes little endian
+	wireFloat64  // 8 bytes little endian
+	wireString   // string table reference: 0 and a new string, or the index of a string plus one
+	wireList     // count and values
+	wireInts     // count, stride and zigzag varint deltas to the entry a stride before
+	wireObject   // count and string keys with values
+	wireTyped    // type name and object, for the struct types of Properties values
+)
+
+// typedValueTypes are the struct types Properties values can hold, by their name in the binary format
+var typedValueTypes = map[string]reflect.Type{
+	"Vec2":        reflect.TypeOf(Vec2{}),
+	"Vec2i":       reflect.TypeOf(Vec2i{}),
+	"Color":       reflect.TypeOf(Color{}),
+	"Rect2":       reflect.TypeOf(Rect2{}),
+	"ResourceRef": reflect.TypeOf(ResourceRef{}),
+	"Variant":     reflect.TypeOf(Variant{}),
+}
+
+// EncodeBinary writes the map data in the binary map format
+func EncodeBinary(w io.Writer, data *MapData, compression BinaryCompression) error {
+	if data == nil {
+		return errors.New("binary: no data to encode")
+	}
+	header := append([]byte(binaryMagic), BinaryFormatVersion, byte(compression))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	var body io.Writer
+	var deflater *flate.Writer
+	switch compression {
+	case BinaryCompressionNone:
+		body = w
+	case BinaryCompressionDeflate:
+		var err error
+		if deflater, err = flate.NewWriter(w, flate.BestCompression); err != nil {
+			return err
+		}
+		body = deflater
+	default:
+		return fmt.Errorf("binary: unknown compression %d", compression)
+	}
+	buffered := bufio.NewWriter(body)
+	e := &binaryEncoder{w: buffered, strings: make(map[string]int)}
+	if err := e.value(reflect.ValueOf(data).Elem()); err != nil {
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		return err
+	}
+	if deflater != nil {
+		return deflater.Close()
+	}
+	return nil
+}
+
+// DecodeBinary reads map data written by EncodeBinary
+func DecodeBinary(r io.Reader) (*MapData, error) {
+	decoder, err := NewBinaryDecoder(r)
+	if err != nil {
+		return nil, err
+	}
+	data := &MapData{}
+	if err := decoder.Decode(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// BinaryDecoder reads a binary map from a stream, value by value, without loading the
+// whole file first
+type BinaryDecoder struct {
+	header  BinaryHeader
+	r       *bufio.Reader
+	strings []string
+}
+
+// NewBinaryDecoder reads the header of a binary map
+func NewBinaryDecoder(r io.Reader) (*BinaryDecoder, error) {
+	header := make([]byte, len(binaryMagic)+2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("binary: reading header: %w", err)
+	}
+	if string(header[:len(binaryMagic)]) != binaryMagic {
+		return nil, errors.New("binary: not a binary map")
+	}
+	d := &BinaryDecoder{header: BinaryHeader{
+		Version:     int(header[len(binaryMagic)]),
+		Compression: BinaryCompression(header[len(binaryMagic)+1]),
+	}}
+	if d.header.Version > BinaryFormatVersion {
+		return nil, fmt.Errorf("binary: unsupported format version %d (newest known %d)", d.header.Version, BinaryFormatVersion)
+	}
+	switch d.header.Compression {
+	case BinaryCompressionNone:
+		d.r = bufio.NewReader(r)
+	case BinaryCompressionDeflate:
+		d.r = bufio.NewReader(flate.NewReader(r))
+	default:
+		return nil, fmt.Errorf("binary: unknown compression %d", d.header.Compression)
+	}
+	return d, nil
+}
+
+// Header returns the header read by NewBinaryDecoder
+func (d *BinaryDecoder) Header() BinaryHeader {
+	return d.header
+}
+
+// Decode reads the map data, which must end the stream. A truncated deflate stream is
+// reported even when the map itself was read in full.
+func (d *BinaryDecoder) Decode(data *MapData) error {
+	if err := d.value(reflect.ValueOf(data).Elem()); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return fmt.Errorf("binary: %w", err)
+	}
+	if _, err := d.r.ReadByte(); err != io.EOF {
+		if err == nil {
+			err = errors.New("unexpected data after the map")
+		}
+		return fmt.Errorf("binary: %w", err)
+	}
+	return nil
+}
+
+// binaryField is a field of a struct as encoding/json sees it
+type binaryField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var binaryFieldCache sync.Map // reflect.Type -> []binaryField
+
+// binaryFields lists the JSON fields of a struct type, with the fields of embedded
+// structs promoted like encoding/json does
+func binaryFields(t reflect.Type) []binaryField {
+	if cached, ok := binaryFieldCache.Load(t); ok {
+		return cached.([]binaryField)
+	}
+	var fields []binaryField
+	var collect func(t reflect.Type, index []int)
+	collect = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			tag := field.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, options, _ := strings.Cut(tag, ",")
+			fieldIndex := append(append([]int{}, index...), i)
+			if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+				collect(field.Type, fieldIndex)
+				continue
+			}
+			if !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fields = append(fields, binaryField{name: name, index: fieldIndex, omitEmpty: strings.Contains(options, "omitempty")})
+		}
+	}
+	collect(t, nil)
+	binaryFieldCache.Store(t, fields)
+	return fields
+}
+
+// isEmptyValue reports whether encoding/json leaves an omitempty field with the value out
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+type binaryEncoder struct {
+	w       *bufio.Writer
+	strings map[string]int
+	buf     [binary.MaxVarintLen64]byte
+}
+
+func (e *binaryEncoder) byte(b byte) error {
+	return e.w.WriteByte(b)
+}
+
+func (e *binaryEncoder) uvarint(v uint64) error {
+	_, err := e.w.Write(binary.AppendUvarint(e.buf[:0], v))
+	return err
+}
+
+func (e *binaryEncoder) varint(v int64) error {
+	_, err := e.w.Write(binary.AppendVarint(e.buf[:0], v))
+	return err
+}
+
+// string writes a string, or its index if it was written before
+func (e *binaryEncoder) string(s string) error {
+	if index, exists := e.strings[s]; exists {
+		return e.uvarint(uint64(index) + 1)
+	}
+	e.strings[s] = len(e.strings)
+	if err := e.uvarint(0); err != nil {
+		return err
+	}
+	if err := e.uvarint(uint64(len(s))); err != nil {
+		return err
+	}
+	_, err := e.w.WriteString(s)
+	return err
+}
+
+func (e *binaryEncoder) float(f float64) error {
+	switch {
+	case f == math.Trunc(f) && math.Abs(f) < 1<<53 && !(f == 0 && math.Signbit(f)):
+		if err := e.byte(wireFloatInt); err != nil {
+			return err
+		}
+		return e.varint(int64(f))
+	case float64(float32(f)) == f:
+		if err := e.byte(wireFloat32); err != nil {
+			return err
+		}
+		_, err := e.w.Write(binary.LittleEndian.AppendUint32(e.buf[:0], math.Float32bits(float32(f))))
+		return err
+	}
+	if err := e.byte(wireFloat64); err != nil {
+		return err
+	}
+	_, err := e.w.Write(binary.LittleEndian.AppendUint64(e.buf[:0], math.Float64bits(f)))
+	return err
+}
+
+// value writes a value of a statically typed field
+func (e *binaryEncoder) value(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return e.byte(wireTrue)
+		}
+		return e.byte(wireFalse)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if err := e.byte(wireInt); err != nil {
+			return err
+		}
+		return e.varint(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if err := e.byte(wireUint); err != nil {
+			return err
+		}
+		return e.uvarint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return e.float(v.Float())
+	case reflect.String:
+		if err := e.byte(wireString); err != nil {
+			return err
+		}
+		return e.string(v.String())
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return e.byte(wireNil)
+		}
+		if v.Kind() == reflect.Interface {
+			return e.dynamic(v.Elem())
+		}
+		return e.value(v.Elem())
+	case reflect.Slice:
+		if v.IsNil() {
+			return e.byte(wireNil)
+		}
+		if v.Type().Elem().Kind() == reflect.Int {
+			return e.ints(v)
+		}
+		return e.list(v)
+	case reflect.Array:
+		return e.list(v)
+	case reflect.Map:
+		if v.IsNil() {
+			return e.byte(wireNil)
+		}
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("binary: unsupported map type %s", v.Type())
+		}
+		return e.mapObject(v)
+	case reflect.Struct:
+		return e.object(v)
+	}
+	return fmt.Errorf("binary: unsupported type %s", v.Type())
+}
+
+// dynamic writes the value held by an interface, keeping the Go types documented on Properties
+func (e *binaryEncoder) dynamic(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool, reflect.Float64, reflect.String:
+		return e.value(v)
+	case reflect.Int:
+		if err := e.byte(wireInt); err != nil {
+			return err
+		}
+		return e.varint(v.Int())
+	case reflect.Slice:
+		if v.Type() == reflect.TypeOf([]any(nil)) {
+			if v.IsNil() {
+				return e.byte(wireNil)
+			}
+			return e.list(v)
+		}
+	case reflect.Map:
+		if v.Type() == reflect.TypeOf(map[string]any(nil)) {
+			return e.value(v)
+		}
+	case reflect.Struct:
+		for name, t := range typedValueTypes {
+			if v.Type() == t {
+				if err := e.byte(wireTyped); err != nil {
+					return err
+				}
+				if err := e.string(name); err != nil {
+					return err
+				}
+				return e.object(v)
+			}
+		}
+	}
+	return fmt.Errorf("binary: unsupported property value of type %s", v.Type())
+}
+
+func (e *binaryEncoder) list(v reflect.Value) error {
+	if err := e.byte(wireList); err != nil {
+		return err
+	}
+	if err := e.uvarint(uint64(v.Len())); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := e.value(v.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ints writes an int slice as deltas to the entry a stride before, with the stride (up to
+// 8, e.g. 5 for tile_data) that gives the smallest stream
+func (e *binaryEncoder) ints(v reflect.Value) error {
+	values := make([]int64, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Int()
+	}
+	bestStride, bestSize := 1, -1
+	for stride := 1; stride <= 8 && stride <= max(len(values), 1); stride++ {
+		size := 0
+		for i := range values {
+			size += varintSize(deltaAt(values, i, stride))
+		}
+		if bestSize < 0 || size < bestSize {
+			bestStride, bestSize = stride, size
+		}
+	}
+	if err := e.byte(wireInts); err != nil {
+		return err
+	}
+	if err := e.uvarint(uint64(len(values))); err != nil {
+		return err
+	}
+	if err := e.uvarint(uint64(bestStride)); err != nil {
+		return err
+	}
+	for i := range values {
+		if err := e.varint(deltaAt(values, i, bestStride)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deltaAt(values []int64, i, stride int) int64 {
+	if i < stride {
+		return values[i]
+	}
+	return values[i] - values[i-stride]
+}
+
+func varintSize(v int64) int {
+	var buf [binary.MaxVarintLen64]byte
+	return len(binary.AppendVarint(buf[:0], v))
+}
+
+func (e *binaryEncoder) object(v reflect.Value) error {
+	fields := binaryFields(v.Type())
+	var present []binaryField
+	for _, field := range fields {
+		if !(field.omitEmpty && isEmptyValue(v.FieldByIndex(field.index))) {
+			present = append(present, field)
+		}
+	}
+	if err := e.byte(wireObject); err != nil {
+		return err
+	}
+	if err := e.uvarint(uint64(len(present))); err != nil {
+		return err
+	}
+	for _, field := range present {
+		if err := e.string(field.name); err != nil {
+			return err
+		}
+		if err := e.value(v.FieldByIndex(field.index)); err != nil {
+			return fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+func (e *binaryEncoder) mapObject(v reflect.Value) error {
+	keys := make([]string, 0, v.Len())
+	for _, key := range v.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	if err := e.byte(wireObject); err != nil {
+		return err
+	}
+	if err := e.uvarint(uint64(len(keys))); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := e.string(key); err != nil {
+			return err
+		}
+		if err := e.value(v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key()))); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+func (d *BinaryDecoder) uvarint() (uint64, error) {
+	return binary.ReadUvarint(d.r)
+}
+
+func (d *BinaryDecoder) varint() (int64, error) {
+	return binary.ReadVarint(d.r)
+}
+
+// length reads a count, bounded so that corrupt data cannot allocate huge slices
+func (d *BinaryDecoder) length() (int, error) {
+	n, err := d.uvarint()
+	if err != nil {
+		return 0, err
+	}
+	if n > 1<<28 {
+		return 0, fmt.Errorf("invalid length %d", n)
+	}
+	return int(n), nil
+}
+
+func (d *BinaryDecoder) string() (string, error) {
+	ref, err := d.uvarint()
+	if err != nil {
+		return "", err
+	}
+	if ref > 0 {
+		if ref > uint64(len(d.strings)) {
+			return "", fmt.Errorf("invalid string reference %d", ref)
+		}
+		return d.strings[ref-1], nil
+	}
+	n, err := d.length()
+	if err != nil {
+		return "", err
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(d.r, buf); err != nil {
+		return "", err
+	}
+	d.strings = append(d.strings, string(buf))
+	return string(buf), nil
+}
+
+// value reads a value into v, converting it to v's type
+func (d *BinaryDecoder) value(v reflect.Value) error {
+	wire, err := d.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	return d.wireValue(wire, v)
+}
+
+func (d *BinaryDecoder) wireValue(wire byte, v reflect.Value) error {
+	if wire == wireNil {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Slice, reflect.Map:
+			v.SetZero()
+		}
+		return nil
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.wireValue(wire, v.Elem())
+	}
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		value, err := d.dynamic(wire)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			v.SetZero()
+		} else {
+			v.Set(reflect.ValueOf(value))
+		}
+		return nil
+	}
+
+	mismatch := func() error {
+		return fmt.Errorf("cannot decode wire type %d into %s", wire, v.Type())
+	}
+	switch wire {
+	case wireFalse, wireTrue:
+		if v.Kind() != reflect.Bool {
+			return mismatch()
+		}
+		v.SetBool(wire == wireTrue)
+	case wireInt, wireUint:
+		var i int64
+		var u uint64
+		var err error
+		if wire == wireInt {
+			if i, err = d.varint(); err != nil {
+				return err
+			}
+			u = uint64(i)
+		} else {
+			if u, err = d.uvarint(); err != nil {
+				return err
+			}
+			i = int64(u)
+		}
+		switch v.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			v.SetInt(i)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			v.SetUint(u)
+		case reflect.Float32, reflect.Float64:
+			v.SetFloat(float64(i))
+		default:
+			return mismatch()
+		}
+	case wireFloatInt, wireFloat32, wireFloat64:
+		f, err := d.float(wire)
+		if err != nil {
+			return err
+		}
+		if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+			return mismatch()
+		}
+		v.SetFloat(f)
+	case wireString:
+		s, err := d.string()
+		if err != nil {
+			return err
+		}
+		if v.Kind() != reflect.String {
+			return mismatch()
+		}
+		v.SetString(s)
+	case wireList:
+		n, err := d.length()
+		if err != nil {
+			return err
+		}
+		switch v.Kind() {
+		case reflect.Slice:
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+		case reflect.Array:
+			if n != v.Len() {
+				return fmt.Errorf("cannot decode %d values into %s", n, v.Type())
+			}
+		default:
+			return mismatch()
+		}
+		for i := 0; i < n; i++ {
+			if err := d.value(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case wireInts:
+		values, err := d.ints()
+		if err != nil {
+			return err
+		}
+		if v.Kind() != reflect.Slice || v.Type().Elem().Kind() < reflect.Int || v.Type().Elem().Kind() > reflect.Int64 {
+			return mismatch()
+		}
+		v.Set(reflect.MakeSlice(v.Type(), len(values), len(values)))
+		for i, value := range values {
+			v.Index(i).SetInt(value)
+		}
+	case wireObject:
+		switch v.Kind() {
+		case reflect.Struct:
+			return d.object(v)
+		case reflect.Map:
+			return d.mapObject(v)
+		}
+		return mismatch()
+	case wireTyped:
+		name, err := d.string()
+		if err != nil {
+			return err
+		}
+		t, exists := typedValueTypes[name]
+		if !exists {
+			return fmt.Errorf("unknown value type %q", name)
+		}
+		if v.Type() != t {
+			return mismatch()
+		}
+		return d.value(v)
+	default:
+		return fmt.Errorf("unknown wire type %d", wire)
+	}
+	return nil
+}
+
+// dynamic reads a value into the Go types documented on Properties
+func (d *BinaryDecoder) dynamic(wire byte) (any, error) {
+	switch wire {
+	case wireNil:
+		return nil, nil
+	case wireFalse, wireTrue:
+		return wire == wireTrue, nil
+	case wireInt:
+		i, err := d.varint()
+		return int(i), err
+	case wireUint:
+		u, err := d.uvarint()
+		return int(u), err
+	case wireFloatInt, wireFloat32, wireFloat64:
+		return d.float(wire)
+	case wireString:
+		return d.string()
+	case wireList:
+		var items []any
+		if err := d.wireValue(wire, reflect.ValueOf(&items).Elem()); err != nil {
+			return nil, err
+		}
+		return items, nil
+	case wireInts:
+		values, err := d.ints()
+		if err != nil {
+			return nil, err
+		}
+		items := make([]any, len(values))
+		for i, value := range values {
+			items[i] = int(value)
+		}
+		return items, nil
+	case wireObject:
+		var dict map[string]any
+		if err := d.wireValue(wire, reflect.ValueOf(&dict).Elem()); err != nil {
+			return nil, err
+		}
+		return dict, nil
+	case wireTyped:
+		name, err := d.string()
+		if err != nil {
+			return nil, err
+		}
+		t, exists := typedValueTypes[name]
+		if !exists {
+			return nil, fmt.Errorf("unknown value type %q", name)
+		}
+		value := reflect.New(t).Elem()
+		if err := d.value(value); err != nil {
+			return nil, err
+		}
+		return value.Interface(), nil
+	}
+	return nil, fmt.Errorf("unknown wire type %d", wire)
+}
+
+func (d *BinaryDecoder) float(wire byte) (float64, error) {
+	switch wire {
+	case wireFloatInt:
+		i, err := d.varint()
+		return float64(i), err
+	case wireFloat32:
+		var buf [4]byte
+		if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+			return 0, err
+		}
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[:]))), nil
+	}
+	var buf [8]byte
+	if _, err := io.ReadFull(d.r, buf[:]); err != nil {
+		return 0, err
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(buf[:])), nil
+}
+
+func (d *BinaryDecoder) ints() ([]int64, error) {
+	n, err := d.length()
+	if err != nil {
+		return nil, err
+	}
+	stride, err := d.length()
+	if err != nil {
+		return nil, err
+	}
+	if stride == 0 && n > 0 {
+		return nil, errors.New("invalid stride 0")
+	}
+	values := make([]int64, n)
+	for i := range values {
+		delta, err := d.varint()
+		if err != nil {
+			return nil, err
+		}
+		if i < stride {
+			values[i] = delta
+		} else {
+			values[i] = values[i-stride] + delta
+		}
+	}
+	return values, nil
+}
+
+// object reads the fields of a struct, skipping the fields it does not have
+func (d *BinaryDecoder) object(v reflect.Value) error {
+	n, err := d.length()
+	if err != nil {
+		return err
+	}
+	fields := binaryFields(v.Type())
+	for i := 0; i < n; i++ {
+		name, err := d.string()
+		if err != nil {
+			return err
+		}
+		index := -1
+		for j, field := range fields {
+			if field.name == name {
+				index = j
+				break
+			}
+		}
+		if index < 0 {
+			var skipped any
+			if err := d.value(reflect.ValueOf(&skipped).Elem()); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			continue
+		}
+		if err := d.value(fieldByIndexAlloc(v, fields[index].index)); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// fieldByIndexAlloc returns a nested field, allocating nil embedded pointers on the way
+func fieldByIndexAlloc(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+func (d *BinaryDecoder) mapObject(v reflect.Value) error {
+	n, err := d.length()
+	if err != nil {
+		return err
+	}
+	if v.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("cannot decode an object into %s", v.Type())
+	}
+	v.Set(reflect.MakeMapWithSize(v.Type(), n))
+	for i := 0; i < n; i++ {
+		key, err := d.string()
+		if err != nil {
+			return err
+		}
+		item := reflect.New(v.Type().Elem()).Elem()
+		if err := d.value(item); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), item)
+	}
+	return nil
+}
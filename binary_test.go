@@ -0,0 +1,144 @@
+package tscnparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// jsonRoundTrip returns the data as read back from its JSON encoding
+func jsonRoundTrip(t *testing.T, data *MapData) *MapData {
+	t.Helper()
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("marshal JSON: %v", err)
+	}
+	decoded := &MapData{}
+	if err := json.Unmarshal(encoded, decoded); err != nil {
+		t.Fatalf("unmarshal JSON: %v", err)
+	}
+	return decoded
+}
+
+func binaryRoundTrip(t *testing.T, data *MapData, compression BinaryCompression) (*MapData, int) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := EncodeBinary(&buf, data, compression); err != nil {
+		t.Fatalf("encode binary: %v", err)
+	}
+	size := buf.Len()
+	decoded, err := DecodeBinary(&buf)
+	if err != nil {
+		t.Fatalf("decode binary: %v", err)
+	}
+	return decoded, size
+}
+
+func TestBinaryRoundTripScene(t *testing.T) {
+	data, err := Parse("test/main.tscn")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	ConvertToTilemap(data)
+	fromJSON := jsonRoundTrip(t, data)
+	encodedJSON, _ := json.Marshal(data)
+
+	for _, compression := range []BinaryCompression{BinaryCompressionNone, BinaryCompressionDeflate} {
+		fromBinary, size := binaryRoundTrip(t, data, compression)
+		if !reflect.DeepEqual(fromJSON, fromBinary) {
+			t.Errorf("compression %d: binary round trip differs from JSON round trip", compression)
+		}
+		again, _ := binaryRoundTrip(t, fromJSON, compression)
+		if !reflect.DeepEqual(fromJSON, again) {
+			t.Errorf("compression %d: binary round trip of the JSON data differs", compression)
+		}
+		if size >= len(encodedJSON)/2 {
+			t.Errorf("compression %d: binary is %d bytes, JSON %d", compression, size, len(encodedJSON))
+		}
+	}
+}
+
+func TestBinaryRoundTripPropertyTypes(t *testing.T) {
+	data := &MapData{
+		TileMap: TileMapData{
+			Format:   1,
+			TileSize: TileSize{Width: 16, Height: 16},
+			Layers: []Layer{{
+				Name:      "ground",
+				TileData:  []int{0, 1, -1, 2, 3, 0, 2, -1, 2, 3, 1, -70000, 5, 0, 0},
+				TileFlags: []int{0, TileFlipH | TileTranspose, 0},
+			}},
+		},
+		Decorators: []DecoratorNode{},
+		Sprites: []SpriteNode{{
+			Name:     "Chest",
+			Parent:   ".",
+			Position: Vec2{X: 12.5, Y: -0.1},
+			Path:     "res://chest.tscn",
+			Properties: Properties{
+				"nil":      nil,
+				"bool":     true,
+				"int":      -42,
+				"float":    3.0,
+				"fraction": 0.1,
+				"string":   "gold",
+				"vec2":     Vec2{X: 1, Y: 2.5},
+				"vec2i":    Vec2i{X: 3, Y: -4},
+				"color":    Color{R: 1, G: 0.5, B: 0.25, A: 1},
+				"rect":     Rect2{X: 1, Y: 2, Width: 3, Height: 4},
+				"resource": ResourceRef{Kind: "ExtResource", ID: "1_abc", Path: "res://key.tres"},
+				"variant":  Variant{Type: "Vector3", Args: []any{1.0, 2.0, 3.5}},
+				"array":    []any{1, "two", []any{}, map[string]any{"nested": Vec2{X: 0.5}}},
+				"dict":     map[string]any{"hp": 10, "name": "chest"},
+			},
+			Metadata: Properties{"kind": "loot"},
+		}},
+		Prefabs: []PrefabNode{},
+		Connections: []Connection{{
+			Signal: "opened",
+			From:   "Chest",
+			To:     ".",
+			Method: "_on_opened",
+			Binds:  Values{1, "a"},
+		}},
+		Warnings: []string{"a warning"},
+	}
+	fromJSON := jsonRoundTrip(t, data)
+	for _, compression := range []BinaryCompression{BinaryCompressionNone, BinaryCompressionDeflate} {
+		fromBinary, _ := binaryRoundTrip(t, data, compression)
+		if !reflect.DeepEqual(fromJSON, fromBinary) {
+			got, _ := json.Marshal(fromBinary)
+			want, _ := json.Marshal(fromJSON)
+			t.Errorf("compression %d: round trips differ\nbinary: %s\njson:   %s", compression, got, want)
+		}
+	}
+}
+
+func TestBinaryHeader(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeBinary(&buf, &MapData{}, BinaryCompressionDeflate); err != nil {
+		t.Fatalf("encode binary: %v", err)
+	}
+	encoded := buf.Bytes()
+	decoder, err := NewBinaryDecoder(bytes.NewReader(encoded))
+	if err != nil {
+		t.Fatalf("read header: %v", err)
+	}
+	if header := decoder.Header(); header.Version != BinaryFormatVersion || header.Compression != BinaryCompressionDeflate {
+		t.Errorf("header = %+v", header)
+	}
+
+	newer := append([]byte{}, encoded...)
+	newer[4] = BinaryFormatVersion + 1
+	if _, err := NewBinaryDecoder(bytes.NewReader(newer)); err == nil || !strings.Contains(err.Error(), "version") {
+		t.Errorf("newer version: err = %v", err)
+	}
+	if _, err := NewBinaryDecoder(strings.NewReader(`{"tilemap":{}}`)); err == nil {
+		t.Error("JSON input: expected an error")
+	}
+	if _, err := DecodeBinary(bytes.NewReader(encoded[:len(encoded)-2])); err == nil {
+		t.Error("truncated input: expected an error")
+	}
+}
@@ -0,0 +1,92 @@
+package tscnparser
+
+import (
+	"maps"
+)
+
+// cameraLimit is Godot's default camera limit, effectively unlimited
+const cameraLimit = 10000000
+
+// buildCameras collects the Camera2D nodes of the scene. Instanced scenes whose root node is
+// a Camera2D are included when the prefabs directory is set; the instance's properties
+// override those of the camera scene.
+func (c *TSCNConverter) buildCameras() []Camera {
+	var cameras []Camera
+	for _, node := range c.nodes.order {
+		props := node.Properties
+		var scene string
+		if node.Type != "Camera2D" {
+			root := c.instanceRoot(node)
+			if root == nil || root.Type != "Camera2D" {
+				continue
+			}
+			scene = c.extResources[node.Instance].Path
+			props = maps.Clone(root.Properties)
+			maps.Copy(props, node.Properties)
+		}
+		cameras = append(cameras, c.newCamera(node, scene, &sceneNode{Properties: props}))
+	}
+	return cameras
+}
+
+// newCamera builds a camera from the merged properties of a Camera2D node
+func (c *TSCNConverter) newCamera(node *sceneNode, scene string, camera *sceneNode) Camera {
+	props := camera.Properties
+	result := Camera{
+		Name:           node.Name,
+		Path:           node.Path,
+		Scene:          scene,
+		Transform:      outputTransform(c.nodes.globalTransform(node)),
+		Enabled:        props["enabled"] != "false",
+		AnchorMode:     "drag_center",
+		IgnoreRotation: props["ignore_rotation"] != "false",
+		Zoom:           Vec2{X: 1, Y: 1},
+		Groups:         c.nodeGroups(node),
+		NodeScript:     c.nodeScript(node),
+		Metadata:       c.nodeMetadata(node),
+	}
+	if camera.intProperty("anchor_mode", 1) == 0 {
+		result.AnchorMode = "fixed_top_left"
+	}
+	if value, exists := props["zoom"]; exists {
+		result.Zoom = parseVector2Value(value)
+	}
+	if value, exists := props["offset"]; exists {
+		result.Offset = outputVector(parseVector2Value(value))
+	}
+
+	// Limits are scene space edges; convert the corners so top and bottom follow the output Y axis
+	topLeft := outputPoint(Vec2{
+		X: float64(camera.intProperty("limit_left", -cameraLimit)),
+		Y: float64(camera.intProperty("limit_top", -cameraLimit)),
+	})
+	bottomRight := outputPoint(Vec2{
+		X: float64(camera.intProperty("limit_right", cameraLimit)),
+		Y: float64(camera.intProperty("limit_bottom", cameraLimit)),
+	})
+	result.Limits = CameraLimits{
+		Left:     topLeft.X,
+		Top:      topLeft.Y,
+		Right:    bottomRight.X,
+		Bottom:   bottomRight.Y,
+		Smoothed: props["limit_smoothed"] == "true",
+	}
+
+	result.Smoothing = CameraSmoothing{
+		PositionEnabled: props["position_smoothing_enabled"] == "true",
+		PositionSpeed:   floatProperty(props, "position_smoothing_speed", 5),
+		RotationEnabled: props["rotation_smoothing_enabled"] == "true",
+		RotationSpeed:   floatProperty(props, "rotation_smoothing_speed", 5),
+	}
+	result.Drag = CameraDrag{
+		HorizontalEnabled: props["drag_horizontal_enabled"] == "true",
+		VerticalEnabled:   props["drag_vertical_enabled"] == "true",
+		HorizontalOffset:  floatProperty(props, "drag_horizontal_offset", 0),
+		VerticalOffset:    floatProperty(props, "drag_vertical_offset", 0),
+		Left:              floatProperty(props, "drag_left_margin", 0.2),
+		Top:               floatProperty(props, "drag_top_margin", 0.2),
+		Right:             floatProperty(props, "drag_right_margin", 0.2),
+		Bottom:            floatProperty(props, "drag_bottom_margin", 0.2),
+	}
+	return result
+}
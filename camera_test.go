@@ -0,0 +1,39 @@
+package tscnparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCameras(t *testing.T) {
+	data := parseNodes(t, "", `
+[node name="Camera" type="Camera2D" parent="."]
+position = Vector2(40, 24)
+anchor_mode = 0
+zoom = Vector2(2, 2)
+offset = Vector2(0, 8)
+limit_left = -16
+limit_top = -32
+limit_right = 320
+limit_bottom = 160
+position_smoothing_enabled = true
+drag_left_margin = 0.5
+`)
+	// The scene space limits become the edges of the output rectangle they cover
+	want := []Camera{{
+		Name:           "Camera",
+		Path:           "Camera",
+		Transform:      Transform2D{Position: Vec2{X: 88, Y: -56}, Scale: Vec2{X: 1, Y: 1}},
+		Enabled:        true,
+		AnchorMode:     "fixed_top_left",
+		IgnoreRotation: true,
+		Zoom:           Vec2{X: 2, Y: 2},
+		Offset:         Vec2{X: 0, Y: -8},
+		Limits:         CameraLimits{Left: 32, Top: 0, Right: 368, Bottom: -192},
+		Smoothing:      CameraSmoothing{PositionEnabled: true, PositionSpeed: 5, RotationSpeed: 5},
+		Drag:           CameraDrag{Left: 0.5, Top: 0.2, Right: 0.2, Bottom: 0.2},
+	}}
+	if !reflect.DeepEqual(data.Cameras, want) {
+		t.Errorf("cameras = %+v\nwant %+v", data.Cameras, want)
+	}
+}
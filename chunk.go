@@ -0,0 +1,168 @@
+package tscnparser
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// TileBounds is an inclusive range of tile coordinates, in the output coordinates of tile_data
+type TileBounds struct {
+	MinX int `json:"min_x"`
+	MinY int `json:"min_y"`
+	MaxX int `json:"max_x"`
+	MaxY int `json:"max_y"`
+}
+
+func (b *TileBounds) add(x, y int, first bool) {
+	if first {
+		*b = TileBounds{MinX: x, MinY: y, MaxX: x, MaxY: y}
+		return
+	}
+	b.MinX, b.MinY = min(b.MinX, x), min(b.MinY, y)
+	b.MaxX, b.MaxY = max(b.MaxX, x), max(b.MaxY, y)
+}
+
+// Chunk holds the tiles of every layer within a square of ChunkSize tiles. Chunk (x, y)
+// covers the tiles from (x*size, y*size) to ((x+1)*size-1, (y+1)*size-1).
+type Chunk struct {
+	X      int          `json:"x"`
+	Y      int          `json:"y"`
+	Bounds TileBounds   `json:"bounds"` // bounds of the chunk's tiles
+	Layers []ChunkLayer `json:"layers"` // non-empty layers only
+}
+
+// ChunkLayer is the part of a layer within a chunk, in the tile_data format of Layer
+type ChunkLayer struct {
+	Layer     int   `json:"layer"` // index in the manifest's layers
+	TileData  []int `json:"tile_data"`
+	TileFlags []int `json:"tile_flags,omitempty"`
+}
+
+// ChunkManifest lists the chunks of a chunked tile map and the layers they hold
+type ChunkManifest struct {
+	ChunkSize int             `json:"chunk_size"` // in tiles
+	TileSize  TileSize        `json:"tile_size"`
+	Bounds    TileBounds      `json:"bounds"` // bounds of all tiles
+	Layers    []ManifestLayer `json:"layers"`
+	Chunks    []ManifestChunk `json:"chunks"`
+}
+
+// ManifestLayer describes a layer once for all chunks
+type ManifestLayer struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	ZIndex    int       `json:"z_index"`
+	DrawOrder DrawOrder `json:"draw_order"`
+}
+
+// ManifestChunk is the manifest entry of a chunk
+type ManifestChunk struct {
+	X      int        `json:"x"`
+	Y      int        `json:"y"`
+	File   string     `json:"file"`
+	Bounds TileBounds `json:"bounds"`
+	Layers []int      `json:"layers"` // indexes of the non-empty layers in the manifest's layers
+	Tiles  int        `json:"tiles"`  // number of tiles of all layers
+}
+
+// ChunkFileName returns the file name of a chunk listed in the manifest
+func ChunkFileName(x, y int) string {
+	return fmt.Sprintf("chunk_%d_%d.json", x, y)
+}
+
+// ChunkTiles partitions the tile layers into chunks of size x size tiles, so a runtime can
+// stream the chunks around the camera. Chunks are sorted by row and column.
+func ChunkTiles(tileMap *TileMapData, size int) (*ChunkManifest, []Chunk, error) {
+	if tileMap == nil {
+		return nil, nil, errors.New("no tile map to chunk")
+	}
+	if size <= 0 {
+		return nil, nil, fmt.Errorf("invalid chunk size %d", size)
+	}
+	manifest := &ChunkManifest{
+		ChunkSize: size,
+		TileSize:  tileMap.TileSize,
+		Layers:    []ManifestLayer{},
+		Chunks:    []ManifestChunk{},
+	}
+	type chunkKey struct{ x, y int }
+	chunks := make(map[chunkKey]*Chunk)
+	tiles := 0
+	for index, layer := range tileMap.Layers {
+		manifest.Layers = append(manifest.Layers, ManifestLayer{
+			ID:        layer.ID,
+			Name:      layer.Name,
+			ZIndex:    layer.ZIndex,
+			DrawOrder: layer.DrawOrder,
+		})
+		for i := 0; i+4 < len(layer.TileData); i += 5 {
+			x, y := layer.TileData[i+1], layer.TileData[i+2]
+			key := chunkKey{floorDiv(x, size), floorDiv(y, size)}
+			chunk, exists := chunks[key]
+			if !exists {
+				chunk = &Chunk{X: key.x, Y: key.y}
+				chunks[key] = chunk
+			}
+			chunk.Bounds.add(x, y, !exists)
+			manifest.Bounds.add(x, y, tiles == 0)
+			tiles++
+
+			// Layers are added in order, so the layer of the tile is the chunk's last one if it has it
+			if n := len(chunk.Layers); n == 0 || chunk.Layers[n-1].Layer != index {
+				chunk.Layers = append(chunk.Layers, ChunkLayer{Layer: index})
+			}
+			chunkLayer := &chunk.Layers[len(chunk.Layers)-1]
+			chunkLayer.TileData = append(chunkLayer.TileData, layer.TileData[i:i+5]...)
+			flags := 0
+			if i/5 < len(layer.TileFlags) {
+				flags = layer.TileFlags[i/5]
+			}
+			chunkLayer.TileFlags = append(chunkLayer.TileFlags, flags)
+		}
+	}
+
+	result := make([]Chunk, 0, len(chunks))
+	for _, chunk := range chunks {
+		result = append(result, *chunk)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Y != result[j].Y {
+			return result[i].Y < result[j].Y
+		}
+		return result[i].X < result[j].X
+	})
+	for i := range result {
+		chunk := &result[i]
+		entry := ManifestChunk{X: chunk.X, Y: chunk.Y, File: ChunkFileName(chunk.X, chunk.Y), Bounds: chunk.Bounds}
+		for j := range chunk.Layers {
+			layer := &chunk.Layers[j]
+			// Tile flags are only kept when a tile of the chunk layer is flipped or transposed
+			if !containsNonZero(layer.TileFlags) {
+				layer.TileFlags = nil
+			}
+			entry.Layers = append(entry.Layers, layer.Layer)
+			entry.Tiles += len(layer.TileData) / 5
+		}
+		manifest.Chunks = append(manifest.Chunks, entry)
+	}
+	return manifest, result, nil
+}
+
+func containsNonZero(values []int) bool {
+	for _, v := range values {
+		if v != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// floorDiv divides rounding towards negative infinity, so negative tiles fall in negative chunks
+func floorDiv(a, b int) int {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
@@ -0,0 +1,74 @@
+package tscnparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestChunkTiles(t *testing.T) {
+	tileMap := &TileMapData{
+		TileSize: TileSize{Width: 16, Height: 16},
+		Layers: []Layer{
+			{
+				ID:   0,
+				Name: "ground",
+				// (-1, -1) and (-4, -4) are in chunk (-1, -1), (-5, 0) in chunk (-2, 0)
+				TileData: []int{0, -1, -1, 0, 0, 0, -4, -4, 1, 0, 0, -5, 0, 0, 0, 0, 0, 0, 0, 0, 0, 3, 5, 0, 0},
+				// Fewer flags than tiles: the others are not flipped
+				TileFlags: []int{0, TileFlipV},
+			},
+			{ID: 1, Name: "empty"},
+			{ID: 2, Name: "top", ZIndex: 1, TileData: []int{0, 2, 1, 0, 0}, TileFlags: []int{TileFlipH}},
+		},
+	}
+	manifest, chunks, err := ChunkTiles(tileMap, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantChunks := []Chunk{
+		{X: -1, Y: -1, Bounds: TileBounds{MinX: -4, MinY: -4, MaxX: -1, MaxY: -1}, Layers: []ChunkLayer{
+			{Layer: 0, TileData: []int{0, -1, -1, 0, 0, 0, -4, -4, 1, 0}, TileFlags: []int{0, TileFlipV}},
+		}},
+		{X: -2, Y: 0, Bounds: TileBounds{MinX: -5, MinY: 0, MaxX: -5, MaxY: 0}, Layers: []ChunkLayer{
+			{Layer: 0, TileData: []int{0, -5, 0, 0, 0}},
+		}},
+		{X: 0, Y: 0, Bounds: TileBounds{MinX: 0, MinY: 0, MaxX: 2, MaxY: 1}, Layers: []ChunkLayer{
+			{Layer: 0, TileData: []int{0, 0, 0, 0, 0}},
+			{Layer: 2, TileData: []int{0, 2, 1, 0, 0}, TileFlags: []int{TileFlipH}},
+		}},
+		{X: 0, Y: 1, Bounds: TileBounds{MinX: 3, MinY: 5, MaxX: 3, MaxY: 5}, Layers: []ChunkLayer{
+			{Layer: 0, TileData: []int{0, 3, 5, 0, 0}},
+		}},
+	}
+	if !reflect.DeepEqual(chunks, wantChunks) {
+		t.Errorf("chunks = %+v\nwant %+v", chunks, wantChunks)
+	}
+
+	wantManifest := &ChunkManifest{
+		ChunkSize: 4,
+		TileSize:  TileSize{Width: 16, Height: 16},
+		Bounds:    TileBounds{MinX: -5, MinY: -4, MaxX: 3, MaxY: 5},
+		Layers: []ManifestLayer{
+			{ID: 0, Name: "ground"},
+			{ID: 1, Name: "empty"},
+			{ID: 2, Name: "top", ZIndex: 1},
+		},
+		Chunks: []ManifestChunk{
+			{X: -1, Y: -1, File: "chunk_-1_-1.json", Bounds: wantChunks[0].Bounds, Layers: []int{0}, Tiles: 2},
+			{X: -2, Y: 0, File: "chunk_-2_0.json", Bounds: wantChunks[1].Bounds, Layers: []int{0}, Tiles: 1},
+			{X: 0, Y: 0, File: "chunk_0_0.json", Bounds: wantChunks[2].Bounds, Layers: []int{0, 2}, Tiles: 2},
+			{X: 0, Y: 1, File: "chunk_0_1.json", Bounds: wantChunks[3].Bounds, Layers: []int{0}, Tiles: 1},
+		},
+	}
+	if !reflect.DeepEqual(manifest, wantManifest) {
+		t.Errorf("manifest = %+v\nwant %+v", manifest, wantManifest)
+	}
+
+	if _, _, err := ChunkTiles(tileMap, 0); err == nil {
+		t.Error("chunk size 0: expected an error")
+	}
+	if _, _, err := ChunkTiles(nil, 4); err == nil {
+		t.Error("no tile map: expected an error")
+	}
+}
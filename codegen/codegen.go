@@ -0,0 +1,429 @@
+// Package codegen renders parsed scene data as Go source code, so a level can be compiled
+// into a game instead of being loaded from JSON at runtime.
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// ImportPath is the import path of the parser package, whose types the generated code mirrors
+const ImportPath = "github.com/JiepengTan/tscn_parser"
+
+// Options configure the generated source
+type Options struct {
+	Package    string // package clause, "main" if empty
+	VarName    string // name of the generated variable, "MapData" if empty
+	TypePrefix string // prefix of the declared types, "tscn" if empty; unused with ImportTypes
+	// ImportTypes references the parser's types through an import instead of declaring
+	// prefixed copies, so the variable can be passed to the library directly
+	ImportTypes bool
+	Source      string // name of the scene the data was parsed from, for the header comment
+}
+
+// Generate returns gofmt-formatted Go source declaring a variable with the given data.
+// The output only depends on the data and options, so it is suitable for checking in.
+func Generate(data *tscnparser.MapData, opts Options) ([]byte, error) {
+	if data == nil {
+		return nil, fmt.Errorf("no data to generate")
+	}
+	if opts.Package == "" {
+		opts.Package = "main"
+	}
+	if opts.VarName == "" {
+		opts.VarName = "MapData"
+	}
+	if opts.TypePrefix == "" {
+		opts.TypePrefix = "tscn"
+	}
+
+	g := &generator{opts: opts, libPath: reflect.TypeOf(*data).PkgPath(), types: make(map[string]reflect.Type)}
+	value := reflect.ValueOf(data)
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "var %s = ", opts.VarName)
+	if err := g.writeValue(&body, value, false); err != nil {
+		return nil, err
+	}
+	body.WriteString("\n")
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by tscn_parser")
+	if opts.Source != "" {
+		fmt.Fprintf(&out, " from %s", opts.Source)
+	}
+	out.WriteString(". DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", opts.Package)
+	var imports []string
+	if g.usesMath {
+		imports = append(imports, strconv.Quote("math"))
+	}
+	if opts.ImportTypes {
+		imports = append(imports, "tscnparser "+strconv.Quote(ImportPath))
+	}
+	if len(imports) > 0 {
+		fmt.Fprintf(&out, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+	if !opts.ImportTypes {
+		if err := g.writeTypes(&out); err != nil {
+			return nil, err
+		}
+	}
+	out.Write(body.Bytes())
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return source, nil
+}
+
+type generator struct {
+	opts     Options
+	libPath  string
+	types    map[string]reflect.Type // parser types used by the data, by name
+	usesMath bool
+}
+
+// isLibType reports whether t is a named type of the parser package
+func (g *generator) isLibType(t reflect.Type) bool {
+	return t.Name() != "" && t.PkgPath() == g.libPath
+}
+
+// typeName returns the name of a parser type as written in the generated code
+func (g *generator) typeName(t reflect.Type) string {
+	if g.opts.ImportTypes {
+		return "tscnparser." + t.Name()
+	}
+	return g.opts.TypePrefix + t.Name()
+}
+
+// localName returns the unqualified name of a parser type, as used for embedded fields
+func (g *generator) localName(t reflect.Type) string {
+	if g.opts.ImportTypes {
+		return t.Name()
+	}
+	return g.opts.TypePrefix + t.Name()
+}
+
+// typeExpr returns the Go type expression for t, registering the parser types it uses
+func (g *generator) typeExpr(t reflect.Type) (string, error) {
+	if g.isLibType(t) {
+		if _, seen := g.types[t.Name()]; !seen {
+			g.types[t.Name()] = t
+			// Register the types of the fields as well, even if the data leaves them empty
+			if _, err := g.underlyingExpr(t); err != nil {
+				return "", err
+			}
+		}
+		return g.typeName(t), nil
+	}
+	if t.Name() != "" {
+		if t.PkgPath() != "" {
+			return "", fmt.Errorf("unsupported type %s", t)
+		}
+		return t.Name(), nil
+	}
+	return g.underlyingExpr(t)
+}
+
+// underlyingExpr returns the type literal of t's underlying type
+func (g *generator) underlyingExpr(t reflect.Type) (string, error) {
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, err := g.typeExpr(t.Elem())
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := g.typeExpr(t.Elem())
+		return "[]" + elem, err
+	case reflect.Map:
+		key, err := g.typeExpr(t.Key())
+		if err != nil {
+			return "", err
+		}
+		elem, err := g.typeExpr(t.Elem())
+		return "map[" + key + "]" + elem, err
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "any", nil
+		}
+	case reflect.Struct:
+		var sb strings.Builder
+		sb.WriteString("struct {\n")
+		for _, field := range exportedFields(t) {
+			fieldType, err := g.typeExpr(field.Type)
+			if err != nil {
+				return "", err
+			}
+			if field.Anonymous {
+				sb.WriteString(fieldType)
+			} else {
+				sb.WriteString(field.Name + " " + fieldType)
+			}
+			if field.Tag != "" {
+				sb.WriteString(" `" + string(field.Tag) + "`")
+			}
+			sb.WriteString("\n")
+		}
+		sb.WriteString("}")
+		return sb.String(), nil
+	default:
+		if t.Kind() <= reflect.Complex128 || t.Kind() == reflect.String {
+			return t.Kind().String(), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported type %s", t)
+}
+
+// writeTypes declares every parser type used by the data, sorted by name
+func (g *generator) writeTypes(out *bytes.Buffer) error {
+	names := make([]string, 0, len(g.types))
+	for name := range g.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		underlying, err := g.underlyingExpr(g.types[name])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "type %s %s\n\n", g.typeName(g.types[name]), underlying)
+	}
+	return nil
+}
+
+// exportedFields returns the fields of a struct that are encoded, skipping those tagged json:"-"
+func exportedFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || field.Tag.Get("json") == "-" {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// basicSliceWrap is the number of elements per line in slices of numbers and strings
+const basicSliceWrap = 16
+
+// writeValue writes a composite literal or constant for v. inInterface is set when the value
+// is stored in an interface, where untyped constants must keep their dynamic type.
+func (g *generator) writeValue(out *bytes.Buffer, v reflect.Value, inInterface bool) error {
+	return g.writeValueElided(out, v, inInterface, false)
+}
+
+// writeValueElided is writeValue that can omit the type of composite literals, as allowed
+// for the elements of slice and map literals
+func (g *generator) writeValueElided(out *bytes.Buffer, v reflect.Value, inInterface, elide bool) error {
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.Bool:
+		out.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		out.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		out.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		out.WriteString(g.formatFloat(v.Float(), inInterface))
+	case reflect.String:
+		out.WriteString(strconv.Quote(v.String()))
+	case reflect.Interface:
+		if v.IsNil() {
+			out.WriteString("nil")
+			return nil
+		}
+		return g.writeValue(out, v.Elem(), true)
+	case reflect.Pointer:
+		if v.IsNil() {
+			out.WriteString("nil")
+			return nil
+		}
+		if t.Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("unsupported pointer type %s", t)
+		}
+		if !elide {
+			out.WriteString("&")
+		}
+		return g.writeValueElided(out, v.Elem(), false, elide)
+	case reflect.Struct:
+		return g.writeStruct(out, v, elide)
+	case reflect.Slice:
+		if v.IsNil() && !inInterface {
+			out.WriteString("nil")
+			return nil
+		}
+		return g.writeSlice(out, v, elide)
+	case reflect.Map:
+		if v.IsNil() && !inInterface {
+			out.WriteString("nil")
+			return nil
+		}
+		return g.writeMap(out, v, elide)
+	default:
+		return fmt.Errorf("unsupported value of type %s", t)
+	}
+	return nil
+}
+
+func (g *generator) formatFloat(f float64, inInterface bool) string {
+	switch {
+	case math.IsInf(f, 1):
+		g.usesMath = true
+		return "math.Inf(1)"
+	case math.IsInf(f, -1):
+		g.usesMath = true
+		return "math.Inf(-1)"
+	case math.IsNaN(f):
+		g.usesMath = true
+		return "math.NaN()"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	// Untyped integer constants would become int inside an interface
+	if inInterface && !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+func (g *generator) writeStruct(out *bytes.Buffer, v reflect.Value, elide bool) error {
+	t := v.Type()
+	if !elide {
+		name, err := g.typeExpr(t)
+		if err != nil {
+			return err
+		}
+		out.WriteString(name)
+	}
+	// Structs of plain values, such as positions, fit on one line
+	inline := true
+	for _, field := range exportedFields(t) {
+		if kind := field.Type.Kind(); kind > reflect.Complex128 && kind != reflect.String {
+			inline = false
+		}
+	}
+	out.WriteString("{")
+	separator := "\n"
+	if inline {
+		separator = ", "
+	} else {
+		out.WriteString("\n")
+	}
+	first := true
+	for _, field := range exportedFields(t) {
+		fv := v.FieldByIndex(field.Index)
+		if fv.IsZero() {
+			continue
+		}
+		if inline && !first {
+			out.WriteString(separator)
+		}
+		first = false
+		name := field.Name
+		if field.Anonymous {
+			name = g.localName(field.Type)
+		}
+		out.WriteString(name + ": ")
+		if err := g.writeValue(out, fv, false); err != nil {
+			return err
+		}
+		if !inline {
+			out.WriteString(",\n")
+		}
+	}
+	out.WriteString("}")
+	return nil
+}
+
+func (g *generator) writeSlice(out *bytes.Buffer, v reflect.Value, elide bool) error {
+	t := v.Type()
+	if !elide {
+		name, err := g.typeExpr(t)
+		if err != nil {
+			return err
+		}
+		out.WriteString(name)
+	}
+	out.WriteString("{")
+	if v.Len() == 0 {
+		out.WriteString("}")
+		return nil
+	}
+	elem := t.Elem()
+	elideElems := isElidable(elem)
+	basic := elem.Kind() <= reflect.Complex128 || elem.Kind() == reflect.String
+	out.WriteString("\n")
+	for i := 0; i < v.Len(); i++ {
+		if err := g.writeValueElided(out, v.Index(i), elem.Kind() == reflect.Interface, elideElems); err != nil {
+			return err
+		}
+		if basic && (i+1)%basicSliceWrap != 0 && i+1 < v.Len() {
+			out.WriteString(", ")
+		} else {
+			out.WriteString(",\n")
+		}
+	}
+	out.WriteString("}")
+	return nil
+}
+
+func (g *generator) writeMap(out *bytes.Buffer, v reflect.Value, elide bool) error {
+	t := v.Type()
+	if !elide {
+		name, err := g.typeExpr(t)
+		if err != nil {
+			return err
+		}
+		out.WriteString(name)
+	}
+	out.WriteString("{")
+	if v.Len() == 0 {
+		out.WriteString("}")
+		return nil
+	}
+	// Sort the keys by their literal so the output is stable
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+	var entries []entry
+	iter := v.MapRange()
+	for iter.Next() {
+		var key bytes.Buffer
+		if err := g.writeValue(&key, iter.Key(), false); err != nil {
+			return err
+		}
+		entries = append(entries, entry{key: key.String(), value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+	out.WriteString("\n")
+	elem := t.Elem()
+	for _, e := range entries {
+		out.WriteString(e.key + ": ")
+		if err := g.writeValueElided(out, e.value, elem.Kind() == reflect.Interface, isElidable(elem)); err != nil {
+			return err
+		}
+		out.WriteString(",\n")
+	}
+	out.WriteString("}")
+	return nil
+}
+
+// isElidable reports whether composite literals of type t may omit their type inside a slice or map literal
+func isElidable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct:
+		return true
+	case reflect.Pointer:
+		return t.Elem().Kind() == reflect.Struct
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"math"
+	"testing"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// testMap has every kind of property value, and enough keys that a map written in
+// iteration order would come out differently between runs
+func testMap() *tscnparser.MapData {
+	properties := tscnparser.Properties{
+		"nil":      nil,
+		"bool":     true,
+		"int":      -42,
+		"float":    0.1,
+		"inf":      math.Inf(1),
+		"nan":      math.NaN(),
+		"string":   "a \"quoted\"\nline",
+		"vec2":     tscnparser.Vec2{X: 1, Y: 2.5},
+		"vec2i":    tscnparser.Vec2i{X: 3, Y: -4},
+		"color":    tscnparser.Color{R: 1, G: 0.5, B: 0.25, A: 1},
+		"rect":     tscnparser.Rect2{X: 1, Y: 2, Width: 3, Height: 4},
+		"resource": tscnparser.ResourceRef{Kind: "ExtResource", ID: "1_abc", Path: "res://key.tres"},
+		"variant":  tscnparser.Variant{Type: "Vector3", Args: []any{1.0, 2.0, 3.5}},
+		"array":    []any{1, "two", []any{}, map[string]any{"nested": tscnparser.Vec2{X: 0.5}}},
+		"dict":     map[string]any{"hp": 10, "name": "chest", "pos": tscnparser.Vec2i{X: 1}},
+	}
+	for i := 0; i < 16; i++ {
+		properties[fmt.Sprintf("key%02d", i)] = i
+	}
+	return &tscnparser.MapData{
+		SchemaVersion: tscnparser.SchemaVersion,
+		TileMap: tscnparser.TileMapData{
+			Format:   2,
+			TileSize: tscnparser.TileSize{Width: 16, Height: 16},
+			Layers:   []tscnparser.Layer{{Name: "ground", TileData: []int{0, 1, -1, 0, 0}}},
+			Metadata: tscnparser.Properties{"biome": "forest"},
+		},
+		Sprites: []tscnparser.SpriteNode{{
+			Name:       "Chest",
+			Parent:     ".",
+			Position:   tscnparser.Vec2{X: 12.5, Y: -3},
+			Rotation:   0.5,
+			Path:       "res://chest.tscn",
+			Properties: properties,
+		}},
+		Connections: []tscnparser.Connection{{Signal: "opened", From: "Chest", To: ".", Method: "_on_opened", Binds: tscnparser.Values{1, "a"}}},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	for _, importTypes := range []bool{false, true} {
+		opts := Options{Package: "levels", VarName: "Level", ImportTypes: importTypes, Source: "level.tscn"}
+		first, err := Generate(testMap(), opts)
+		if err != nil {
+			t.Fatalf("import types %v: %v", importTypes, err)
+		}
+		second, err := Generate(testMap(), opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(first, second) {
+			t.Errorf("import types %v: output differs between runs", importTypes)
+		}
+		formatted, err := format.Source(first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(formatted, first) {
+			t.Errorf("import types %v: output is not gofmt-formatted", importTypes)
+		}
+
+		fset := token.NewFileSet()
+		file, err := parser.ParseFile(fset, "level.go", first, parser.ParseComments)
+		if err != nil {
+			t.Fatalf("import types %v: %v", importTypes, err)
+		}
+		if !ast.IsGenerated(file) || file.Name.Name != "levels" {
+			t.Errorf("import types %v: header %q, package %s", importTypes, file.Doc.Text(), file.Name.Name)
+		}
+		if importTypes {
+			continue
+		}
+		// The self-contained source declares every type it uses
+		config := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+		if _, err := config.Check("levels", fset, []*ast.File{file}, nil); err != nil {
+			t.Errorf("type check: %v", err)
+		}
+	}
+}
+
+func TestGenerateNil(t *testing.T) {
+	if _, err := Generate(nil, Options{}); err == nil {
+		t.Error("nil data: expected an error")
+	}
+}
@@ -0,0 +1,209 @@
+package tscnparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Collider types and the layout of their ColliderParams:
+//
+//	"rect"     [width, height]                  RectangleShape2D
+//	"circle"   [radius]                         CircleShape2D
+//	"capsule"  [radius, height]                 CapsuleShape2D (height is the full height, including both caps)
+//	"segment"  [ax, ay, bx, by]                 SegmentShape2D
+//	"ray"      [length, slide_on_slope]         SeparationRayShape2D (slide_on_slope is 0 or 1)
+//	"boundary" [normal_x, normal_y, distance]   WorldBoundaryShape2D
+//	"polygon"  [x0, y0, x1, y1, ...]            ConvexPolygonShape2D, CollisionPolygon2D in solids mode
+//	"concave"  [ax0, ay0, bx0, by0, ...]        ConcavePolygonShape2D, CollisionPolygon2D in segments mode (one segment per point pair)
+//	"auto"     []                               shape could not be resolved
+//
+// All coordinates are local to the collision node, which carries its own
+// position (ColliderPivot), rotation and scale.
+const (
+	ColliderRect     = "rect"
+	ColliderCircle   = "circle"
+	ColliderCapsule  = "capsule"
+	ColliderSegment  = "segment"
+	ColliderRay      = "ray"
+	ColliderBoundary = "boundary"
+	ColliderPolygon  = "polygon"
+	ColliderConcave  = "concave"
+	ColliderAuto     = "auto"
+)
+
+// parseShapeProperty parses a property of a Shape2D sub_resource
+func (c *TSCNConverter) parseShapeProperty(shape *ShapeInfo, line string) {
+	key, value, ok := splitProperty(line)
+	if !ok {
+		return
+	}
+	switch key {
+	case "size":
+		// RectangleShape2D
+		shape.Dimensions = c.extractVector2(line)
+	case "radius":
+		// CircleShape2D, CapsuleShape2D
+		shape.Dimensions.X, _ = strconv.ParseFloat(value, 64)
+	case "height":
+		// CapsuleShape2D
+		shape.Dimensions.Y, _ = strconv.ParseFloat(value, 64)
+	case "points", "segments":
+		// ConvexPolygonShape2D points, ConcavePolygonShape2D segments
+		shape.Points = nil
+		for _, p := range c.extractPolygonPoints(line) {
+			shape.Points = append(shape.Points, p.X, p.Y)
+		}
+	case "a":
+		// SegmentShape2D
+		shape.A = c.extractVector2(line)
+	case "b":
+		shape.B = c.extractVector2(line)
+	case "length":
+		// SeparationRayShape2D
+		shape.Length, _ = strconv.ParseFloat(value, 64)
+	case "slide_on_slope":
+		shape.SlideOnSlope = value == "true"
+	case "normal":
+		// WorldBoundaryShape2D
+		shape.Normal = c.extractVector2(line)
+	case "distance":
+		shape.Distance, _ = strconv.ParseFloat(value, 64)
+	}
+}
+
+// newShapeInfo creates a shape with Godot's default parameters for its type
+func newShapeInfo(shapeType string) *ShapeInfo {
+	shape := &ShapeInfo{Type: shapeType}
+	switch shapeType {
+	case "RectangleShape2D":
+		shape.Dimensions = Vec2{X: 20, Y: 20}
+	case "CircleShape2D":
+		shape.Dimensions = Vec2{X: 10}
+	case "CapsuleShape2D":
+		shape.Dimensions = Vec2{X: 10, Y: 30}
+	case "SegmentShape2D":
+		shape.B = Vec2{X: 0, Y: 10}
+	case "SeparationRayShape2D":
+		shape.Length = 20
+	case "WorldBoundaryShape2D":
+		shape.Normal = Vec2{X: 0, Y: -1}
+	}
+	return shape
+}
+
+// colliderParams converts a shape to its collider type and ColliderParams
+func (shape *ShapeInfo) colliderParams() (string, []float64) {
+	switch shape.Type {
+	case "RectangleShape2D":
+		return ColliderRect, []float64{shape.Dimensions.X, shape.Dimensions.Y}
+	case "CircleShape2D":
+		return ColliderCircle, []float64{shape.Dimensions.X}
+	case "CapsuleShape2D":
+		return ColliderCapsule, []float64{shape.Dimensions.X, shape.Dimensions.Y}
+	case "SegmentShape2D":
+		return ColliderSegment, []float64{shape.A.X, shape.A.Y, shape.B.X, shape.B.Y}
+	case "SeparationRayShape2D":
+		slide := 0.0
+		if shape.SlideOnSlope {
+			slide = 1
+		}
+		return ColliderRay, []float64{shape.Length, slide}
+	case "WorldBoundaryShape2D":
+		return ColliderBoundary, []float64{shape.Normal.X, shape.Normal.Y, shape.Distance}
+	case "ConvexPolygonShape2D":
+		return ColliderPolygon, shape.Points
+	case "ConcavePolygonShape2D":
+		return ColliderConcave, shape.Points
+	}
+	return ColliderAuto, nil
+}
+
+// physicsBodyTypes are the CollisionObject2D node types that own collision shapes
+var physicsBodyTypes = map[string]bool{
+	"StaticBody2D":     true,
+	"AnimatableBody2D": true,
+	"RigidBody2D":      true,
+	"CharacterBody2D":  true,
+	"Area2D":           true,
+	"PhysicalBone2D":   true,
+}
+
+func isPhysicsBody(nodeType string) bool {
+	return physicsBodyTypes[nodeType]
+}
+
+// newCollisionShape builds the shape of a CollisionShape2D or CollisionPolygon2D node,
+// resolving shape sub_resources against the given map. The transform is the node's local one.
+func (c *TSCNConverter) newCollisionShape(node *sceneNode, shapes map[string]*ShapeInfo) CollisionShape {
+	shape := CollisionShape{
+		Name:         node.Name,
+		Transform:    node.transform(),
+		OneWayMargin: 1,
+	}
+	props := node.Properties
+	shape.Disabled = props["disabled"] == "true"
+	shape.OneWay = props["one_way_collision"] == "true"
+	if value, exists := props["one_way_collision_margin"]; exists {
+		shape.OneWayMargin, _ = strconv.ParseFloat(value, 64)
+	}
+
+	shape.Type = ColliderAuto
+	if value, exists := props["polygon"]; exists {
+		var polygon []float64
+		for _, p := range c.extractPolygonPoints(value) {
+			polygon = append(polygon, p.X, p.Y)
+		}
+		// CollisionPolygon2D build_mode: 0 solids, 1 segments
+		if node.intProperty("build_mode", 0) == 1 {
+			shape.Type, shape.Params = ColliderConcave, polygonToSegments(polygon)
+		} else {
+			shape.Type, shape.Params = ColliderPolygon, polygon
+		}
+	} else if value, exists := props["shape"]; exists {
+		if info, found := shapes[c.extractSubResourceReference(value)]; found {
+			shape.Type, shape.Params = info.colliderParams()
+		}
+	}
+	return shape
+}
+
+// newCollider builds a collider for a collision node, relating it to its owning physics body
+func (c *TSCNConverter) newCollider(tree *nodeTree, node *sceneNode, shapes map[string]*ShapeInfo) Collider {
+	collider := Collider{
+		CollisionShape: c.newCollisionShape(node, shapes),
+		BodyTransform:  IdentityTransform,
+		CollisionLayer: 1,
+		CollisionMask:  1,
+	}
+	body := tree.ancestor(node, isPhysicsBody)
+	if body == nil {
+		// Shapes without a body are kept relative to the root node
+		collider.Transform = tree.relativeTransform(".", node)
+		return collider
+	}
+	collider.Body = body.Type
+	collider.BodyPath = body.Path
+	collider.CollisionLayer = uint32(body.intProperty("collision_layer", 1))
+	collider.CollisionMask = uint32(body.intProperty("collision_mask", 1))
+	collider.Transform = tree.relativeTransform(body.Path, node)
+	if body.Path != "." {
+		collider.BodyTransform = tree.relativeTransform(".", body)
+	}
+	return collider
+}
+
+// polygonToSegments converts a closed polygon outline to segment point pairs
+func polygonToSegments(points []float64) []float64 {
+	count := len(points) / 2
+	segments := make([]float64, 0, count*4)
+	for i := 0; i < count; i++ {
+		j := (i + 1) % count
+		segments = append(segments, points[i*2], points[i*2+1], points[j*2], points[j*2+1])
+	}
+	return segments
+}
+
+// isCollisionNodeLine reports whether a node header declares a collision node
+func isCollisionNodeLine(line string) bool {
+	return strings.Contains(line, "type=\"CollisionShape2D\"") || strings.Contains(line, "type=\"CollisionPolygon2D\"")
+}
@@ -0,0 +1,82 @@
+package tscnparser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Connection is a signal connection declared with a [connection] section
+type Connection struct {
+	Signal  string   `json:"signal"`
+	From    string   `json:"from"` // path of the emitting node, "." for the root node
+	To      string   `json:"to"`   // path of the receiving node
+	Method  string   `json:"method"`
+	Flags   int64    `json:"flags,omitempty"` // ConnectFlags: 1 deferred, 2 persist, 4 one shot, 8 reference counted
+	Binds   Values   `json:"binds,omitempty"`
+	Unbinds int64    `json:"unbinds,omitempty"`
+	FromRef *NodeRef `json:"from_ref,omitempty"` // exported entry of the emitting node
+	ToRef   *NodeRef `json:"to_ref,omitempty"`   // exported entry of the receiving node
+}
+
+// NodeRef identifies an exported entry of MapData, e.g. {"decorator", 3} for Decorators[3].
+// Parallax sprites are indexed within their layer: {"parallax_sprite", 2, 1} is
+// Parallax[2].Sprites[1].
+type NodeRef struct {
+	Kind  string `json:"kind"`            // same kinds as GroupMember
+	Layer int    `json:"layer,omitempty"` // index of the parallax layer of a parallax_sprite, zero for other kinds
+	Index int    `json:"index"`
+}
+
+var connectionAttrRe = regexp.MustCompile(`(\w+)=("(?:[^"\\]|\\.)*"|-?\d+)`)
+
+// parseConnection parses a [connection ...] section header
+func parseConnection(line string) Connection {
+	var conn Connection
+	// binds hold arbitrary values; parse them first and keep them out of the attribute scan
+	if start := strings.Index(line, " binds="); start >= 0 {
+		p := &variantParser{src: line[start+len(" binds="):]}
+		if value, err := p.parseValue(); err == nil {
+			if binds, ok := typedValue(value).([]any); ok && len(binds) > 0 {
+				conn.Binds = binds
+			}
+			line = line[:start] + line[start+len(" binds=")+p.pos:]
+		}
+	}
+	for _, match := range connectionAttrRe.FindAllStringSubmatch(line, -1) {
+		value := match[2]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		switch match[1] {
+		case "signal":
+			conn.Signal = value
+		case "from":
+			conn.From = value
+		case "to":
+			conn.To = value
+		case "method":
+			conn.Method = value
+		case "flags":
+			conn.Flags, _ = strconv.ParseInt(value, 10, 64)
+		case "unbinds":
+			conn.Unbinds, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+	return conn
+}
+
+// resolveConnections points the connections at the exported entries of their nodes
+func (m *MapData) resolveConnections() {
+	refs := make(map[string]*NodeRef)
+	m.eachNode(func(ref NodeRef, path string, groups []string, node any) {
+		if _, exists := refs[path]; !exists {
+			refs[path] = &ref
+		}
+	})
+	for i := range m.Connections {
+		conn := &m.Connections[i]
+		conn.FromRef = refs[conn.From]
+		conn.ToRef = refs[conn.To]
+	}
+}
@@ -0,0 +1,32 @@
+package tscnparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveConnections(t *testing.T) {
+	data := &MapData{
+		Markers: []Marker{{Name: "Spawn", Path: "Spawn"}},
+		Parallax: []ParallaxLayer{
+			{Path: "Sky", Sprites: []ParallaxSprite{{Path: "Sky/Sun"}}},
+			{Path: "Hills", Sprites: []ParallaxSprite{{Path: "Hills/Tree"}, {Path: "Hills/Rock"}}},
+		},
+		Connections: []Connection{
+			{From: "Sky/Sun", To: "Hills/Rock"},
+			{From: "Spawn", To: "Hills"},
+			{From: "Missing", To: "Sky"},
+		},
+	}
+	data.resolveConnections()
+	want := []struct{ from, to *NodeRef }{
+		{&NodeRef{Kind: "parallax_sprite", Layer: 0, Index: 0}, &NodeRef{Kind: "parallax_sprite", Layer: 1, Index: 1}},
+		{&NodeRef{Kind: "marker", Index: 0}, &NodeRef{Kind: "parallax_layer", Index: 1}},
+		{nil, &NodeRef{Kind: "parallax_layer", Index: 0}},
+	}
+	for i, conn := range data.Connections {
+		if !reflect.DeepEqual(conn.FromRef, want[i].from) || !reflect.DeepEqual(conn.ToRef, want[i].to) {
+			t.Errorf("connection %d: from %+v, to %+v, want %+v, %+v", i, conn.FromRef, conn.ToRef, want[i].from, want[i].to)
+		}
+	}
+}
@@ -19,12 +19,17 @@ func ConvertToTilemap(data *MapData) {
 	// Process each sprite and convert to decorator
 	for _, sprite := range data.Sprites {
 		decorator := DecoratorNode{
-			Name:     sprite.Name,
-			Parent:   sprite.Parent,
-			Path:     sprite.Path,
-			Position: sprite.Position,
-			Scale:    sprite.Scale,
-			Ratation: sprite.Ratation,
+			Name:       sprite.Name,
+			Parent:     sprite.Parent,
+			Path:       sprite.Path,
+			Position:   sprite.Position,
+			Scale:      sprite.Scale,
+			Rotation:   sprite.Rotation,
+			DrawOrder:  sprite.DrawOrder,
+			NodePath:   joinNodePath(sprite.Parent, sprite.Name),
+			Groups:     sprite.Groups,
+			Metadata:   sprite.Metadata,
+			NodeScript: sprite.NodeScript,
 		}
 		// If there's a matching prefab, merge its data
 		if prefab, exists := prefabMap[sprite.Path]; exists {
@@ -35,6 +40,11 @@ func ConvertToTilemap(data *MapData) {
 
 			// IMPORTANT: Use the texture path from prefab for rendering
 			decorator.Path = prefab.Texture
+			decorator.TextureRef = prefab.TextureRef
+			decorator.Hframes = prefab.Hframes
+			decorator.Vframes = prefab.Vframes
+			decorator.Frame = prefab.Frame
+			decorator.Animations = prefab.Animations
 			// Use pivot from prefab if available
 			decorator.Pivot = prefab.Pivot
 
@@ -42,9 +52,7 @@ func ConvertToTilemap(data *MapData) {
 			decorator.ZIndex = prefab.ZIndex
 
 			// Copy collision data from prefab
-			decorator.ColliderType = prefab.ColliderType
-			decorator.ColliderPivot = prefab.ColliderPivot
-			decorator.ColliderParams = prefab.ColliderParams
+			decorator.PrefabColliders = prefab.PrefabColliders
 
 			decorator.Scale.X *= prefab.Scale.X
 			decorator.Scale.Y *= prefab.Scale.Y
@@ -69,9 +77,6 @@ func ConvertToTilemap(data *MapData) {
 	//data.Prefabs = nil
 	for idx := range data.Decorators {
 		item := &data.Decorators[idx]
-		//item.Position.InvertY()
-		item.ColliderPivot.InvertY()
 		item.Parent = ""
-		//item.Pivot.InvertY()
 	}
 }
@@ -0,0 +1,220 @@
+package tscnparser
+
+import (
+	"fmt"
+	"math"
+)
+
+// CoordinateSystem selects the coordinates positions are written in
+type CoordinateSystem string
+
+// Coordinate systems. In the Y-up systems every Y component, including the Y of offsets,
+// pivots and local polygon points, is negated and every rotation and skew changes sign,
+// so counterclockwise on screen stays counterclockwise in the output.
+const (
+	// CoordinatesYUp has the Y axis pointing up and the origin at the scene origin shifted
+	// by the offset. It is the default.
+	CoordinatesYUp CoordinateSystem = "y-up"
+	// CoordinatesGodot keeps Godot's axes, with the Y axis pointing down, and the origin at
+	// the scene origin shifted by the offset
+	CoordinatesGodot CoordinateSystem = "godot"
+	// CoordinatesYUpBottom has the Y axis pointing up and the origin at the bottom left
+	// corner of the tile map
+	CoordinatesYUpBottom CoordinateSystem = "y-up-bottom"
+	// CoordinatesCentered has the Y axis pointing up and the origin at the center of the
+	// tile map, rounded to a tile corner
+	CoordinatesCentered CoordinateSystem = "centered"
+)
+
+// CoordinateSystems lists the supported coordinate systems
+var CoordinateSystems = []CoordinateSystem{CoordinatesYUp, CoordinatesGodot, CoordinatesYUpBottom, CoordinatesCentered}
+
+// Coordinates maps scene positions to output positions. Data read without it, from before
+// it was recorded, is in CoordinatesYUp with its origin at the scene origin.
+type Coordinates struct {
+	System CoordinateSystem `json:"system"`
+	Origin Vec2             `json:"origin"` // scene position of the output origin
+}
+
+// newCoordinates returns the coordinates of a system for a scene whose tiles cover the
+// given cells, inclusive; tiles is false when the scene has none
+func newCoordinates(system CoordinateSystem, offset Vec2, tileSize TileSize, tiles bool, minX, minY, maxX, maxY int) Coordinates {
+	coords := Coordinates{System: system, Origin: Vec2{X: -offset.X, Y: -offset.Y}}
+	if !tiles {
+		if system == CoordinatesYUpBottom || system == CoordinatesCentered {
+			coords.Origin = Vec2{}
+		}
+		return coords
+	}
+	width, height := float64(tileSize.Width), float64(tileSize.Height)
+	switch system {
+	case CoordinatesYUpBottom:
+		coords.Origin = Vec2{X: float64(minX) * width, Y: float64(maxY+1) * height}
+	case CoordinatesCentered:
+		coords.Origin = Vec2{X: float64(floorDiv(minX+maxX+1, 2)) * width, Y: float64(floorDiv(minY+maxY+1, 2)) * height}
+	}
+	return coords
+}
+
+// yUp reports whether the Y axis points up
+func (c Coordinates) yUp() bool {
+	return c.System != CoordinatesGodot
+}
+
+// Point converts a scene position to an output position
+func (c Coordinates) Point(p Vec2) Vec2 {
+	p.Sub(c.Origin)
+	return c.Vector(p)
+}
+
+// ScenePoint converts an output position back to a scene position
+func (c Coordinates) ScenePoint(p Vec2) Vec2 {
+	p = c.Vector(p)
+	p.Add(c.Origin)
+	return p
+}
+
+// Vector converts an offset, direction or point in a node's local space, which does not
+// move with the origin. It is its own inverse.
+func (c Coordinates) Vector(v Vec2) Vec2 {
+	v.Y = c.flip(v.Y)
+	return v
+}
+
+// flip negates a Y component or an angle in the Y-up systems, keeping zero positive so
+// that it is not written as -0
+func (c Coordinates) flip(value float64) float64 {
+	if c.yUp() && value != 0 {
+		return -value
+	}
+	return value
+}
+
+// Rotation converts a rotation or skew in radians. It is its own inverse.
+func (c Coordinates) Rotation(radians float64) float64 {
+	return c.flip(radians)
+}
+
+// Transform converts a scene space transform
+func (c Coordinates) Transform(t Transform2D) Transform2D {
+	position := c.Point(t.Position)
+	t = c.LocalTransform(t)
+	t.Position = position
+	return t
+}
+
+// SceneTransform converts an output transform back to scene space
+func (c Coordinates) SceneTransform(t Transform2D) Transform2D {
+	position := c.ScenePoint(t.Position)
+	t = c.LocalTransform(t)
+	t.Position = position
+	return t
+}
+
+// LocalTransform converts a transform relative to a parent whose own transform is converted
+// too, e.g. a collision shape relative to its body. It is its own inverse.
+func (c Coordinates) LocalTransform(t Transform2D) Transform2D {
+	t.Position = c.Vector(t.Position)
+	t.Rotation = c.Rotation(t.Rotation)
+	t.Skew = c.Rotation(t.Skew)
+	return t
+}
+
+// ColliderParams converts the ColliderParams of a collider type, whose coordinates are
+// local to the collision node. It is its own inverse.
+func (c Coordinates) ColliderParams(colliderType string, params []float64) []float64 {
+	if !c.yUp() || params == nil {
+		return params
+	}
+	result := append([]float64(nil), params...)
+	switch colliderType {
+	case ColliderSegment, ColliderPolygon, ColliderConcave:
+		for i := 1; i < len(result); i += 2 {
+			result[i] = c.flip(result[i])
+		}
+	case ColliderBoundary:
+		if len(result) >= 2 {
+			result[1] = c.flip(result[1])
+		}
+	}
+	return result
+}
+
+// Tile converts the cell of a tile in the scene to its tile_data coordinates: the output
+// position of the tile's top left corner in Godot, divided by the tile size
+func (c Coordinates) Tile(x, y int, size TileSize) (int, int) {
+	p := c.Point(Vec2{X: float64(x * size.Width), Y: float64(y * size.Height)})
+	return int(math.Round(p.X / float64(size.Width))), int(math.Round(p.Y / float64(size.Height)))
+}
+
+// SceneTile converts tile_data coordinates back to the cell of the tile in the scene
+func (c Coordinates) SceneTile(x, y int, size TileSize) (int, int) {
+	p := c.ScenePoint(Vec2{X: float64(x * size.Width), Y: float64(y * size.Height)})
+	return int(math.Round(p.X / float64(size.Width))), int(math.Round(p.Y / float64(size.Height)))
+}
+
+// parseCoordinateSystem checks a coordinate system name
+func parseCoordinateSystem(name string) (CoordinateSystem, error) {
+	for _, system := range CoordinateSystems {
+		if string(system) == name {
+			return system, nil
+		}
+	}
+	return "", fmt.Errorf("unknown coordinate system %q", name)
+}
+
+// outputCoordinates are the coordinates of the scene being converted
+var outputCoordinates = Coordinates{System: CoordinatesYUp}
+
+// outputPoint converts a scene space position to output coordinates
+func outputPoint(p Vec2) Vec2 {
+	return outputCoordinates.Point(p)
+}
+
+// outputVector converts a scene space offset or direction, which is not shifted by the origin
+func outputVector(v Vec2) Vec2 {
+	return outputCoordinates.Vector(v)
+}
+
+// outputVectors converts points in a node's local space, e.g. the polygon of a tile
+func outputVectors(points []Vec2) []Vec2 {
+	if points == nil {
+		return nil
+	}
+	result := make([]Vec2, len(points))
+	for i, p := range points {
+		result[i] = outputVector(p)
+	}
+	return result
+}
+
+// outputRotation converts a rotation in radians to output coordinates
+func outputRotation(radians float64) float64 {
+	return outputCoordinates.Rotation(radians)
+}
+
+// outputTransform converts a scene space transform to output coordinates
+func outputTransform(t Transform2D) Transform2D {
+	return outputCoordinates.Transform(t)
+}
+
+// outputShape converts a collision shape whose transform is relative to a converted parent
+func outputShape(shape CollisionShape) CollisionShape {
+	shape.Transform = outputCoordinates.LocalTransform(shape.Transform)
+	shape.Params = outputCoordinates.ColliderParams(shape.Type, shape.Params)
+	return shape
+}
+
+// outputColliders converts the colliders of a prefab, which are relative to its root node
+func outputColliders(colliders []Collider) []Collider {
+	if colliders == nil {
+		return nil
+	}
+	result := make([]Collider, len(colliders))
+	for i, collider := range colliders {
+		collider.CollisionShape = outputShape(collider.CollisionShape)
+		collider.BodyTransform = outputCoordinates.LocalTransform(collider.BodyTransform)
+		result[i] = collider
+	}
+	return result
+}
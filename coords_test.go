@@ -0,0 +1,275 @@
+package tscnparser
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// coordinateScene has tiles covering the cells (-2, -3) to (1, 0), a marker and an
+// instanced crate at (40, 24) rotated by 0.5, and a trigger with a segment shape
+const coordinateScene = `[gd_scene load_steps=5 format=3]
+
+[ext_resource type="PackedScene" path="res://scenes/crate.tscn" id="1_c"]
+
+[sub_resource type="TileSetAtlasSource" id="TileSetAtlasSource_1"]
+texture_region_size = Vector2i(16, 16)
+0:0/0 = 0
+0:0/0/physics_layer_0/polygon_0/points = PackedVector2Array(-8, -8, 8, -8, 8, 0, -8, 0)
+
+[sub_resource type="TileSet" id="TileSet_1"]
+sources/0 = SubResource("TileSetAtlasSource_1")
+
+[sub_resource type="SegmentShape2D" id="Segment_1"]
+a = Vector2(0, 0)
+b = Vector2(10, 5)
+
+[node name="Root" type="Node2D"]
+
+[node name="TileMap" type="TileMap" parent="."]
+tile_set = SubResource("TileSet_1")
+format = 2
+layer_0/name = "ground"
+layer_0/tile_data = PackedInt32Array(-131074, 0, 0, 1, 0, 0)
+
+[node name="Spawn" type="Marker2D" parent="."]
+position = Vector2(40, 24)
+rotation = 0.5
+
+[node name="Crate" parent="." instance=ExtResource("1_c")]
+position = Vector2(40, 24)
+rotation = 0.5
+
+[node name="Entry" type="Area2D" parent="."]
+
+[node name="Shape" type="CollisionShape2D" parent="Entry"]
+position = Vector2(0, 8)
+shape = SubResource("Segment_1")
+`
+
+// coordinatePrefab is the crate: a sprite 4 pixels above its origin and a rotated segment collider
+const coordinatePrefab = `[gd_scene load_steps=2 format=3]
+
+[sub_resource type="SegmentShape2D" id="Segment_1"]
+a = Vector2(0, 0)
+b = Vector2(10, 5)
+
+[node name="Crate" type="Node2D"]
+
+[node name="Sprite2D" type="Sprite2D" parent="."]
+position = Vector2(0, -4)
+
+[node name="Collision" type="CollisionShape2D" parent="."]
+position = Vector2(2, -6)
+rotation = 0.25
+shape = SubResource("Segment_1")
+`
+
+// parseCoordinateScene parses the coordinate scene with an offset of (48, 32)
+func parseCoordinateScene(t *testing.T, system CoordinateSystem) *MapData {
+	t.Helper()
+	dir := t.TempDir()
+	scene := filepath.Join(dir, "main.tscn")
+	if err := os.WriteFile(scene, []byte(coordinateScene), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "crate.tscn"), []byte(coordinatePrefab), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		SetTileSize(16)
+		SetOffset(0, 0)
+		SetPrefabsDir("")
+		SetCoordinateSystem(CoordinatesYUp)
+	})
+	SetTileSize(16)
+	SetOffset(48, 32)
+	SetPrefabsDir(dir)
+	if err := SetCoordinateSystem(system); err != nil {
+		t.Fatal(err)
+	}
+	data, err := Parse(scene)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return data
+}
+
+// parseNodes parses a scene of a one tile ground layer followed by the sub resources and
+// nodes, under a root at the scene origin. With the offset of (48, 32) in Y-up coordinates,
+// the scene point (x, y) is written as (x+48, -y-32).
+func parseNodes(t *testing.T, subResources, nodes string) *MapData {
+	t.Helper()
+	scene := filepath.Join(t.TempDir(), "main.tscn")
+	content := `[gd_scene format=3]
+
+[sub_resource type="TileSetAtlasSource" id="TileSetAtlasSource_1"]
+texture_region_size = Vector2i(16, 16)
+0:0/0 = 0
+
+[sub_resource type="TileSet" id="TileSet_1"]
+sources/0 = SubResource("TileSetAtlasSource_1")
+` + subResources + `
+[node name="Root" type="Node2D"]
+
+[node name="TileMap" type="TileMap" parent="."]
+tile_set = SubResource("TileSet_1")
+format = 2
+layer_0/name = "ground"
+layer_0/tile_data = PackedInt32Array(0, 0, 0, 65537, 0, 0)
+` + nodes
+	if err := os.WriteFile(scene, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		SetTileSize(16)
+		SetOffset(0, 0)
+	})
+	SetTileSize(16)
+	SetOffset(48, 32)
+	data, err := Parse(scene)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return data
+}
+
+func TestCoordinateSystems(t *testing.T) {
+	yUpLocal := struct {
+		polygon                    []Vec2
+		shape                      Vec2
+		params                     []float64
+		pivot, colliderPivot       Vec2
+		colliderRotation, rotation float64
+	}{
+		polygon:          []Vec2{{X: -8, Y: 8}, {X: 8, Y: 8}, {X: 8, Y: 0}, {X: -8, Y: 0}},
+		shape:            Vec2{X: 0, Y: -8},
+		params:           []float64{0, 0, 10, -5},
+		pivot:            Vec2{X: 0, Y: 4},
+		colliderPivot:    Vec2{X: 2, Y: 2},
+		colliderRotation: -0.25,
+		rotation:         -0.5,
+	}
+	tests := []struct {
+		system   CoordinateSystem
+		origin   Vec2
+		position Vec2  // of the marker and the crate
+		tiles    []int // tile_data
+	}{
+		{CoordinatesYUp, Vec2{X: -48, Y: -32}, Vec2{X: 88, Y: -56}, []int{0, 1, 1, 0, 0, 0, 4, -2, 0, 0}},
+		{CoordinatesGodot, Vec2{X: -48, Y: -32}, Vec2{X: 88, Y: 56}, []int{0, 1, -1, 0, 0, 0, 4, 2, 0, 0}},
+		{CoordinatesYUpBottom, Vec2{X: -32, Y: 16}, Vec2{X: 72, Y: -8}, []int{0, 0, 4, 0, 0, 0, 3, 1, 0, 0}},
+		{CoordinatesCentered, Vec2{X: 0, Y: -16}, Vec2{X: 40, Y: -40}, []int{0, -2, 2, 0, 0, 0, 1, -1, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.system), func(t *testing.T) {
+			data := parseCoordinateScene(t, tt.system)
+			if want := (Coordinates{System: tt.system, Origin: tt.origin}); data.Coordinates != want {
+				t.Errorf("coordinates = %+v, want %+v", data.Coordinates, want)
+			}
+			if got := data.TileMap.Layers[0].TileData; !reflect.DeepEqual(got, tt.tiles) {
+				t.Errorf("tile_data = %v, want %v", got, tt.tiles)
+			}
+
+			local := yUpLocal
+			if tt.system == CoordinatesGodot {
+				local.polygon = []Vec2{{X: -8, Y: -8}, {X: 8, Y: -8}, {X: 8, Y: 0}, {X: -8, Y: 0}}
+				local.shape = Vec2{X: 0, Y: 8}
+				local.params = []float64{0, 0, 10, 5}
+				local.pivot = Vec2{X: 0, Y: -4}
+				local.colliderPivot = Vec2{X: 2, Y: -2}
+				local.colliderRotation, local.rotation = 0.25, 0.5
+			}
+			if got := data.TileMap.TileSet.Sources[0].Tiles[0].Physics.CollisionPoints; !reflect.DeepEqual(got, local.polygon) {
+				t.Errorf("tile polygon = %v, want %v", got, local.polygon)
+			}
+
+			marker := data.Markers[0]
+			if marker.Position != tt.position || marker.Rotation != local.rotation {
+				t.Errorf("marker at %v rotated %v, want %v rotated %v", marker.Position, marker.Rotation, tt.position, local.rotation)
+			}
+			sprite := data.Sprites[0]
+			if sprite.Position != tt.position || sprite.Rotation != local.rotation {
+				t.Errorf("sprite at %v rotated %v, want %v rotated %v", sprite.Position, sprite.Rotation, tt.position, local.rotation)
+			}
+
+			prefab := data.Prefabs[0]
+			if prefab.Pivot != local.pivot {
+				t.Errorf("pivot = %v, want %v", prefab.Pivot, local.pivot)
+			}
+			if prefab.ColliderPivot != local.colliderPivot || prefab.ColliderRotation != local.colliderRotation {
+				t.Errorf("collider at %v rotated %v, want %v rotated %v", prefab.ColliderPivot, prefab.ColliderRotation, local.colliderPivot, local.colliderRotation)
+			}
+			if !reflect.DeepEqual(prefab.ColliderParams, local.params) {
+				t.Errorf("collider params = %v, want %v", prefab.ColliderParams, local.params)
+			}
+			if collider := prefab.Colliders[0]; collider.Transform.Rotation != local.colliderRotation || !reflect.DeepEqual(collider.Params, local.params) {
+				t.Errorf("colliders[0] rotated %v with params %v, want %v and %v", collider.Transform.Rotation, collider.Params, local.colliderRotation, local.params)
+			}
+
+			shape := data.Triggers[0].Shapes[0]
+			if shape.Transform.Position != local.shape || !reflect.DeepEqual(shape.Params, local.params) {
+				t.Errorf("trigger shape at %v with params %v, want %v and %v", shape.Transform.Position, shape.Params, local.shape, local.params)
+			}
+		})
+	}
+}
+
+func TestCoordinatesInverse(t *testing.T) {
+	size := TileSize{Width: 16, Height: 8}
+	transform := Transform2D{Position: Vec2{X: 12.5, Y: -7}, Rotation: 0.75, Skew: 0.1, Scale: Vec2{X: 2, Y: -1}}
+	params := []float64{1, 2, 3, 4, 5, 6}
+	for _, system := range CoordinateSystems {
+		c := newCoordinates(system, Vec2{X: 5, Y: -3}, size, true, -4, -2, 3, 5)
+		p := Vec2{X: 12.5, Y: -7}
+		if got := c.ScenePoint(c.Point(p)); got != p {
+			t.Errorf("%s: point %v comes back as %v", system, p, got)
+		}
+		if got := c.SceneTransform(c.Transform(transform)); got != transform {
+			t.Errorf("%s: transform %+v comes back as %+v", system, transform, got)
+		}
+		if got := c.LocalTransform(c.LocalTransform(transform)); got != transform {
+			t.Errorf("%s: local transform %+v comes back as %+v", system, transform, got)
+		}
+		if got := c.ColliderParams(ColliderPolygon, c.ColliderParams(ColliderPolygon, params)); !reflect.DeepEqual(got, params) {
+			t.Errorf("%s: polygon %v comes back as %v", system, params, got)
+		}
+		for _, cell := range [][2]int{{-4, -2}, {3, 5}, {0, 0}} {
+			x, y := c.Tile(cell[0], cell[1], size)
+			if bx, by := c.SceneTile(x, y, size); bx != cell[0] || by != cell[1] {
+				t.Errorf("%s: tile %v comes back as (%d, %d)", system, cell, bx, by)
+			}
+		}
+		// Counterclockwise on screen stays counterclockwise: the rotated X axis keeps its side of the Y axis
+		rotated := c.Transform(Transform2D{Rotation: 0.5, Scale: Vec2{X: 1, Y: 1}})
+		screen := c.Vector(Vec2{X: math.Cos(0.5), Y: math.Sin(0.5)})
+		if got := (Vec2{X: math.Cos(rotated.Rotation), Y: math.Sin(rotated.Rotation)}); math.Abs(got.X-screen.X) > 1e-9 || math.Abs(got.Y-screen.Y) > 1e-9 {
+			t.Errorf("%s: rotation 0.5 points to %v, want %v", system, got, screen)
+		}
+	}
+}
+
+func TestCoordinatesDefaults(t *testing.T) {
+	// Data from before coordinates were recorded is Y-up around the scene origin
+	var legacy Coordinates
+	if got := legacy.Point(Vec2{X: 3, Y: 4}); got != (Vec2{X: 3, Y: -4}) {
+		t.Errorf("legacy point = %v", got)
+	}
+	if got := legacy.Vector(Vec2{X: 3, Y: 0}); math.Signbit(got.Y) {
+		t.Errorf("zero Y is written as -0")
+	}
+	// Without tiles the map-anchored systems fall back to the scene origin
+	for _, system := range []CoordinateSystem{CoordinatesYUpBottom, CoordinatesCentered} {
+		if c := newCoordinates(system, Vec2{X: 8, Y: 8}, TileSize{Width: 16, Height: 16}, false, 0, 0, 0, 0); c.Origin != (Vec2{}) {
+			t.Errorf("%s without tiles: origin = %v", system, c.Origin)
+		}
+	}
+	if err := SetCoordinateSystem("y-down"); err == nil {
+		t.Error("unknown coordinate system: expected an error")
+	}
+	if coordinateSystem != CoordinatesYUp {
+		t.Errorf("an unknown coordinate system replaced %q", CoordinatesYUp)
+	}
+}
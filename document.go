@@ -0,0 +1,648 @@
+package tscnparser
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Document is a scene or resource file in Godot's text format. It keeps the text of every
+// section and property as read, so writing it back reproduces the file byte for byte and
+// only the parts that were changed are formatted again.
+type Document struct {
+	Sections []*Section
+	prefix   string // text before the first section
+	newline  string // line ending of the file, "\n" or "\r\n"
+}
+
+// Section is a [tag key=value ...] header and the properties that follow it, e.g. a node
+type Section struct {
+	Tag        string
+	attributes []Attribute
+	items      []*sectionItem
+	header     string // header text as read, empty once the header changed
+	trailing   string // blank lines between the section and the next one
+}
+
+// Attribute is a key=value pair of a section header. Value is Godot text, e.g. "\"Sprite2D\"".
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// sectionItem is a property line, or a line that is kept as is when key is empty
+type sectionItem struct {
+	key   string
+	value string
+	raw   string // text as read, empty once the value changed
+}
+
+// NewDocument returns an empty document. Add a gd_scene header section before any other.
+func NewDocument() *Document {
+	return &Document{newline: "\n"}
+}
+
+// NewSection returns a section to add to a document
+func NewSection(tag string, attributes ...Attribute) *Section {
+	return &Section{Tag: tag, attributes: append([]Attribute(nil), attributes...)}
+}
+
+// ReadDocument reads a .tscn or .tres file
+func ReadDocument(path string) (*Document, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseDocument(file)
+}
+
+// ParseDocument reads a document in Godot's text format
+func ParseDocument(r io.Reader) (*Document, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := string(content)
+	doc := NewDocument()
+	if strings.Contains(text, "\r\n") {
+		doc.newline = "\r\n"
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	var section *Section
+	blank := ""
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if section != nil && trimmed == "" {
+			blank += line
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") {
+			raw := line
+			for !valueComplete(strings.TrimSpace(raw)) && i+1 < len(lines) {
+				i++
+				raw += lines[i]
+			}
+			next, err := parseSectionHeader(strings.TrimSpace(raw))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			next.header = raw
+			if section != nil {
+				section.trailing = blank
+			}
+			blank = ""
+			section = next
+			doc.Sections = append(doc.Sections, section)
+			continue
+		}
+		if section == nil {
+			doc.prefix += line
+			continue
+		}
+		if blank != "" {
+			section.items = append(section.items, &sectionItem{raw: blank})
+			blank = ""
+		}
+		key, value, ok := splitProperty(trimmed)
+		if !ok {
+			section.items = append(section.items, &sectionItem{raw: line})
+			continue
+		}
+		raw := line
+		for !valueComplete(value) && i+1 < len(lines) {
+			i++
+			raw += lines[i]
+			value += "\n" + strings.TrimRight(lines[i], "\r\n")
+		}
+		section.items = append(section.items, &sectionItem{key: key, value: strings.TrimSpace(value), raw: raw})
+	}
+	if section != nil {
+		section.trailing = blank
+	}
+	return doc, nil
+}
+
+// parseSectionHeader parses a [tag key=value ...] header
+func parseSectionHeader(text string) (*Section, error) {
+	if !strings.HasPrefix(text, "[") || !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("invalid section header %q", text)
+	}
+	body := text[1 : len(text)-1]
+	tag := body
+	if end := strings.IndexAny(body, " \t"); end >= 0 {
+		tag = body[:end]
+	}
+	section := &Section{Tag: tag}
+	rest := body[len(tag):]
+	for {
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if rest == "" {
+			return section, nil
+		}
+		eq := strings.IndexByte(rest, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("invalid attribute %q in section header", rest)
+		}
+		key := strings.TrimSpace(rest[:eq])
+		p := &variantParser{src: rest[eq+1:]}
+		if _, err := p.parseValue(); err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", key, err)
+		}
+		section.attributes = append(section.attributes, Attribute{Key: key, Value: strings.TrimSpace(p.src[:p.pos])})
+		rest = p.src[p.pos:]
+	}
+}
+
+// Bytes returns the document in Godot's text format
+func (d *Document) Bytes() []byte {
+	var buf bytes.Buffer
+	// Generated text must start on a new line, even after a last line without one
+	write := func(text string, generated bool) {
+		if generated && buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteString(d.newline)
+		}
+		buf.WriteString(text)
+	}
+	write(d.prefix, false)
+	for _, section := range d.Sections {
+		if section.header != "" {
+			write(section.header, false)
+		} else {
+			write(section.headerText()+d.newline, true)
+		}
+		for _, item := range section.items {
+			if item.raw != "" {
+				write(item.raw, false)
+			} else {
+				value := strings.ReplaceAll(item.value, "\n", d.newline)
+				write(item.key+" = "+value+d.newline, true)
+			}
+		}
+		write(section.trailing, false)
+	}
+	return buf.Bytes()
+}
+
+// WriteTo writes the document in Godot's text format
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(d.Bytes())
+	return int64(n), err
+}
+
+// WriteFile writes the document to a .tscn or .tres file
+func (d *Document) WriteFile(path string) error {
+	return os.WriteFile(path, d.Bytes(), 0644)
+}
+
+// Header returns the first section, [gd_scene ...] in a scene
+func (d *Document) Header() *Section {
+	if len(d.Sections) == 0 {
+		return nil
+	}
+	return d.Sections[0]
+}
+
+// Find returns the sections with the given tag in file order
+func (d *Document) Find(tag string) []*Section {
+	var found []*Section
+	for _, section := range d.Sections {
+		if section.Tag == tag {
+			found = append(found, section)
+		}
+	}
+	return found
+}
+
+// Nodes returns the node sections in file order
+func (d *Document) Nodes() []*Section {
+	return d.Find("node")
+}
+
+// Node returns the node at a path relative to the scene root, "." for the root itself
+func (d *Document) Node(path string) *Section {
+	for _, node := range d.Nodes() {
+		if node.NodePath() == path {
+			return node
+		}
+	}
+	return nil
+}
+
+// ExtResource returns the ext_resource section with the given id
+func (d *Document) ExtResource(id string) *Section {
+	return d.findResource("ext_resource", id)
+}
+
+// SubResource returns the sub_resource section with the given id
+func (d *Document) SubResource(id string) *Section {
+	return d.findResource("sub_resource", id)
+}
+
+func (d *Document) findResource(tag, id string) *Section {
+	for _, section := range d.Find(tag) {
+		if section.StringAttr("id") == id {
+			return section
+		}
+	}
+	return nil
+}
+
+// AddExtResource declares an external resource after the existing ones and returns it.
+// The id follows Godot's "<n>_<suffix>" form and is unique within the document.
+func (d *Document) AddExtResource(resourceType, path string) *Section {
+	section := NewSection("ext_resource")
+	section.SetStringAttr("type", resourceType)
+	section.SetStringAttr("path", path)
+	section.SetStringAttr("id", d.newResourceID("ext_resource", path))
+	d.insertAfterLast(section, "ext_resource", "gd_scene", "gd_resource")
+	return section
+}
+
+// AddSubResource declares an embedded resource after the existing ones and returns it
+func (d *Document) AddSubResource(resourceType string) *Section {
+	section := NewSection("sub_resource")
+	section.SetStringAttr("type", resourceType)
+	section.SetStringAttr("id", d.newResourceID("sub_resource", resourceType))
+	d.insertAfterLast(section, "sub_resource", "ext_resource", "gd_scene", "gd_resource")
+	return section
+}
+
+// AddNode adds a node after the last node and returns it. Parent is a path relative to the
+// scene root as in Node, and empty for the root node.
+func (d *Document) AddNode(name, nodeType, parent string) *Section {
+	section := NewSection("node")
+	section.SetStringAttr("name", name)
+	if nodeType != "" {
+		section.SetStringAttr("type", nodeType)
+	}
+	if parent != "" {
+		section.SetStringAttr("parent", parent)
+	}
+	d.insertAfterLast(section, "node", "sub_resource", "ext_resource", "gd_scene", "gd_resource")
+	return section
+}
+
+// insertAfterLast inserts a section after the last section with the first of the tags found
+func (d *Document) insertAfterLast(section *Section, tags ...string) {
+	for _, tag := range tags {
+		for i := len(d.Sections) - 1; i >= 0; i-- {
+			if d.Sections[i].Tag == tag {
+				d.Insert(i+1, section)
+				return
+			}
+		}
+	}
+	d.Insert(len(d.Sections), section)
+}
+
+// Insert adds a section at an index of Sections. Consecutive ext_resource sections are
+// written without a blank line between them and any other sections with one, as Godot does.
+func (d *Document) Insert(index int, section *Section) {
+	if index > 0 {
+		prev := d.Sections[index-1]
+		section.trailing = prev.trailing
+		if prev.Tag == "ext_resource" && section.Tag == "ext_resource" {
+			prev.trailing = ""
+		} else {
+			prev.trailing = d.newline
+		}
+	} else if len(d.Sections) > 0 {
+		section.trailing = d.newline
+	}
+	d.Sections = append(d.Sections, nil)
+	copy(d.Sections[index+1:], d.Sections[index:])
+	d.Sections[index] = section
+	d.updateLoadSteps(section)
+}
+
+// Remove removes a section from the document and reports whether it was found
+func (d *Document) Remove(section *Section) bool {
+	for i, s := range d.Sections {
+		if s == section {
+			// Keep the blank line that separated the section from the next one
+			if prev := i - 1; prev >= 0 && (i == len(d.Sections)-1 || len(s.trailing) > len(d.Sections[prev].trailing)) {
+				d.Sections[prev].trailing = s.trailing
+			}
+			d.Sections = append(d.Sections[:i], d.Sections[i+1:]...)
+			d.updateLoadSteps(section)
+			return true
+		}
+	}
+	return false
+}
+
+// updateLoadSteps keeps the load_steps attribute of the header, the number of resources
+// plus one, in step when a resource is added or removed. Headers without it are left alone.
+func (d *Document) updateLoadSteps(changed *Section) {
+	header := d.Header()
+	if header == nil || header == changed || (changed.Tag != "ext_resource" && changed.Tag != "sub_resource") {
+		return
+	}
+	if _, ok := header.Attr("load_steps"); !ok {
+		return
+	}
+	steps := len(d.Find("ext_resource")) + len(d.Find("sub_resource")) + 1
+	header.SetAttr("load_steps", strconv.Itoa(steps))
+}
+
+// newResourceID returns an id of the form Godot gives new resources, e.g. "3_x7k2p"
+func (d *Document) newResourceID(tag, seed string) string {
+	const letters = "abcdefghijklmnopqrstuvwxyz0123456789"
+	taken := make(map[string]bool)
+	for _, section := range d.Find(tag) {
+		taken[section.StringAttr("id")] = true
+	}
+	n := len(taken) + 1
+	for attempt := 0; ; attempt++ {
+		sum := sha1.Sum([]byte(fmt.Sprintf("%s:%d:%d", seed, n, attempt)))
+		suffix := make([]byte, 5)
+		for i := range suffix {
+			suffix[i] = letters[int(sum[i])%len(letters)]
+		}
+		id := fmt.Sprintf("%d_%s", n, suffix)
+		if !taken[id] {
+			return id
+		}
+	}
+}
+
+func (s *Section) headerText() string {
+	var sb strings.Builder
+	sb.WriteString("[" + s.Tag)
+	for _, attr := range s.attributes {
+		sb.WriteString(" " + attr.Key + "=" + attr.Value)
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+// Attributes returns the header attributes in order
+func (s *Section) Attributes() []Attribute {
+	return append([]Attribute(nil), s.attributes...)
+}
+
+// Attr returns the Godot text of a header attribute
+func (s *Section) Attr(key string) (string, bool) {
+	for _, attr := range s.attributes {
+		if attr.Key == key {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// StringAttr returns a string attribute such as name, type or path without its quotes
+func (s *Section) StringAttr(key string) string {
+	value, _ := s.Attr(key)
+	if parsed, err := parseVariant(value); err == nil {
+		if str, ok := parsed.(string); ok {
+			return str
+		}
+	}
+	return value
+}
+
+// SetAttr sets a header attribute to Godot text, adding it at the end if it is new
+func (s *Section) SetAttr(key, value string) {
+	for i := range s.attributes {
+		if s.attributes[i].Key == key {
+			if s.attributes[i].Value != value {
+				s.attributes[i].Value = value
+				s.header = ""
+			}
+			return
+		}
+	}
+	s.attributes = append(s.attributes, Attribute{Key: key, Value: value})
+	s.header = ""
+}
+
+// SetStringAttr sets a header attribute to a quoted string
+func (s *Section) SetStringAttr(key, value string) {
+	s.SetAttr(key, quoteGodotString(value))
+}
+
+// DeleteAttr removes a header attribute and reports whether it was present
+func (s *Section) DeleteAttr(key string) bool {
+	for i, attr := range s.attributes {
+		if attr.Key == key {
+			s.attributes = append(s.attributes[:i], s.attributes[i+1:]...)
+			s.header = ""
+			return true
+		}
+	}
+	return false
+}
+
+// NodePath returns the path of a node section relative to the scene root, "." for the root
+func (s *Section) NodePath() string {
+	if _, ok := s.Attr("parent"); !ok {
+		return "."
+	}
+	parent := s.StringAttr("parent")
+	if parent == "." {
+		return s.StringAttr("name")
+	}
+	return parent + "/" + s.StringAttr("name")
+}
+
+// Keys returns the property names in order
+func (s *Section) Keys() []string {
+	var keys []string
+	for _, item := range s.items {
+		if item.key != "" {
+			keys = append(keys, item.key)
+		}
+	}
+	return keys
+}
+
+// Get returns the Godot text of a property value
+func (s *Section) Get(key string) (string, bool) {
+	if item := s.item(key); item != nil {
+		return item.value, true
+	}
+	return "", false
+}
+
+// Value returns a property value as one of the Go types documented on Properties
+func (s *Section) Value(key string) (any, bool) {
+	raw, ok := s.Get(key)
+	if !ok {
+		return nil, false
+	}
+	return propertyValue(raw), true
+}
+
+// Set sets a property to Godot text, adding it after the other properties if it is new
+func (s *Section) Set(key, value string) {
+	if item := s.item(key); item != nil {
+		if item.value != value {
+			item.value = value
+			item.raw = ""
+		}
+		return
+	}
+	s.items = append(s.items, &sectionItem{key: key, value: value})
+}
+
+// SetValue sets a property to a Go value, formatted with FormatValue
+func (s *Section) SetValue(key string, value any) error {
+	text, err := FormatValue(value)
+	if err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	s.Set(key, text)
+	return nil
+}
+
+// Delete removes a property and reports whether it was present
+func (s *Section) Delete(key string) bool {
+	for i, item := range s.items {
+		if item.key == key {
+			s.items = append(s.items[:i], s.items[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Section) item(key string) *sectionItem {
+	if key == "" {
+		return nil
+	}
+	for _, item := range s.items {
+		if item.key == key {
+			return item
+		}
+	}
+	return nil
+}
+
+// FormatValue formats a Go value in Godot's text format. It accepts the types documented
+// on Properties, Go integer and float types, and slices and string-keyed maps of them.
+func FormatValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "null", nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case string:
+		return quoteGodotString(v), nil
+	case float32:
+		return formatGodotFloat(float64(v)), nil
+	case float64:
+		return formatGodotFloat(v), nil
+	case Vec2:
+		return "Vector2(" + formatComponents(v.X, v.Y) + ")", nil
+	case Vec2i:
+		return fmt.Sprintf("Vector2i(%d, %d)", v.X, v.Y), nil
+	case Rect2:
+		return "Rect2(" + formatComponents(v.X, v.Y, v.Width, v.Height) + ")", nil
+	case Color:
+		return "Color(" + formatComponents(v.R, v.G, v.B, v.A) + ")", nil
+	case ResourceRef:
+		return v.Kind + "(" + quoteGodotString(v.ID) + ")", nil
+	case Variant:
+		args := make([]string, len(v.Args))
+		for i, arg := range v.Args {
+			var err error
+			if f, ok := arg.(float64); ok {
+				args[i] = formatComponents(f)
+			} else if args[i], err = FormatValue(arg); err != nil {
+				return "", err
+			}
+		}
+		return v.Type + "(" + strings.Join(args, ", ") + ")", nil
+	case Properties:
+		return FormatValue(map[string]any(v))
+	case Values:
+		return FormatValue([]any(v))
+	case map[string]any:
+		if len(v) == 0 {
+			return "{}", nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		entries := make([]string, len(keys))
+		for i, key := range keys {
+			item, err := FormatValue(v[key])
+			if err != nil {
+				return "", err
+			}
+			entries[i] = quoteGodotString(key) + ": " + item
+		}
+		return "{\n" + strings.Join(entries, ",\n") + "\n}", nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Slice, reflect.Array:
+		items := make([]string, rv.Len())
+		for i := range items {
+			item, err := FormatValue(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			items[i] = item
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("cannot format %T as a Godot value", value)
+}
+
+// formatGodotFloat formats a float value the way Godot does, always with a fraction
+func formatGodotFloat(f float64) string {
+	s := formatComponents(f)
+	if !strings.ContainsAny(s, ".en") {
+		s += ".0"
+	}
+	return s
+}
+
+// formatComponents formats the components of a vector, rect or color, written without a
+// fraction when they are whole
+func formatComponents(values ...float64) string {
+	parts := make([]string, len(values))
+	for i, f := range values {
+		switch {
+		case math.IsInf(f, 1):
+			parts[i] = "inf"
+		case math.IsInf(f, -1):
+			parts[i] = "inf_neg"
+		case math.IsNaN(f):
+			parts[i] = "nan"
+		default:
+			parts[i] = strconv.FormatFloat(f, 'g', -1, 64)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// quoteGodotString quotes a string, escaping backslashes and quotes. Line breaks are kept
+// as they are, as Godot writes them.
+func quoteGodotString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
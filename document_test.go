@@ -0,0 +1,173 @@
+package tscnparser
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// multilineScene has values that span several lines and a comment line
+const multilineScene = `[gd_scene load_steps=3 format=3 uid="uid://multi"]
+
+[ext_resource type="Texture2D" path="res://a.png" id="1_aaaaa"]
+[ext_resource type="Script" path="res://a.gd" id="2_bbbbb"]
+
+[node name="Root" type="Node2D"]
+script = ExtResource("2_bbbbb")
+metadata/info = {
+"hp": 10,
+"tags": ["a", "b"]
+}
+
+[node name="Sprite" type="Sprite2D" parent="."]
+texture = ExtResource("1_aaaaa")
+position = Vector2(1, 2)
+; a comment
+layer_0/tile_data = PackedInt32Array(0, 0, 0,
+65537, 0, 0)
+
+[node name="Last" type="Node2D" parent="."]
+`
+
+func TestDocumentRoundTrip(t *testing.T) {
+	main, err := os.ReadFile("test/main.tscn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, text string
+	}{
+		{"main.tscn", string(main)},
+		{"multi-line values", multilineScene},
+		{"CRLF", strings.ReplaceAll(multilineScene, "\n", "\r\n")},
+		{"no final newline", strings.TrimSuffix(multilineScene, "\n")},
+	}
+	for _, tt := range tests {
+		doc, err := ParseDocument(strings.NewReader(tt.text))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := string(doc.Bytes()); got != tt.text {
+			t.Errorf("%s: round trip changed the file", tt.name)
+		}
+	}
+
+	doc, err := ParseDocument(strings.NewReader(multilineScene))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := doc.Node("Sprite").Get("layer_0/tile_data"); got != "PackedInt32Array(0, 0, 0,\n65537, 0, 0)" {
+		t.Errorf("tile_data = %q", got)
+	}
+	info, _ := doc.Node(".").Value("metadata/info")
+	if dict, ok := info.(map[string]any); !ok || len(dict) != 2 {
+		t.Errorf("metadata/info = %#v", info)
+	}
+}
+
+// editDocument parses the scene with a newline, edits it and returns the text written back
+func editDocument(t *testing.T, newline string, edit func(doc *Document)) string {
+	t.Helper()
+	doc, err := ParseDocument(strings.NewReader(strings.ReplaceAll(multilineScene, "\n", newline)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	edit(doc)
+	return string(doc.Bytes())
+}
+
+func TestDocumentEdits(t *testing.T) {
+	tests := []struct {
+		name     string
+		edit     func(doc *Document)
+		old, new string // the only change to the text
+	}{
+		{
+			"set",
+			func(doc *Document) { doc.Node("Sprite").Set("position", "Vector2(3, 4)") },
+			"position = Vector2(1, 2)\n", "position = Vector2(3, 4)\n",
+		},
+		{
+			"set unchanged",
+			func(doc *Document) { doc.Node("Sprite").Set("position", "Vector2(1, 2)") },
+			"", "",
+		},
+		{
+			"set multi-line",
+			func(doc *Document) { doc.Node("Sprite").Set("layer_0/tile_data", "PackedInt32Array()") },
+			"layer_0/tile_data = PackedInt32Array(0, 0, 0,\n65537, 0, 0)\n", "layer_0/tile_data = PackedInt32Array()\n",
+		},
+		{
+			"add property",
+			func(doc *Document) { doc.Node("Last").Set("visible", "false") },
+			"name=\"Last\" type=\"Node2D\" parent=\".\"]\n", "name=\"Last\" type=\"Node2D\" parent=\".\"]\nvisible = false\n",
+		},
+		{
+			"remove node",
+			func(doc *Document) { doc.Remove(doc.Node("Sprite")) },
+			"[node name=\"Sprite\" type=\"Sprite2D\" parent=\".\"]\ntexture = ExtResource(\"1_aaaaa\")\nposition = Vector2(1, 2)\n; a comment\nlayer_0/tile_data = PackedInt32Array(0, 0, 0,\n65537, 0, 0)\n\n", "",
+		},
+		{
+			"remove last node",
+			func(doc *Document) { doc.Remove(doc.Node("Last")) },
+			"\n[node name=\"Last\" type=\"Node2D\" parent=\".\"]\n", "",
+		},
+		{
+			"remove resource",
+			func(doc *Document) { doc.Remove(doc.ExtResource("1_aaaaa")) },
+			"load_steps=3 format=3 uid=\"uid://multi\"]\n\n[ext_resource type=\"Texture2D\" path=\"res://a.png\" id=\"1_aaaaa\"]\n", "load_steps=2 format=3 uid=\"uid://multi\"]\n\n",
+		},
+	}
+	for _, tt := range tests {
+		for _, newline := range []string{"\n", "\r\n"} {
+			want := strings.Replace(multilineScene, tt.old, tt.new, 1)
+			if tt.old != "" && want == multilineScene {
+				t.Fatalf("%s: %q is not in the scene", tt.name, tt.old)
+			}
+			want = strings.ReplaceAll(want, "\n", newline)
+			if got := editDocument(t, newline, tt.edit); got != want {
+				t.Errorf("%s, newline %q:\n%s\nwant:\n%s", tt.name, newline, got, want)
+			}
+		}
+	}
+}
+
+func TestDocumentAddExtResource(t *testing.T) {
+	var added []*Section
+	got := editDocument(t, "\n", func(doc *Document) {
+		for i := 0; i < 3; i++ {
+			added = append(added, doc.AddExtResource("Texture2D", "res://a.png"))
+		}
+		if ref := doc.ExtResource(added[2].StringAttr("id")); ref != added[2] {
+			t.Errorf("ExtResource(%s) = %v", added[2].StringAttr("id"), ref)
+		}
+	})
+
+	ids := map[string]bool{"1_aaaaa": true, "2_bbbbb": true}
+	var lines strings.Builder
+	for _, section := range added {
+		id := section.StringAttr("id")
+		if ids[id] {
+			t.Errorf("id %s is taken", id)
+		}
+		ids[id] = true
+		lines.WriteString(`[ext_resource type="Texture2D" path="res://a.png" id="` + id + "\"]\n")
+	}
+	want := strings.Replace(multilineScene, "load_steps=3", "load_steps=6", 1)
+	want = strings.Replace(want, "id=\"2_bbbbb\"]\n", "id=\"2_bbbbb\"]\n"+lines.String(), 1)
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	// The first resource of a scene without any goes after the header
+	doc, err := ParseDocument(strings.NewReader("[gd_scene load_steps=1 format=3]\n\n[node name=\"Root\" type=\"Node2D\"]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	section := doc.AddExtResource("Script", "res://b.gd")
+	want = "[gd_scene load_steps=2 format=3]\n\n[ext_resource type=\"Script\" path=\"res://b.gd\" id=\"" + section.StringAttr("id") + "\"]\n\n[node name=\"Root\" type=\"Node2D\"]\n"
+	if got := doc.Bytes(); !bytes.Equal(got, []byte(want)) {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package tscnparser
+
+import (
+	"fmt"
+)
+
+// Godot clamps absolute z indices to this range
+const (
+	zIndexMin = -4096
+	zIndexMax = 4096
+)
+
+// resolveDrawOrder computes the draw order of the tile layers, decorators and instanced sprites
+func (c *TSCNConverter) resolveDrawOrder(data *MapData) {
+	for i := range data.Decorators {
+		decorator := &data.Decorators[i]
+		if node, exists := c.nodes.nodes[joinNodePath(decorator.Parent, decorator.Name)]; exists {
+			decorator.DrawOrder = c.drawOrder(node)
+		}
+	}
+	for i := range data.Sprites {
+		sprite := &data.Sprites[i]
+		if node, exists := c.nodes.nodes[joinNodePath(sprite.Parent, sprite.Name)]; exists {
+			sprite.DrawOrder = c.instanceDrawOrder(node)
+		}
+	}
+
+	var tileMap *sceneNode
+	for _, node := range c.nodes.order {
+		if node.Type == "TileMap" {
+			tileMap = node
+			break
+		}
+	}
+	if tileMap == nil {
+		return
+	}
+	// Tile layers behave like children of the TileMap with a relative z index
+	base := c.drawOrder(tileMap)
+	for i := range data.TileMap.Layers {
+		layer := &data.TileMap.Layers[i]
+		layer.DrawOrder = base
+		layer.DrawOrder.ZIndex = clampZIndex(base.ZIndex + int64(layer.ZIndex))
+		layer.DrawOrder.YSortGroup = ""
+		if tileMap.Properties[fmt.Sprintf("layer_%d/y_sort_enabled", layer.ID)] == "true" {
+			layer.DrawOrder.YSortGroup = c.ySortGroup(tileMap)
+		}
+	}
+}
+
+// drawOrder returns the draw order of a node of the scene
+func (c *TSCNConverter) drawOrder(node *sceneNode) DrawOrder {
+	order := DrawOrder{
+		ZIndex:     clampZIndex(c.absoluteZIndex(node)),
+		YSortGroup: c.ySortGroup(c.nodes.parent(node)),
+		TreeOrder:  node.Index,
+	}
+	if canvas := c.nodes.ancestor(node, isCanvasLayer); canvas != nil {
+		order.CanvasLayer = canvas.intProperty("layer", defaultCanvasLayer(canvas.Type))
+	}
+	return order
+}
+
+// instanceDrawOrder returns the draw order of the sprite of an instanced prefab,
+// which adds its z index within the prefab when it is relative
+func (c *TSCNConverter) instanceDrawOrder(node *sceneNode) DrawOrder {
+	order := c.drawOrder(node)
+	root := c.instanceRoot(node)
+	if root == nil {
+		return order
+	}
+	info := c.prefabCache[c.extResources[node.Instance].Path]
+	for _, sprite := range info.Nodes.order {
+		if sprite.Type != "Sprite2D" && sprite.Type != "AnimatedSprite2D" {
+			continue
+		}
+		var z int64
+		relative := true
+		for n := sprite; n != root && n != nil; n = info.Nodes.parent(n) {
+			z += n.intProperty("z_index", 0)
+			if n.Properties["z_as_relative"] == "false" {
+				relative = false
+				break
+			}
+		}
+		if relative {
+			z += order.ZIndex
+		}
+		order.ZIndex = clampZIndex(z)
+		break
+	}
+	return order
+}
+
+// absoluteZIndex accumulates z_index up the tree while z_as_relative is set.
+// Plain Node parents and canvas layers end the accumulation.
+func (c *TSCNConverter) absoluteZIndex(node *sceneNode) int64 {
+	var z int64
+	for n := node; n != nil; n = c.nodes.parent(n) {
+		if t := c.nodeType(n); t == "Node" || isCanvasLayer(t) {
+			break
+		}
+		z += c.intNodeProperty(n, "z_index", 0)
+		if c.nodeProperty(n, "z_as_relative") == "false" {
+			break
+		}
+	}
+	return z
+}
+
+// ySortGroup returns the path of the node whose y-sort orders the children of parent,
+// or "" if parent does not y-sort them. Nested y-sorted nodes join their parent's sort.
+func (c *TSCNConverter) ySortGroup(parent *sceneNode) string {
+	if parent == nil || c.nodeProperty(parent, "y_sort_enabled") != "true" {
+		return ""
+	}
+	group := parent
+	for p := c.nodes.parent(group); p != nil && c.nodeProperty(p, "y_sort_enabled") == "true"; p = c.nodes.parent(p) {
+		group = p
+	}
+	return group.Path
+}
+
+// nodeType returns the type of a node, looking it up in the scene of instanced nodes
+func (c *TSCNConverter) nodeType(node *sceneNode) string {
+	if node.Type == "" {
+		if root := c.instanceRoot(node); root != nil {
+			return root.Type
+		}
+	}
+	return node.Type
+}
+
+// nodeProperty returns a raw property of a node, falling back to the root of its instanced scene
+func (c *TSCNConverter) nodeProperty(node *sceneNode, key string) string {
+	if value, exists := node.Properties[key]; exists {
+		return value
+	}
+	if root := c.instanceRoot(node); root != nil {
+		return root.Properties[key]
+	}
+	return ""
+}
+
+func (c *TSCNConverter) intNodeProperty(node *sceneNode, key string, def int64) int64 {
+	if _, exists := node.Properties[key]; !exists {
+		if root := c.instanceRoot(node); root != nil {
+			return root.intProperty(key, def)
+		}
+	}
+	return node.intProperty(key, def)
+}
+
+func clampZIndex(z int64) int64 {
+	return max(zIndexMin, min(zIndexMax, z))
+}
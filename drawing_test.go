@@ -0,0 +1,53 @@
+package tscnparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDrawOrder(t *testing.T) {
+	data := parseNodes(t, "", `
+[node name="World" type="Node2D" parent="."]
+z_index = 2
+y_sort_enabled = true
+
+[node name="Props" type="Node2D" parent="World"]
+z_index = 1
+y_sort_enabled = true
+
+[node name="Barrel" type="Sprite2D" parent="World/Props"]
+z_index = 1
+
+[node name="Fixed" type="Sprite2D" parent="World"]
+z_index = -1
+z_as_relative = false
+
+[node name="HUD" type="CanvasLayer" parent="."]
+layer = 3
+
+[node name="Icon" type="Sprite2D" parent="HUD"]
+z_index = 5
+
+[node name="Deep" type="Sprite2D" parent="."]
+z_index = 5000
+`)
+	want := map[string]DrawOrder{
+		// Relative z indices add up, and the nested y-sorted node joins its parent's sort
+		"Barrel": {ZIndex: 4, YSortGroup: "World", TreeOrder: 4},
+		"Fixed":  {ZIndex: -1, YSortGroup: "World", TreeOrder: 5},
+		// A canvas layer ends the accumulation
+		"Icon": {CanvasLayer: 3, ZIndex: 5, TreeOrder: 7},
+		"Deep": {ZIndex: 4096, TreeOrder: 8},
+	}
+	if len(data.Decorators) != len(want) {
+		t.Fatalf("decorators = %+v", data.Decorators)
+	}
+	for _, decorator := range data.Decorators {
+		if got := decorator.DrawOrder; !reflect.DeepEqual(got, want[decorator.Name]) {
+			t.Errorf("%s: draw order = %+v, want %+v", decorator.Name, got, want[decorator.Name])
+		}
+	}
+	if got, want := data.TileMap.Layers[0].DrawOrder, (DrawOrder{TreeOrder: 1}); got != want {
+		t.Errorf("tile layer draw order = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package tscnparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// buildGeometry collects Polygon2D, Line2D and CollisionPolygon2D nodes with their points in world space.
+// Collision polygons of Area2D nodes are exported as trigger shapes instead.
+func (c *TSCNConverter) buildGeometry() []Geometry {
+	var geometry []Geometry
+	for _, node := range c.nodes.order {
+		var item Geometry
+		switch node.Type {
+		case "Polygon2D":
+			item = c.newPolygon2D(node)
+		case "Line2D":
+			item = c.newLine2D(node)
+		case "CollisionPolygon2D":
+			body := c.nodes.ancestor(node, isPhysicsBody)
+			if body != nil && body.Type == "Area2D" {
+				continue
+			}
+			item = c.newCollisionPolygon(node, body)
+		default:
+			continue
+		}
+		item.Name = node.Name
+		item.Path = node.Path
+		item.Groups = c.nodeGroups(node)
+		item.NodeScript = c.nodeScript(node)
+		item.Metadata = c.nodeMetadata(node)
+		geometry = append(geometry, item)
+	}
+	return geometry
+}
+
+func (c *TSCNConverter) newPolygon2D(node *sceneNode) Geometry {
+	item := Geometry{Type: node.Type, Closed: true, Color: &Color{R: 1, G: 1, B: 1, A: 1}}
+	// Polygon2D draws its points shifted by offset
+	local := IdentityTransform
+	if value, exists := node.Properties["offset"]; exists {
+		local.Position = parseVector2Value(value)
+	}
+	item.Points = c.worldPoints(node, local, node.Properties["polygon"])
+	if color, ok := parseColorValue(node.Properties["color"]); ok {
+		item.Color = &color
+	}
+	if value, exists := node.Properties["texture"]; exists {
+		item.Texture = c.textures.resolve(value)
+	}
+	return item
+}
+
+func (c *TSCNConverter) newLine2D(node *sceneNode) Geometry {
+	item := Geometry{
+		Type:   node.Type,
+		Closed: node.Properties["closed"] == "true",
+		Width:  10,
+		Color:  &Color{R: 0.4, G: 0.5, B: 1, A: 1},
+	}
+	item.Points = c.worldPoints(node, IdentityTransform, node.Properties["points"])
+	if value, exists := node.Properties["width"]; exists {
+		item.Width, _ = strconv.ParseFloat(value, 64)
+	}
+	if color, ok := parseColorValue(node.Properties["default_color"]); ok {
+		item.Color = &color
+	}
+	if value, exists := node.Properties["texture"]; exists {
+		item.Texture = c.textures.resolve(value)
+	}
+	return item
+}
+
+func (c *TSCNConverter) newCollisionPolygon(node *sceneNode, body *sceneNode) Geometry {
+	item := Geometry{
+		Type:      node.Type,
+		Closed:    true, // both build modes follow the closed outline
+		BuildMode: "solids",
+		Disabled:  node.Properties["disabled"] == "true",
+		OneWay:    node.Properties["one_way_collision"] == "true",
+	}
+	if node.intProperty("build_mode", 0) == 1 {
+		item.BuildMode = "segments"
+	}
+	item.Points = c.worldPoints(node, IdentityTransform, node.Properties["polygon"])
+	if body != nil {
+		item.Body = body.Type
+		item.CollisionLayer = uint32(body.intProperty("collision_layer", 1))
+		item.CollisionMask = uint32(body.intProperty("collision_mask", 1))
+	}
+	return item
+}
+
+// worldPoints converts a PackedVector2Array value of a node to output coordinates
+func (c *TSCNConverter) worldPoints(node *sceneNode, local Transform2D, value string) []Vec2 {
+	transform := c.nodes.globalTransform(node).Mul(local)
+	points := []Vec2{}
+	for _, p := range c.extractPolygonPoints(value) {
+		points = append(points, outputPoint(transform.Xform(p)))
+	}
+	return points
+}
+
+// parseColorValue parses a Color(r, g, b, a) value
+func parseColorValue(value string) (Color, bool) {
+	parsed, err := parseVariant(strings.TrimSpace(value))
+	if err != nil {
+		return Color{}, false
+	}
+	call, ok := parsed.(variantCall)
+	if !ok {
+		return Color{}, false
+	}
+	return parseColorCall(call)
+}
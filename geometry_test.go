@@ -0,0 +1,71 @@
+package tscnparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGeometry(t *testing.T) {
+	data := parseNodes(t, "", `
+[node name="Floor" type="Polygon2D" parent="."]
+position = Vector2(16, 0)
+offset = Vector2(0, 8)
+color = Color(1, 0, 0, 1)
+polygon = PackedVector2Array(0, 0, 16, 0, 16, 16)
+
+[node name="Rope" type="Line2D" parent="."]
+scale = Vector2(2, 2)
+points = PackedVector2Array(0, 0, 8, 0)
+width = 4.0
+closed = true
+
+[node name="Wall" type="StaticBody2D" parent="."]
+position = Vector2(40, 24)
+collision_layer = 2
+
+[node name="Outline" type="CollisionPolygon2D" parent="Wall"]
+build_mode = 1
+polygon = PackedVector2Array(0, 0, 8, 0, 8, 8)
+one_way_collision = true
+
+[node name="Zone" type="Area2D" parent="."]
+
+[node name="ZoneOutline" type="CollisionPolygon2D" parent="Zone"]
+polygon = PackedVector2Array(0, 0, 8, 0, 8, 8)
+`)
+	want := []Geometry{
+		{
+			Name:   "Floor",
+			Path:   "Floor",
+			Type:   "Polygon2D",
+			Points: []Vec2{{X: 64, Y: -40}, {X: 80, Y: -40}, {X: 80, Y: -56}},
+			Closed: true,
+			Color:  &Color{R: 1, G: 0, B: 0, A: 1},
+		},
+		{
+			Name:   "Rope",
+			Path:   "Rope",
+			Type:   "Line2D",
+			Points: []Vec2{{X: 48, Y: -32}, {X: 64, Y: -32}},
+			Closed: true,
+			Color:  &Color{R: 0.4, G: 0.5, B: 1, A: 1},
+			Width:  4,
+		},
+		// The collision polygon of the area is one of its trigger shapes instead
+		{
+			Name:           "Outline",
+			Path:           "Wall/Outline",
+			Type:           "CollisionPolygon2D",
+			Points:         []Vec2{{X: 88, Y: -56}, {X: 96, Y: -56}, {X: 96, Y: -64}},
+			Closed:         true,
+			BuildMode:      "segments",
+			Body:           "StaticBody2D",
+			CollisionLayer: 2,
+			CollisionMask:  1,
+			OneWay:         true,
+		},
+	}
+	if !reflect.DeepEqual(data.Geometry, want) {
+		t.Errorf("geometry = %+v\nwant %+v", data.Geometry, want)
+	}
+}
@@ -4,4 +4,4 @@ go 1.21
 
 require github.com/goplus/spbase v0.1.0
 
-require golang.org/x/image v0.23.0 // indirect
+require golang.org/x/image v0.23.0
@@ -0,0 +1,244 @@
+// Package godot builds a Godot 4 scene from parsed map data, the reverse of the parser, so
+// that generated levels can be opened in the editor.
+package godot
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// Options configure the generated scene
+type Options struct {
+	Name string // name of the root node, "Level" if empty
+	// TileMapNode writes the layers as layer_N properties of a single TileMap node
+	// (Godot 4.0 to 4.2), which the parser reads back, instead of TileMapLayer nodes
+	TileMapNode bool
+}
+
+// Scene is a generated scene with the warnings raised while building it
+type Scene struct {
+	*tscnparser.Document
+	Warnings []string
+}
+
+// Generate builds a scene holding a TileSet with an atlas source per tile source, the tile
+// layers, the decorators as Sprite2D nodes and the instanced sprites as PackedScene
+// instances.
+//
+// Positions, rotations and tile coordinates are converted back to scene space with the
+// data's Coordinates, so nodes and tiles return to where they were in the parsed scene.
+// Decorators built from instanced sprites by ConvertToTilemap are written once, as
+// instances.
+func Generate(data *tscnparser.MapData, opts Options) (*Scene, error) {
+	if data == nil {
+		return nil, fmt.Errorf("no data to generate a scene from")
+	}
+	if opts.Name == "" {
+		opts.Name = "Level"
+	}
+	doc := tscnparser.NewDocument()
+	doc.Insert(0, tscnparser.NewSection("gd_scene",
+		tscnparser.Attribute{Key: "load_steps", Value: "1"},
+		tscnparser.Attribute{Key: "format", Value: "3"}))
+	g := &generator{
+		data:      data,
+		opts:      opts,
+		coords:    data.Coordinates,
+		scene:     &Scene{Document: doc},
+		resources: make(map[string]string),
+		paths:     map[string]string{".": "."},
+		children:  make(map[string]map[string]bool),
+	}
+	doc.AddNode(sanitizeName(opts.Name, "Level"), "Node2D", "")
+
+	tileSet := g.tileSet()
+	if err := g.tileLayers(tileSet); err != nil {
+		return nil, err
+	}
+	for _, node := range g.sceneNodes() {
+		node.add(g)
+	}
+	return g.scene, nil
+}
+
+type generator struct {
+	data   *tscnparser.MapData
+	opts   Options
+	coords tscnparser.Coordinates
+	scene  *Scene
+	// ext_resource ids by type and path
+	resources map[string]string
+	// Node paths of the scene by their path in the data, and the names taken under each node
+	paths    map[string]string
+	children map[string]map[string]bool
+}
+
+func (g *generator) warnf(format string, args ...any) {
+	g.scene.Warnings = append(g.scene.Warnings, fmt.Sprintf(format, args...))
+}
+
+// extResource returns the id of the ext_resource of a path, declaring it the first time
+func (g *generator) extResource(resourceType, resPath string) string {
+	key := resourceType + " " + resPath
+	if id, exists := g.resources[key]; exists {
+		return id
+	}
+	id := g.scene.AddExtResource(resourceType, resPath).StringAttr("id")
+	g.resources[key] = id
+	return id
+}
+
+// resourceType guesses the type of an external resource from its extension
+func resourceType(resPath string) string {
+	switch strings.ToLower(path.Ext(resPath)) {
+	case ".png", ".jpg", ".jpeg", ".webp", ".svg", ".bmp", ".tga":
+		return "Texture2D"
+	case ".tscn", ".scn":
+		return "PackedScene"
+	case ".gd":
+		return "Script"
+	case ".wav", ".ogg", ".mp3":
+		return "AudioStream"
+	}
+	return "Resource"
+}
+
+// addNode adds a node under the node at a data path, creating missing parents as Node2D
+// nodes. Names are made valid and unique among their siblings. It returns the node and
+// registers it under its own data path.
+func (g *generator) addNode(name, nodeType, parent, dataPath string) *tscnparser.Section {
+	node := g.addChild(name, nodeType, g.nodePath(parent))
+	if _, exists := g.paths[dataPath]; !exists {
+		g.paths[dataPath] = node.NodePath()
+	}
+	return node
+}
+
+// addChild adds a node under the node at a scene path
+func (g *generator) addChild(name, nodeType, parentPath string) *tscnparser.Section {
+	name = g.uniqueName(parentPath, sanitizeName(name, nodeType))
+	return g.scene.AddNode(name, nodeType, parentPath)
+}
+
+// nodePath returns the scene path of the node at a data path, creating it if needed
+func (g *generator) nodePath(dataPath string) string {
+	if dataPath == "" {
+		dataPath = "."
+	}
+	if scenePath, exists := g.paths[dataPath]; exists {
+		return scenePath
+	}
+	parent, name := ".", dataPath
+	if i := strings.LastIndex(dataPath, "/"); i >= 0 {
+		parent, name = dataPath[:i], dataPath[i+1:]
+	}
+	g.addNode(name, "Node2D", parent, dataPath)
+	return g.paths[dataPath]
+}
+
+func (g *generator) uniqueName(parentPath, name string) string {
+	taken := g.children[parentPath]
+	if taken == nil {
+		taken = make(map[string]bool)
+		g.children[parentPath] = taken
+	}
+	unique := name
+	for n := 2; taken[unique]; n++ {
+		unique = fmt.Sprintf("%s%d", name, n)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// sanitizeName replaces the characters Godot does not allow in node names
+func sanitizeName(name, fallback string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(".:@/\"%", r) {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return fallback
+	}
+	return name
+}
+
+func joinPath(parent, name string) string {
+	if parent == "." {
+		return name
+	}
+	return parent + "/" + name
+}
+
+// setValue sets a property, recording a warning when the value cannot be written
+func (g *generator) setValue(node *tscnparser.Section, key string, value any) {
+	if err := node.SetValue(key, value); err != nil {
+		g.warnf("node %s: %v", node.NodePath(), err)
+	}
+}
+
+// setProperties writes properties in key order. References to external resources are
+// declared; sub-resources of the original scene are not available and are skipped.
+func (g *generator) setProperties(node *tscnparser.Section, prefix string, properties tscnparser.Properties) {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, ok := g.resolveResources(properties[key])
+		if !ok {
+			g.warnf("node %s: property %s references a sub-resource and is skipped", node.NodePath(), prefix+key)
+			continue
+		}
+		g.setValue(node, prefix+key, value)
+	}
+}
+
+// resolveResources replaces the resource references of a value with references to the
+// scene's ext_resources. It reports false when the value references a sub-resource or an
+// external resource without a path.
+func (g *generator) resolveResources(value any) (any, bool) {
+	switch v := value.(type) {
+	case tscnparser.ResourceRef:
+		if v.Kind != "ExtResource" || v.Path == "" {
+			return nil, false
+		}
+		return tscnparser.ResourceRef{Kind: v.Kind, ID: g.extResource(resourceType(v.Path), v.Path)}, true
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			resolved, ok := g.resolveResources(item)
+			if !ok {
+				return nil, false
+			}
+			items[i] = resolved
+		}
+		return items, true
+	case map[string]any:
+		dict := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved, ok := g.resolveResources(item)
+			if !ok {
+				return nil, false
+			}
+			dict[key] = resolved
+		}
+		return dict, true
+	}
+	return value, true
+}
+
+// setNodeInfo writes the groups and metadata of a node
+func (g *generator) setNodeInfo(node *tscnparser.Section, groups []string, metadata tscnparser.Properties) {
+	if len(groups) > 0 {
+		text, _ := tscnparser.FormatValue(groups)
+		node.SetAttr("groups", text)
+	}
+	g.setProperties(node, "metadata/", metadata)
+}
@@ -0,0 +1,201 @@
+package godot
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// roundTripScene has a 2x2 layer whose tiles are flipped horizontally, vertically and in
+// all three ways, a decorator and an instanced crate
+const roundTripScene = `[gd_scene load_steps=6 format=3]
+
+[ext_resource type="Texture2D" path="res://tiles.png" id="1_t"]
+[ext_resource type="Texture2D" path="res://lamp.png" id="2_l"]
+[ext_resource type="PackedScene" path="res://scenes/crate.tscn" id="3_c"]
+
+[sub_resource type="TileSetAtlasSource" id="TileSetAtlasSource_1"]
+texture = ExtResource("1_t")
+texture_region_size = Vector2i(16, 16)
+0:0/0 = 0
+1:0/0 = 0
+
+[sub_resource type="TileSet" id="TileSet_1"]
+sources/0 = SubResource("TileSetAtlasSource_1")
+
+[node name="Root" type="Node2D"]
+
+[node name="TileMap" type="TileMap" parent="."]
+tile_set = SubResource("TileSet_1")
+format = 2
+layer_0/name = "ground"
+layer_0/tile_data = PackedInt32Array(0, 0, 0, 1, 65536, 268435456, 65536, 0, 536870912, 65537, 65536, 1879048192)
+layer_1/name = "top"
+layer_1/z_index = 1
+layer_1/tile_data = PackedInt32Array(65537, 0, 1073741824)
+
+[node name="Lamp" type="Sprite2D" parent="."]
+position = Vector2(8, 24)
+rotation = 0.5
+texture = ExtResource("2_l")
+
+[node name="Crate" parent="." instance=ExtResource("3_c")]
+position = Vector2(40, 8)
+`
+
+const roundTripPrefab = `[gd_scene format=3]
+
+[node name="Crate" type="Node2D"]
+
+[node name="Sprite2D" type="Sprite2D" parent="."]
+position = Vector2(0, -4)
+`
+
+// parseScene writes a scene next to the crate prefab and parses it
+func parseScene(t *testing.T, dir, scene string) *tscnparser.MapData {
+	t.Helper()
+	path := filepath.Join(dir, "main.tscn")
+	if err := os.WriteFile(path, []byte(scene), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := tscnparser.Parse(path)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	return data
+}
+
+func TestGenerateRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "crate.tscn"), []byte(roundTripPrefab), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tscnparser.SetPrefabsDir("") })
+	tscnparser.SetPrefabsDir(dir)
+
+	data := parseScene(t, dir, roundTripScene)
+	flags := []int{0, tscnparser.TileFlipH, tscnparser.TileFlipV, tscnparser.TileFlipH | tscnparser.TileFlipV | tscnparser.TileTranspose}
+	if len(data.TileMap.Layers) != 2 || !reflect.DeepEqual(data.TileMap.Layers[0].TileFlags, flags) {
+		t.Fatalf("layers of the source scene: %+v", data.TileMap.Layers)
+	}
+	scene, err := Generate(data, Options{TileMapNode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scene.Warnings) > 0 {
+		t.Errorf("warnings: %v", scene.Warnings)
+	}
+	generated := parseScene(t, dir, string(scene.Bytes()))
+
+	for i, layer := range data.TileMap.Layers {
+		got := generated.TileMap.Layers[i]
+		if got.Name != layer.Name || got.ZIndex != layer.ZIndex || !reflect.DeepEqual(got.TileData, layer.TileData) || !reflect.DeepEqual(got.TileFlags, layer.TileFlags) {
+			t.Errorf("layer %d = %+v, want %+v", i, got, layer)
+		}
+	}
+	if len(generated.Decorators) != 1 || len(data.Decorators) != 1 {
+		t.Fatalf("decorators: %d, want %d", len(generated.Decorators), len(data.Decorators))
+	}
+	if got, want := generated.Decorators[0], data.Decorators[0]; got.Name != want.Name || got.Path != want.Path || got.Position != want.Position || got.Rotation != want.Rotation {
+		t.Errorf("decorator = %+v, want %+v", got, want)
+	}
+	if len(generated.Sprites) != 1 || len(data.Sprites) != 1 {
+		t.Fatalf("sprites: %d, want %d", len(generated.Sprites), len(data.Sprites))
+	}
+	if got, want := generated.Sprites[0], data.Sprites[0]; got.Name != want.Name || got.Path != want.Path || got.Position != want.Position {
+		t.Errorf("sprite = %+v, want %+v", got, want)
+	}
+	if len(generated.Prefabs) != 1 || generated.Prefabs[0].Path != data.Prefabs[0].Path {
+		t.Errorf("prefabs = %+v, want %+v", generated.Prefabs, data.Prefabs)
+	}
+}
+
+// cell is a tile of the layer tile data Godot stores, in scene tile coordinates
+type cell struct {
+	x, y, source, atlasX, atlasY, alternative int
+}
+
+func TestTileMapLayerData(t *testing.T) {
+	data := &tscnparser.MapData{TileMap: tscnparser.TileMapData{
+		TileSet: tscnparser.TileSet{Sources: []tscnparser.TileSource{{ID: 2, TexturePath: "res://tiles.png"}}},
+		Layers: []tscnparser.Layer{{
+			Name:      "ground",
+			TileData:  []int{2, 0, 0, 1, 0, 2, -1, 3, 2, 0, 2, 300, -2, 3, 4},
+			TileFlags: []int{0, tscnparser.TileFlipH | tscnparser.TileTranspose, tscnparser.TileFlipV},
+		}},
+	}}
+	data.Coordinates = tscnparser.Coordinates{System: tscnparser.CoordinatesGodot}
+	// Godot's TileSetAtlasSource::TRANSFORM_FLIP_H, TRANSFORM_FLIP_V and TRANSFORM_TRANSPOSE
+	want := []cell{
+		{0, 0, 2, 1, 0, 0},
+		{-1, 3, 2, 2, 0, 1<<12 | 1<<14},
+		{300, -2, 2, 3, 4, 1 << 13},
+	}
+
+	legacy, err := Generate(data, Options{TileMapNode: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, _ := legacy.Node("TileMap").Value("layer_0/tile_data")
+	var got []cell
+	args := packedInts(t, value)
+	for i := 0; i+2 < len(args); i += 3 {
+		got = append(got, cell{
+			x:           int(int16(args[i])),
+			y:           int(int16(uint32(args[i]) >> 16)),
+			source:      int(uint16(args[i+1])),
+			atlasX:      int(uint16(uint32(args[i+1]) >> 16)),
+			atlasY:      int(uint16(args[i+2])),
+			alternative: int(uint16(uint32(args[i+2]) >> 16)),
+		})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tile_data cells = %v, want %v", got, want)
+	}
+
+	layers, err := Generate(data, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, _ = layers.Node("TileMap/ground").Value("tile_map_data")
+	var bytes []byte
+	for _, b := range packedInts(t, value) {
+		bytes = append(bytes, byte(b))
+	}
+	if len(bytes) != 2+12*len(want) || binary.LittleEndian.Uint16(bytes) != 0 {
+		t.Fatalf("tile_map_data = %v", bytes)
+	}
+	got = nil
+	for i := 2; i < len(bytes); i += 12 {
+		field := func(n int) uint16 { return binary.LittleEndian.Uint16(bytes[i+2*n:]) }
+		got = append(got, cell{int(int16(field(0))), int(int16(field(1))), int(field(2)), int(field(3)), int(field(4)), int(field(5))})
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tile_map_data cells = %v, want %v", got, want)
+	}
+}
+
+// packedInts returns the integers of a packed array value
+func packedInts(t *testing.T, value any) []int32 {
+	t.Helper()
+	args, ok := value.([]any)
+	if !ok {
+		t.Fatalf("%#v is not a packed array", value)
+	}
+	ints := make([]int32, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case int:
+			ints[i] = int32(arg)
+		case float64:
+			ints[i] = int32(arg)
+		default:
+			t.Fatalf("%#v is not an integer", arg)
+		}
+	}
+	return ints
+}
@@ -0,0 +1,182 @@
+package godot
+
+import (
+	"sort"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// sceneNode is a decorator or instanced sprite to add to the scene
+type sceneNode struct {
+	treeOrder int
+	add       func(g *generator)
+}
+
+// sceneNodes returns the decorators and instanced sprites in their original tree order,
+// so that parents are added before their children
+func (g *generator) sceneNodes() []sceneNode {
+	instanced := make(map[string]bool)
+	var nodes []sceneNode
+	for _, sprite := range g.data.Sprites {
+		sprite := sprite
+		instanced[joinPath(parentPath(sprite.Parent), sprite.Name)] = true
+		nodes = append(nodes, sceneNode{sprite.DrawOrder.TreeOrder, func(g *generator) { g.instance(sprite) }})
+	}
+	for _, decorator := range g.data.Decorators {
+		decorator := decorator
+		if decorator.NodePath != "" && instanced[decorator.NodePath] {
+			continue
+		}
+		nodes = append(nodes, sceneNode{decorator.DrawOrder.TreeOrder, func(g *generator) { g.decorator(decorator) }})
+	}
+	sort.SliceStable(nodes, func(i, j int) bool { return nodes[i].treeOrder < nodes[j].treeOrder })
+	return nodes
+}
+
+func parentPath(parent string) string {
+	if parent == "" {
+		return "."
+	}
+	return parent
+}
+
+// instance writes an instanced sprite as an instance of its PackedScene
+func (g *generator) instance(sprite tscnparser.SpriteNode) {
+	dataPath := joinPath(parentPath(sprite.Parent), sprite.Name)
+	node := g.addNode(sprite.Name, "", sprite.Parent, dataPath)
+	if sprite.Path == "" || sprite.Path == "unknown" {
+		g.warnf("sprite %s has no scene and is written as a Node2D", dataPath)
+		node.SetStringAttr("type", "Node2D")
+	} else {
+		node.SetAttr("instance", `ExtResource("`+g.extResource("PackedScene", sprite.Path)+`")`)
+	}
+	g.setNodeInfo(node, sprite.Groups, nil)
+	g.setTransform(node, sprite.Position, sprite.Rotation, sprite.Scale)
+	properties := make(tscnparser.Properties, len(sprite.Properties))
+	for key, value := range sprite.Properties {
+		switch key {
+		case "position", "rotation", "scale":
+		default:
+			properties[key] = value
+		}
+	}
+	g.setProperties(node, "", properties)
+	if sprite.Script != "" && sprite.Script != g.prefabScript(sprite.Path) {
+		g.setValue(node, "script", tscnparser.ResourceRef{Kind: "ExtResource", ID: g.extResource("Script", sprite.Script)})
+	}
+	g.setProperties(node, "metadata/", sprite.Metadata)
+}
+
+// prefabScript returns the script of the root of an instanced scene
+func (g *generator) prefabScript(scenePath string) string {
+	for _, prefab := range g.data.Prefabs {
+		if prefab.Path == scenePath {
+			return prefab.Script
+		}
+	}
+	return ""
+}
+
+// decorator writes a decorator as a Sprite2D, or an AnimatedSprite2D when it has animations
+func (g *generator) decorator(decorator tscnparser.DecoratorNode) {
+	nodeType := "Sprite2D"
+	if len(decorator.Animations) > 0 {
+		nodeType = "AnimatedSprite2D"
+	}
+	dataPath := decorator.NodePath
+	if dataPath == "" {
+		dataPath = joinPath(parentPath(decorator.Parent), decorator.Name)
+	}
+	node := g.addNode(decorator.Name, nodeType, decorator.Parent, dataPath)
+	g.setNodeInfo(node, decorator.Groups, nil)
+
+	if nodeType == "AnimatedSprite2D" {
+		g.setValue(node, "sprite_frames", tscnparser.ResourceRef{Kind: "SubResource", ID: g.spriteFrames(decorator.Animations)})
+		if name := decorator.Animations[0].Name; name != "default" {
+			g.setValue(node, "animation", name)
+		}
+	} else {
+		texture := decorator.TextureRef
+		if texture == nil && decorator.Path != "" && decorator.Path != "unknown" {
+			texture = &tscnparser.TextureRef{Path: decorator.Path}
+		}
+		if texture != nil && texture.Path != "" {
+			g.setValue(node, "texture", tscnparser.ResourceRef{Kind: "ExtResource", ID: g.extResource("Texture2D", texture.Path)})
+		}
+		if decorator.Hframes > 1 {
+			g.setValue(node, "hframes", decorator.Hframes)
+		}
+		if decorator.Vframes > 1 {
+			g.setValue(node, "vframes", decorator.Vframes)
+		}
+		if texture != nil && texture.Region != nil {
+			node.Set("region_enabled", "true")
+			g.setValue(node, "region_rect", *texture.Region)
+			if texture.FilterClip {
+				node.Set("region_filter_clip_enabled", "true")
+			}
+		}
+	}
+	if decorator.Frame != 0 {
+		g.setValue(node, "frame", decorator.Frame)
+	}
+	if decorator.Pivot != (tscnparser.Vec2{}) {
+		g.setValue(node, "offset", g.coords.Vector(decorator.Pivot))
+	}
+	if decorator.ZIndex != 0 {
+		g.setValue(node, "z_index", int(decorator.ZIndex))
+	}
+	g.setTransform(node, decorator.Position, decorator.Rotation, decorator.Scale)
+	if decorator.Script != "" {
+		g.setValue(node, "script", tscnparser.ResourceRef{Kind: "ExtResource", ID: g.extResource("Script", decorator.Script)})
+	}
+	g.setProperties(node, "metadata/", decorator.Metadata)
+}
+
+// setTransform writes the position, rotation and scale of a node given in output
+// coordinates, leaving out defaults. A zero scale means the scale is unset.
+func (g *generator) setTransform(node *tscnparser.Section, position tscnparser.Vec2, rotation float64, scale tscnparser.Vec2) {
+	if position = g.coords.ScenePoint(position); position != (tscnparser.Vec2{}) {
+		g.setValue(node, "position", position)
+	}
+	if rotation = g.coords.Rotation(rotation); rotation != 0 {
+		g.setValue(node, "rotation", rotation)
+	}
+	if scale != (tscnparser.Vec2{}) && scale != (tscnparser.Vec2{X: 1, Y: 1}) {
+		g.setValue(node, "scale", scale)
+	}
+}
+
+// spriteFrames writes a SpriteFrames resource for the animations and returns its id.
+// Frames showing a region of their texture get an AtlasTexture.
+func (g *generator) spriteFrames(animations []tscnparser.SpriteAnimation) string {
+	list := make([]any, 0, len(animations))
+	for _, animation := range animations {
+		frames := make([]any, 0, len(animation.Frames))
+		for _, frame := range animation.Frames {
+			entry := map[string]any{"duration": frame.Duration}
+			if frame.Texture.Path != "" {
+				texture := tscnparser.ResourceRef{Kind: "ExtResource", ID: g.extResource("Texture2D", frame.Texture.Path)}
+				if frame.Texture.Region != nil {
+					atlas := g.scene.AddSubResource("AtlasTexture")
+					g.setValue(atlas, "atlas", texture)
+					g.setValue(atlas, "region", *frame.Texture.Region)
+					texture = tscnparser.ResourceRef{Kind: "SubResource", ID: atlas.StringAttr("id")}
+				}
+				entry["texture"] = texture
+			} else {
+				entry["texture"] = nil
+			}
+			frames = append(frames, entry)
+		}
+		list = append(list, map[string]any{
+			"frames": frames,
+			"loop":   animation.Loop,
+			"name":   animation.Name,
+			"speed":  animation.Speed,
+		})
+	}
+	resource := g.scene.AddSubResource("SpriteFrames")
+	g.setValue(resource, "animations", list)
+	return resource.StringAttr("id")
+}
@@ -0,0 +1,261 @@
+package godot
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// Alternative tile bits of a flipped or transposed tile
+const (
+	transformFlipH     = 1 << 12
+	transformFlipV     = 1 << 13
+	transformTranspose = 1 << 14
+)
+
+// tile is a cell of a layer in scene tile coordinates
+type tile struct {
+	x, y        int
+	source      int
+	atlas       tscnparser.Vec2i
+	alternative int
+}
+
+// tiles decodes the [source_id, tile_x, tile_y, atlas_x, atlas_y] tile data of a layer
+func (g *generator) tiles(layer tscnparser.Layer) []tile {
+	var result []tile
+	for i := 0; i+4 < len(layer.TileData); i += 5 {
+		x, y := g.coords.SceneTile(layer.TileData[i+1], layer.TileData[i+2], g.tileSize())
+		t := tile{
+			x:      x,
+			y:      y,
+			source: layer.TileData[i],
+			atlas:  tscnparser.Vec2i{X: layer.TileData[i+3], Y: layer.TileData[i+4]},
+		}
+		if i/5 < len(layer.TileFlags) {
+			t.alternative = alternative(layer.TileFlags[i/5])
+		}
+		result = append(result, t)
+	}
+	return result
+}
+
+// alternative converts TileFlip* flags to the bits of an alternative tile ID
+func alternative(flags int) int {
+	bits := 0
+	if flags&tscnparser.TileFlipH != 0 {
+		bits |= transformFlipH
+	}
+	if flags&tscnparser.TileFlipV != 0 {
+		bits |= transformFlipV
+	}
+	if flags&tscnparser.TileTranspose != 0 {
+		bits |= transformTranspose
+	}
+	return bits
+}
+
+// tileSet writes a TileSetAtlasSource for every tile source and the TileSet holding them,
+// and returns the id of the TileSet, or "" when there are no tiles
+func (g *generator) tileSet() string {
+	tileMap := g.data.TileMap
+	if len(tileMap.TileSet.Sources) == 0 && len(tileMap.Layers) == 0 {
+		return ""
+	}
+	// Atlas coordinates the layers use, which need a tile even if the source does not list them
+	used := make(map[int][]tscnparser.Vec2i)
+	for _, layer := range tileMap.Layers {
+		for _, t := range g.tiles(layer) {
+			used[t.source] = append(used[t.source], t.atlas)
+		}
+	}
+
+	collision := false
+	sources := make(map[int]string)
+	for _, source := range tileMap.TileSet.Sources {
+		if source.TexturePath == "" {
+			g.warnf("tile source %d has no texture and is skipped", source.ID)
+			continue
+		}
+		atlas := g.scene.AddSubResource("TileSetAtlasSource")
+		g.setValue(atlas, "texture", tscnparser.ResourceRef{Kind: "ExtResource", ID: g.extResource("Texture2D", source.TexturePath)})
+		if source.Margins != (tscnparser.Vec2i{}) {
+			g.setValue(atlas, "margins", source.Margins)
+		}
+		if source.Separation != (tscnparser.Vec2i{}) {
+			g.setValue(atlas, "separation", source.Separation)
+		}
+		if source.TextureRegionSize != (tscnparser.Vec2i{}) {
+			g.setValue(atlas, "texture_region_size", source.TextureRegionSize)
+		}
+
+		infos := append([]tscnparser.TileInfo(nil), source.Tiles...)
+		listed := make(map[tscnparser.Vec2i]bool)
+		for _, info := range infos {
+			listed[info.AtlasCoords] = true
+		}
+		var extra []tscnparser.TileInfo
+		for _, coords := range used[source.ID] {
+			if !listed[coords] {
+				listed[coords] = true
+				extra = append(extra, tscnparser.TileInfo{AtlasCoords: coords})
+			}
+		}
+		sort.Slice(extra, func(i, j int) bool {
+			a, b := extra[i].AtlasCoords, extra[j].AtlasCoords
+			return a.Y < b.Y || (a.Y == b.Y && a.X < b.X)
+		})
+		for _, info := range append(infos, extra...) {
+			key := fmt.Sprintf("%d:%d/", info.AtlasCoords.X, info.AtlasCoords.Y)
+			if size := info.SizeInAtlas; size.X > 0 && size.Y > 0 && (size.X != 1 || size.Y != 1) {
+				g.setValue(atlas, key+"size_in_atlas", size)
+			}
+			atlas.Set(key+"0", "0")
+			if points := info.Physics.CollisionPoints; len(points) >= 3 {
+				collision = true
+				g.setValue(atlas, key+"0/physics_layer_0/polygon_0/points", g.packedVector2Array(points))
+			}
+		}
+		sources[source.ID] = atlas.StringAttr("id")
+	}
+
+	tileSize := g.tileSize()
+	tileSet := g.scene.AddSubResource("TileSet")
+	g.setValue(tileSet, "tile_size", tscnparser.Vec2i{X: tileSize.Width, Y: tileSize.Height})
+	if collision {
+		tileSet.Set("physics_layer_0/collision_layer", "1")
+	}
+	ids := make([]int, 0, len(sources))
+	for id := range sources {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		g.setValue(tileSet, fmt.Sprintf("sources/%d", id), tscnparser.ResourceRef{Kind: "SubResource", ID: sources[id]})
+	}
+	return tileSet.StringAttr("id")
+}
+
+// tileSize returns the tile size of the map, 16x16 when it is not set
+func (g *generator) tileSize() tscnparser.TileSize {
+	tileSize := g.data.TileMap.TileSize
+	if tileSize.Width <= 0 || tileSize.Height <= 0 {
+		tileSize = tscnparser.TileSize{Width: 16, Height: 16}
+	}
+	return tileSize
+}
+
+// packedVector2Array writes points in a node's local space, converting them to scene space
+func (g *generator) packedVector2Array(points []tscnparser.Vec2) tscnparser.Variant {
+	args := make([]any, 0, 2*len(points))
+	for _, p := range points {
+		p = g.coords.Vector(p)
+		args = append(args, p.X, p.Y)
+	}
+	return tscnparser.Variant{Type: "PackedVector2Array", Args: args}
+}
+
+// tileLayers writes the layers as TileMapLayer nodes under a TileMap node, or as the
+// layer_N properties of a TileMap node
+func (g *generator) tileLayers(tileSet string) error {
+	tileMap := g.data.TileMap
+	if tileSet == "" {
+		return nil
+	}
+	nodeType := "Node2D"
+	if g.opts.TileMapNode {
+		nodeType = "TileMap"
+	}
+	node := g.addChild("TileMap", nodeType, ".")
+	g.setNodeInfo(node, tileMap.Groups, tileMap.Metadata)
+	tileSetRef := tscnparser.ResourceRef{Kind: "SubResource", ID: tileSet}
+
+	if g.opts.TileMapNode {
+		g.setValue(node, "tile_set", tileSetRef)
+		node.Set("format", "2")
+		for i, layer := range tileMap.Layers {
+			key := fmt.Sprintf("layer_%d/", i)
+			g.setValue(node, key+"name", layer.Name)
+			if layer.ZIndex != 0 {
+				g.setValue(node, key+"z_index", layer.ZIndex)
+			}
+			data, err := legacyTileData(g.tiles(layer))
+			if err != nil {
+				return fmt.Errorf("layer %q: %w", layer.Name, err)
+			}
+			g.setValue(node, key+"tile_data", tscnparser.Variant{Type: "PackedInt32Array", Args: data})
+		}
+		return nil
+	}
+
+	parent := node.NodePath()
+	for i, layer := range tileMap.Layers {
+		name := layer.Name
+		if name == "" {
+			name = fmt.Sprintf("Layer%d", i)
+		}
+		layerNode := g.addChild(name, "TileMapLayer", parent)
+		if layer.ZIndex != 0 {
+			g.setValue(layerNode, "z_index", layer.ZIndex)
+		}
+		data, err := tileMapData(g.tiles(layer))
+		if err != nil {
+			return fmt.Errorf("layer %q: %w", layer.Name, err)
+		}
+		g.setValue(layerNode, "tile_map_data", tscnparser.Variant{Type: "PackedByteArray", Args: data})
+		g.setValue(layerNode, "tile_set", tileSetRef)
+	}
+	return nil
+}
+
+// tileMapData encodes the tile_map_data of a TileMapLayer: a format version of 0, then
+// per tile the coordinates as int16, and the source ID, atlas coordinates and alternative
+// tile as uint16, little endian
+func tileMapData(cells []tile) ([]any, error) {
+	buf := make([]byte, 2, 2+12*len(cells))
+	for _, t := range cells {
+		if err := checkTile(t); err != nil {
+			return nil, err
+		}
+		for _, v := range []int{t.x, t.y, t.source, t.atlas.X, t.atlas.Y, t.alternative} {
+			buf = binary.LittleEndian.AppendUint16(buf, uint16(v))
+		}
+	}
+	data := make([]any, len(buf))
+	for i, b := range buf {
+		data[i] = int(b)
+	}
+	return data, nil
+}
+
+// legacyTileData encodes the tile_data of a TileMap layer, three int32 per tile: the
+// coordinates, the source ID with the atlas X coordinate, and the atlas Y coordinate
+// with the alternative tile
+func legacyTileData(cells []tile) ([]any, error) {
+	data := make([]any, 0, 3*len(cells))
+	for _, t := range cells {
+		if err := checkTile(t); err != nil {
+			return nil, err
+		}
+		data = append(data,
+			int(int32(uint32(uint16(t.y))<<16|uint32(uint16(t.x)))),
+			int(int32(uint32(t.atlas.X)<<16|uint32(t.source))),
+			int(int32(uint32(t.alternative)<<16|uint32(t.atlas.Y))))
+	}
+	return data, nil
+}
+
+// checkTile reports tiles that do not fit the 16-bit fields of the tile data
+func checkTile(t tile) error {
+	if t.x < -32768 || t.x > 32767 || t.y < -32768 || t.y > 32767 {
+		return fmt.Errorf("tile (%d, %d) is out of range", t.x, t.y)
+	}
+	for _, v := range []int{t.source, t.atlas.X, t.atlas.Y} {
+		if v < 0 || v > 0xFFFF {
+			return fmt.Errorf("tile (%d, %d) has an invalid source %d or atlas coordinates (%d, %d)", t.x, t.y, t.source, t.atlas.X, t.atlas.Y)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package tscnparser
+
+import (
+	"maps"
+	"slices"
+)
+
+// GroupMember is a node of the scene returned by MapData.NodesInGroup
+type GroupMember struct {
+	Kind string // decorator, sprite, prefab, trigger, marker, path, geometry, light, occluder, camera, parallax_layer or parallax_sprite
+	Path string // node path in the scene, or the scene path for prefabs
+	Node any    // pointer to the entry in MapData, e.g. *DecoratorNode for decorators
+}
+
+// nodeGroups returns the groups of a node together with those of the root of its instanced scene
+func (c *TSCNConverter) nodeGroups(node *sceneNode) []string {
+	root := c.instanceRoot(node)
+	if root == nil {
+		return node.Groups
+	}
+	groups := slices.Clone(root.Groups)
+	for _, group := range node.Groups {
+		if !slices.Contains(groups, group) {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// nodeMetadata returns the typed metadata of a node, on top of the metadata of the root of its
+// instanced scene. ExtResource references carry the paths of the file they were read from.
+func (c *TSCNConverter) nodeMetadata(node *sceneNode) Properties {
+	metadata := node.metadata()
+	c.resolveResourcePaths(metadata)
+	if root := c.instanceRoot(node); root != nil {
+		if inherited := root.metadata(); inherited != nil {
+			info := c.prefabCache[c.extResources[node.Instance].Path]
+			resolveExtResourcePaths(inherited, info.ExtResources)
+			maps.Copy(inherited, metadata)
+			metadata = inherited
+		}
+	}
+	return metadata
+}
+
+// resolveGroups fills the groups and metadata of the tilemap, decorators and instanced sprites
+func (c *TSCNConverter) resolveGroups(data *MapData) {
+	for i := range data.Decorators {
+		decorator := &data.Decorators[i]
+		decorator.NodePath = joinNodePath(decorator.Parent, decorator.Name)
+		if node, exists := c.nodes.nodes[decorator.NodePath]; exists {
+			decorator.Groups = c.nodeGroups(node)
+			decorator.Metadata = c.nodeMetadata(node)
+		}
+	}
+	for i := range data.Sprites {
+		sprite := &data.Sprites[i]
+		if node, exists := c.nodes.nodes[joinNodePath(sprite.Parent, sprite.Name)]; exists {
+			sprite.Groups = c.nodeGroups(node)
+			sprite.Metadata = c.nodeMetadata(node)
+		}
+	}
+	for _, node := range c.nodes.order {
+		if node.Type == "TileMap" {
+			data.TileMap.Groups = c.nodeGroups(node)
+			data.TileMap.Metadata = c.nodeMetadata(node)
+			break
+		}
+	}
+}
+
+// NodesInGroup returns the exported nodes that belong to a group, by kind and then in output order.
+// Prefabs are matched by the groups of their scene's root node.
+func (m *MapData) NodesInGroup(group string) []GroupMember {
+	var members []GroupMember
+	m.eachNode(func(ref NodeRef, path string, groups []string, node any) {
+		if slices.Contains(groups, group) {
+			members = append(members, GroupMember{Kind: ref.Kind, Path: path, Node: node})
+		}
+	})
+	return members
+}
+
+// eachNode calls fn for every exported node entry with a reference to it, its node path, groups and
+// a pointer to the entry
+func (m *MapData) eachNode(fn func(ref NodeRef, path string, groups []string, node any)) {
+	for i := range m.Decorators {
+		fn(NodeRef{Kind: "decorator", Index: i}, m.Decorators[i].NodePath, m.Decorators[i].Groups, &m.Decorators[i])
+	}
+	for i := range m.Sprites {
+		fn(NodeRef{Kind: "sprite", Index: i}, joinNodePath(m.Sprites[i].Parent, m.Sprites[i].Name), m.Sprites[i].Groups, &m.Sprites[i])
+	}
+	for i := range m.Prefabs {
+		fn(NodeRef{Kind: "prefab", Index: i}, m.Prefabs[i].Path, m.Prefabs[i].Groups, &m.Prefabs[i])
+	}
+	for i := range m.Triggers {
+		fn(NodeRef{Kind: "trigger", Index: i}, m.Triggers[i].Path, m.Triggers[i].Groups, &m.Triggers[i])
+	}
+	for i := range m.Markers {
+		fn(NodeRef{Kind: "marker", Index: i}, m.Markers[i].Path, m.Markers[i].Groups, &m.Markers[i])
+	}
+	for i := range m.Paths {
+		fn(NodeRef{Kind: "path", Index: i}, m.Paths[i].Path, m.Paths[i].Groups, &m.Paths[i])
+	}
+	for i := range m.Geometry {
+		fn(NodeRef{Kind: "geometry", Index: i}, m.Geometry[i].Path, m.Geometry[i].Groups, &m.Geometry[i])
+	}
+	if m.Lighting != nil {
+		for i := range m.Lighting.Lights {
+			fn(NodeRef{Kind: "light", Index: i}, m.Lighting.Lights[i].Path, m.Lighting.Lights[i].Groups, &m.Lighting.Lights[i])
+		}
+		for i := range m.Lighting.Occluders {
+			fn(NodeRef{Kind: "occluder", Index: i}, m.Lighting.Occluders[i].Path, m.Lighting.Occluders[i].Groups, &m.Lighting.Occluders[i])
+		}
+	}
+	for i := range m.Cameras {
+		fn(NodeRef{Kind: "camera", Index: i}, m.Cameras[i].Path, m.Cameras[i].Groups, &m.Cameras[i])
+	}
+	for i := range m.Parallax {
+		layer := &m.Parallax[i]
+		fn(NodeRef{Kind: "parallax_layer", Index: i}, layer.Path, layer.Groups, layer)
+		// Parallax sprites are indexed within their layer
+		for j := range layer.Sprites {
+			fn(NodeRef{Kind: "parallax_sprite", Layer: i, Index: j}, layer.Sprites[j].Path, layer.Sprites[j].Groups, &layer.Sprites[j])
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package tscnparser
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "golang.org/x/image/webp"
+)
+
+// resolveResourcePath converts a Godot res:// path to a file system path under the project root
+func resolveResourcePath(resPath string) string {
+	if projectRoot == "" || !strings.HasPrefix(resPath, "res://") {
+		return ""
+	}
+	return filepath.Join(projectRoot, filepath.FromSlash(strings.TrimPrefix(resPath, "res://")))
+}
+
+// readImageSize reads the pixel dimensions of a PNG, JPEG, WebP or SVG file
+func readImageSize(filePath string) (int, int, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(filePath), ".svg") {
+		return readSVGSize(file)
+	}
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to decode image %s: %w", filePath, err)
+	}
+	return config.Width, config.Height, nil
+}
+
+// readSVGSize reads the size of an SVG document from its width/height attributes,
+// falling back to the viewBox, as Godot does when importing at scale 1
+func readSVGSize(file *os.File) (int, int, error) {
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to read svg %s: %w", file.Name(), err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "svg" {
+			continue
+		}
+		var width, height float64
+		var viewBox []string
+		for _, attr := range start.Attr {
+			switch attr.Name.Local {
+			case "width":
+				width = parseSVGLength(attr.Value)
+			case "height":
+				height = parseSVGLength(attr.Value)
+			case "viewBox":
+				viewBox = strings.Fields(strings.ReplaceAll(attr.Value, ",", " "))
+			}
+		}
+		if (width == 0 || height == 0) && len(viewBox) == 4 {
+			width, _ = strconv.ParseFloat(viewBox[2], 64)
+			height, _ = strconv.ParseFloat(viewBox[3], 64)
+		}
+		if width == 0 || height == 0 {
+			return 0, 0, fmt.Errorf("svg %s has no size", file.Name())
+		}
+		return int(width + 0.5), int(height + 0.5), nil
+	}
+}
+
+func parseSVGLength(value string) float64 {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+	length, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		// Relative units such as "100%" carry no intrinsic size
+		return 0
+	}
+	return length
+}
+
+// imageSizeCache reads each image at most once per conversion
+type imageSizeCache struct {
+	sizes  map[string]Vec2i
+	failed map[string]bool
+}
+
+func newImageSizeCache() *imageSizeCache {
+	return &imageSizeCache{
+		sizes:  make(map[string]Vec2i),
+		failed: make(map[string]bool),
+	}
+}
+
+// fill records the image size on a texture reference, returning an error the first time an image cannot be read
+func (cache *imageSizeCache) fill(texture *TextureRef) error {
+	if texture == nil || texture.Path == "" {
+		return nil
+	}
+	if size, exists := cache.sizes[texture.Path]; exists {
+		texture.ImageWidth, texture.ImageHeight = size.X, size.Y
+		return nil
+	}
+	if cache.failed[texture.Path] {
+		return nil
+	}
+	filePath := resolveResourcePath(texture.Path)
+	if filePath == "" {
+		return nil
+	}
+	width, height, err := readImageSize(filePath)
+	if err != nil {
+		cache.failed[texture.Path] = true
+		return err
+	}
+	cache.sizes[texture.Path] = Vec2i{X: width, Y: height}
+	texture.ImageWidth, texture.ImageHeight = width, height
+	return nil
+}
+
+// loadTextureSizes records image sizes on every texture reference in the map data
+// and returns warnings for regions and frame grids that don't fit their images
+func loadTextureSizes(data *MapData) []string {
+	if projectRoot == "" {
+		return nil
+	}
+	cache := newImageSizeCache()
+	var warnings []string
+	fill := func(texture *TextureRef) {
+		if err := cache.fill(texture); err != nil {
+			warnings = append(warnings, err.Error())
+		}
+	}
+	fillAnimations := func(animations []SpriteAnimation) {
+		for i := range animations {
+			for j := range animations[i].Frames {
+				fill(&animations[i].Frames[j].Texture)
+			}
+		}
+	}
+
+	for i := range data.TileMap.TileSet.Sources {
+		source := &data.TileMap.TileSet.Sources[i]
+		fill(source.TextureRef)
+		warnings = append(warnings, validateTileSource(source)...)
+	}
+	for i := range data.Decorators {
+		decorator := &data.Decorators[i]
+		fill(decorator.TextureRef)
+		fillAnimations(decorator.Animations)
+		warnings = append(warnings, validateSpriteTexture(decorator.Name, decorator.TextureRef, decorator.Hframes, decorator.Vframes)...)
+	}
+	for i := range data.Prefabs {
+		prefab := &data.Prefabs[i]
+		fill(prefab.TextureRef)
+		fillAnimations(prefab.Animations)
+		warnings = append(warnings, validateSpriteTexture(prefab.Name, prefab.TextureRef, prefab.Hframes, prefab.Vframes)...)
+	}
+	for i := range data.Geometry {
+		fill(data.Geometry[i].Texture)
+	}
+	for i := range data.Parallax {
+		for j := range data.Parallax[i].Sprites {
+			sprite := &data.Parallax[i].Sprites[j]
+			fill(sprite.Texture)
+			fillAnimations(sprite.Animations)
+		}
+	}
+	if data.Lighting != nil {
+		for i := range data.Lighting.Lights {
+			fill(data.Lighting.Lights[i].Texture)
+		}
+	}
+	return warnings
+}
+
+// textureBounds returns the area of the image a texture covers
+func textureBounds(texture *TextureRef) Rect2 {
+	if texture.Region != nil {
+		return *texture.Region
+	}
+	return Rect2{Width: float64(texture.ImageWidth), Height: float64(texture.ImageHeight)}
+}
+
+// validateRegion checks that a texture's region lies within its image
+func validateRegion(owner string, texture *TextureRef) []string {
+	if texture == nil || texture.Region == nil || texture.ImageWidth == 0 {
+		return nil
+	}
+	region := texture.Region
+	if region.X < 0 || region.Y < 0 ||
+		region.X+region.Width > float64(texture.ImageWidth) || region.Y+region.Height > float64(texture.ImageHeight) {
+		return []string{fmt.Sprintf("%s: region (%g, %g, %g, %g) exceeds image %s (%dx%d)",
+			owner, region.X, region.Y, region.Width, region.Height, texture.Path, texture.ImageWidth, texture.ImageHeight)}
+	}
+	return nil
+}
+
+// validateSpriteTexture checks a Sprite2D region and its hframes/vframes grid against the image
+func validateSpriteTexture(name string, texture *TextureRef, hframes, vframes int) []string {
+	if texture == nil || texture.ImageWidth == 0 {
+		return nil
+	}
+	owner := fmt.Sprintf("sprite %q", name)
+	warnings := validateRegion(owner, texture)
+	bounds := textureBounds(texture)
+	if hframes > 1 && int(bounds.Width)%hframes != 0 {
+		warnings = append(warnings, fmt.Sprintf("%s: width %g of %s is not divisible by hframes %d",
+			owner, bounds.Width, texture.Path, hframes))
+	}
+	if vframes > 1 && int(bounds.Height)%vframes != 0 {
+		warnings = append(warnings, fmt.Sprintf("%s: height %g of %s is not divisible by vframes %d",
+			owner, bounds.Height, texture.Path, vframes))
+	}
+	return warnings
+}
+
+// validateTileSource checks that every tile lies within the atlas grid of the image
+func validateTileSource(source *TileSource) []string {
+	texture := source.TextureRef
+	if texture == nil || texture.ImageWidth == 0 {
+		return nil
+	}
+	owner := fmt.Sprintf("tile source %d", source.ID)
+	warnings := validateRegion(owner, texture)
+	region := source.TextureRegionSize
+	if region.X <= 0 || region.Y <= 0 {
+		return warnings
+	}
+
+	bounds := textureBounds(texture)
+	columns := atlasGridSize(int(bounds.Width), source.Margins.X, region.X, source.Separation.X)
+	rows := atlasGridSize(int(bounds.Height), source.Margins.Y, region.Y, source.Separation.Y)
+	for _, tile := range source.Tiles {
+		size := tile.SizeInAtlas
+		if size.X <= 0 || size.Y <= 0 {
+			size = Vec2i{X: 1, Y: 1}
+		}
+		if tile.AtlasCoords.X+size.X > columns || tile.AtlasCoords.Y+size.Y > rows {
+			warnings = append(warnings, fmt.Sprintf("%s: tile %d:%d (size %dx%d) lies outside the %dx%d atlas grid of %s",
+				owner, tile.AtlasCoords.X, tile.AtlasCoords.Y, size.X, size.Y, columns, rows, texture.Path))
+		}
+	}
+	return warnings
+}
+
+// atlasGridSize is the number of tiles along one axis of an atlas, like Godot's
+// get_atlas_grid_size: the margin only applies at the top left, and pixels left over at the
+// bottom right that do not fit a whole tile are ignored
+func atlasGridSize(size, margin, region, separation int) int {
+	usable := size - margin
+	if usable < region {
+		return 0
+	}
+	return (usable-region)/(region+separation) + 1
+}
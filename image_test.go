@@ -0,0 +1,39 @@
+package tscnparser
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateTileSourceGrid(t *testing.T) {
+	tests := []struct {
+		name                string
+		width, height       int
+		margins, separation Vec2i
+		inside, outside     Vec2i // last tile in the grid and first one past it
+	}{
+		{"exact", 64, 32, Vec2i{}, Vec2i{}, Vec2i{X: 3, Y: 1}, Vec2i{X: 4, Y: 1}},
+		// 100 - 4 leaves 96 pixels: six 16 pixel tiles, not 5 as when the margin counts twice
+		{"margins", 100, 100, Vec2i{X: 4, Y: 4}, Vec2i{}, Vec2i{X: 5, Y: 5}, Vec2i{X: 6, Y: 0}},
+		{"separation", 100, 40, Vec2i{X: 2, Y: 2}, Vec2i{X: 2, Y: 2}, Vec2i{X: 4, Y: 1}, Vec2i{X: 0, Y: 2}},
+		{"trailing pixels", 70, 20, Vec2i{}, Vec2i{}, Vec2i{X: 3, Y: 0}, Vec2i{X: 4, Y: 0}},
+	}
+	for _, tt := range tests {
+		source := func(coords Vec2i) *TileSource {
+			return &TileSource{
+				TextureRef:        &TextureRef{Path: "res://atlas.png", ImageWidth: tt.width, ImageHeight: tt.height},
+				TextureRegionSize: Vec2i{X: 16, Y: 16},
+				Margins:           tt.margins,
+				Separation:        tt.separation,
+				Tiles:             []TileInfo{{AtlasCoords: coords}},
+			}
+		}
+		if warnings := validateTileSource(source(tt.inside)); len(warnings) != 0 {
+			t.Errorf("%s: tile %v: %v", tt.name, tt.inside, warnings)
+		}
+		warnings := validateTileSource(source(tt.outside))
+		if len(warnings) != 1 || !strings.Contains(warnings[0], "outside") {
+			t.Errorf("%s: tile %v: %v, want it outside the grid", tt.name, tt.outside, warnings)
+		}
+	}
+}
@@ -0,0 +1,393 @@
+package ldtk
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// entityType is an entity definition with the fields its instances set
+type entityType struct {
+	def *EntityDef
+	// Field names in the order they were first set, their identifiers and LDtk types
+	keys        []string
+	identifiers map[string]string
+	types       map[string]string
+	taken       map[string]bool
+}
+
+// entity is an entity instance placed in scene pixels (Y axis down) at its pivot
+type entity struct {
+	kind           *entityType
+	key            string // node path, unique in the scene
+	x, y           float64
+	width, height  int
+	pivotX, pivotY float64
+	values         map[string]any
+}
+
+// set sets a field of the entity, widening the field's type when instances disagree
+func (en *entity) set(name string, value any) {
+	if value == nil {
+		return
+	}
+	kind := en.kind
+	valueType := fieldType(value)
+	if current, exists := kind.types[name]; !exists {
+		kind.keys = append(kind.keys, name)
+		kind.identifiers[name] = unique(kind.taken, identifier(name, "field"))
+		kind.types[name] = valueType
+	} else if current != valueType {
+		if (current == "Int" || current == "Float") && (valueType == "Int" || valueType == "Float") {
+			kind.types[name] = "Float"
+		} else {
+			kind.types[name] = "String"
+		}
+	}
+	en.values[name] = value
+}
+
+// setAll sets the fields of a property map in name order
+func (en *entity) setAll(properties tscnparser.Properties) {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		en.set(name, properties[name])
+	}
+}
+
+// fieldType returns the LDtk type of a field holding the value; values without a
+// counterpart are stored as JSON strings
+func fieldType(value any) string {
+	switch value.(type) {
+	case int:
+		return "Int"
+	case float64:
+		return "Float"
+	case bool:
+		return "Bool"
+	case tscnparser.Color:
+		return "Color"
+	}
+	return "String"
+}
+
+// entityType returns the entity definition for the key, adding it named after name if needed
+func (e *exporter) entityType(key, name, fallback, color string, width, height int, pivot float64) *entityType {
+	if kind, exists := e.entities[key]; exists {
+		return kind
+	}
+	kind := &entityType{
+		def: &EntityDef{
+			Identifier:       e.uniqueIdentifier(name, fallback),
+			UID:              e.uid(),
+			Tags:             []string{},
+			Width:            width,
+			Height:           height,
+			TileOpacity:      1,
+			FillOpacity:      0.08,
+			LineOpacity:      1,
+			Hollow:           true,
+			Color:            color,
+			RenderMode:       "Rectangle",
+			ShowName:         true,
+			TileRenderMode:   "FitInside",
+			NineSliceBorders: []int{},
+			LimitScope:       "PerLevel",
+			LimitBehavior:    "MoveLastOne",
+			PivotX:           pivot,
+			PivotY:           pivot,
+			FieldDefs:        []*FieldDef{},
+		},
+		identifiers: make(map[string]string),
+		types:       make(map[string]string),
+		taken:       make(map[string]bool),
+	}
+	e.entities[key] = kind
+	e.entityOrder = append(e.entityOrder, kind)
+	e.p.Defs.Entities = append(e.p.Defs.Entities, kind.def)
+	return kind
+}
+
+func (e *exporter) newEntity(kind *entityType, key string, position tscnparser.Vec2) *entity {
+	en := &entity{
+		kind:   kind,
+		key:    key,
+		x:      position.X,
+		y:      position.Y,
+		width:  kind.def.Width,
+		height: kind.def.Height,
+		pivotX: kind.def.PivotX,
+		pivotY: kind.def.PivotY,
+		values: make(map[string]any),
+	}
+	en.set("node", key)
+	e.placedEntity = append(e.placedEntity, en)
+	return en
+}
+
+// collectEntities converts prefab instances, markers and triggers to entities
+func (e *exporter) collectEntities() {
+	e.prefabs()
+	e.markers()
+	e.triggers()
+}
+
+// prefabs exports an entity type per instanced scene, sized to the prefab's texture, and
+// the instances with their properties as fields
+func (e *exporter) prefabs() {
+	prefabs := make(map[string]tscnparser.PrefabNode)
+	for _, prefab := range e.data.Prefabs {
+		prefabs[prefab.Path] = prefab
+	}
+	for _, sprite := range e.data.Sprites {
+		prefab, exists := prefabs[sprite.Path]
+		width, height := e.grid, e.grid
+		if exists {
+			if w, h := textureSize(prefab.TextureRef); w > 0 && h > 0 {
+				scale := prefab.Scale
+				if scale.X == 0 && scale.Y == 0 {
+					scale = tscnparser.Vec2{X: 1, Y: 1}
+				}
+				width = max(int(math.Round(w*math.Abs(scale.X))), 1)
+				height = max(int(math.Round(h*math.Abs(scale.Y))), 1)
+			}
+		}
+		name := strings.TrimSuffix(path.Base(sprite.Path), path.Ext(sprite.Path))
+		kind := e.entityType(sprite.Path, name, "Prefab", prefabColor, width, height, 0.5)
+		if kind.def.Doc == nil {
+			doc := sprite.Path
+			kind.def.Doc = &doc
+		}
+		center := e.scenePoint(sprite.Position)
+		offset := rotate(e.coords.Vector(prefab.Pivot), e.coords.Rotation(sprite.Rotation))
+		center.X, center.Y = center.X+offset.X, center.Y+offset.Y
+		en := e.newEntity(kind, nodePath(sprite.Parent, sprite.Name), center)
+		en.set("rotation", sprite.Rotation)
+		en.setAll(sprite.Properties)
+	}
+}
+
+// textureSize returns the size of a texture or its region, zero if unknown
+func textureSize(texture *tscnparser.TextureRef) (float64, float64) {
+	if texture == nil {
+		return 0, 0
+	}
+	if region := texture.Region; region != nil {
+		return region.Width, region.Height
+	}
+	return float64(texture.ImageWidth), float64(texture.ImageHeight)
+}
+
+// markers exports markers as point entities with their metadata as fields
+func (e *exporter) markers() {
+	for _, marker := range e.data.Markers {
+		kind := e.entityType("marker", "Marker", "Marker", markerColor, e.grid, e.grid, 0.5)
+		en := e.newEntity(kind, marker.Path, e.scenePoint(marker.Position))
+		en.set("rotation", marker.Rotation)
+		en.setAll(marker.Metadata)
+	}
+}
+
+// triggers exports triggers as resizable entities covering the bounding box of their
+// shapes, keeping the shapes as JSON
+func (e *exporter) triggers() {
+	for _, trigger := range e.data.Triggers {
+		kind := e.entityType("trigger", "Trigger", "Trigger", triggerColor, e.grid, e.grid, 0)
+		kind.def.ResizableX, kind.def.ResizableY = true, true
+		transform := e.coords.SceneTransform(trigger.Transform)
+
+		var points []tscnparser.Vec2
+		for _, shape := range trigger.Shapes {
+			local := e.coords.LocalTransform(shape.Transform)
+			points = append(points, shapePoints(transform.Mul(local), shape.Type, e.coords.ColliderParams(shape.Type, shape.Params))...)
+		}
+		if len(points) == 0 {
+			points = []tscnparser.Vec2{transform.Position}
+		}
+		minX, minY, maxX, maxY := points[0].X, points[0].Y, points[0].X, points[0].Y
+		for _, p := range points {
+			minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+			maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+		}
+		en := e.newEntity(kind, trigger.Path, tscnparser.Vec2{X: minX, Y: minY})
+		en.width = max(int(math.Round(maxX-minX)), 1)
+		en.height = max(int(math.Round(maxY-minY)), 1)
+		en.set("collision_layer", int(trigger.CollisionLayer))
+		en.set("collision_mask", int(trigger.CollisionMask))
+		en.set("shapes", jsonString(trigger.Shapes))
+		en.setAll(trigger.Metadata)
+	}
+}
+
+// shapePoints returns points outlining a collision shape with the given transform in scene pixels
+func shapePoints(transform tscnparser.Transform2D, shapeType string, params []float64) []tscnparser.Vec2 {
+	var local []tscnparser.Vec2
+	switch {
+	case shapeType == tscnparser.ColliderRect && len(params) >= 2:
+		local = box(params[0]/2, params[1]/2)
+	case shapeType == tscnparser.ColliderCircle && len(params) >= 1:
+		local = box(params[0], params[0])
+	case shapeType == tscnparser.ColliderCapsule && len(params) >= 2:
+		local = box(params[0], params[1]/2)
+	case shapeType == tscnparser.ColliderPolygon, shapeType == tscnparser.ColliderConcave, shapeType == tscnparser.ColliderSegment:
+		for i := 0; i+1 < len(params); i += 2 {
+			local = append(local, tscnparser.Vec2{X: params[i], Y: params[i+1]})
+		}
+	}
+	if len(local) == 0 {
+		return []tscnparser.Vec2{transform.Position}
+	}
+	points := make([]tscnparser.Vec2, len(local))
+	for i, p := range local {
+		points[i] = transform.Xform(p)
+	}
+	return points
+}
+
+// box returns the corners of a box centered on the origin
+func box(halfWidth, halfHeight float64) []tscnparser.Vec2 {
+	return []tscnparser.Vec2{
+		{X: -halfWidth, Y: -halfHeight}, {X: halfWidth, Y: -halfHeight},
+		{X: halfWidth, Y: halfHeight}, {X: -halfWidth, Y: halfHeight},
+	}
+}
+
+// entityLayer defines the fields of the entity types and places the entities in an
+// entity layer above the tile layers, or returns nil if the scene has none
+func (e *exporter) entityLayer(level *Level) *LayerInstance {
+	if len(e.placedEntity) == 0 {
+		return nil
+	}
+	fields := make(map[*entityType]map[string]*FieldDef)
+	for _, kind := range e.entityOrder {
+		fields[kind] = make(map[string]*FieldDef)
+		for _, key := range kind.keys {
+			def := newFieldDef(kind.identifiers[key], kind.types[key], e.uid())
+			kind.def.FieldDefs = append(kind.def.FieldDefs, def)
+			fields[kind][key] = def
+		}
+	}
+
+	def := e.layerDef("Entities", "Entities", "Entities")
+	layer := e.layerInstance(def, level)
+	for _, en := range e.placedEntity {
+		px := [2]int{int(math.Round(en.x)) - e.originX, int(math.Round(en.y)) - e.originY}
+		instance := &EntityInstance{
+			Identifier:     en.kind.def.Identifier,
+			Grid:           [2]int{floorDiv(px[0], e.grid), floorDiv(px[1], e.grid)},
+			Pivot:          [2]float64{en.pivotX, en.pivotY},
+			Tags:           en.kind.def.Tags,
+			SmartColor:     en.kind.def.Color,
+			IID:            iid("entity", en.key),
+			Width:          en.width,
+			Height:         en.height,
+			DefUID:         en.kind.def.UID,
+			Px:             px,
+			FieldInstances: []*FieldInstance{},
+			WorldX:         level.WorldX + px[0],
+			WorldY:         level.WorldY + px[1],
+		}
+		for _, key := range en.kind.keys {
+			instance.FieldInstances = append(instance.FieldInstances, fieldInstance(fields[en.kind][key], en.values[key]))
+		}
+		layer.EntityInstances = append(layer.EntityInstances, instance)
+	}
+	return layer
+}
+
+func newFieldDef(id, fieldType string, uid int) *FieldDef {
+	return &FieldDef{
+		Identifier:          id,
+		TypeName:            fieldType,
+		UID:                 uid,
+		Type:                "F_" + fieldType,
+		CanBeNull:           true,
+		EditorDisplayMode:   "Hidden",
+		EditorDisplayScale:  1,
+		EditorDisplayPos:    "Above",
+		EditorLinkStyle:     "StraightArrow",
+		EditorShowInWorld:   true,
+		EditorCutLongValues: true,
+		AutoChainRef:        true,
+		AllowOutOfLevelRef:  true,
+		AllowedRefs:         "OnlySame",
+		AllowedRefTags:      []string{},
+	}
+}
+
+// fieldInstance converts a value to the type of its field. Entities without the field get null.
+func fieldInstance(def *FieldDef, value any) *FieldInstance {
+	field := &FieldInstance{
+		Identifier:       def.Identifier,
+		Type:             def.TypeName,
+		DefUID:           def.UID,
+		RealEditorValues: []EditorValue{},
+	}
+	if value == nil {
+		return field
+	}
+	var editorValue any
+	switch def.TypeName {
+	case "Int", "Bool":
+		field.Value, editorValue = value, value
+	case "Float":
+		if v, ok := value.(int); ok {
+			value = float64(v)
+		}
+		field.Value, editorValue = value, value
+	case "Color":
+		c := value.(tscnparser.Color)
+		rgb := channel(c.R)<<16 | channel(c.G)<<8 | channel(c.B)
+		field.Value, editorValue = fmt.Sprintf("#%06X", rgb), rgb
+	default:
+		text, ok := value.(string)
+		if !ok {
+			text = jsonString(value)
+		}
+		field.Value, editorValue = text, text
+	}
+	field.RealEditorValues = []EditorValue{{ID: "V_" + editorType(def.TypeName), Params: []any{editorValue}}}
+	return field
+}
+
+// editorType returns the type of the editor value stored for a field type
+func editorType(fieldType string) string {
+	if fieldType == "Color" {
+		return "Int"
+	}
+	return fieldType
+}
+
+// channel converts a color channel to 0-255
+func channel(v float64) int {
+	return int(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+func floorDiv(a, b int) int {
+	return int(math.Floor(float64(a) / float64(b)))
+}
+
+// nodePath returns the scene path of a node from its parent path and name
+func nodePath(parent, name string) string {
+	switch parent {
+	case "":
+		return "."
+	case ".":
+		return name
+	}
+	return parent + "/" + name
+}
+
+// rotate rotates a vector by radians, clockwise with the Y axis down
+func rotate(v tscnparser.Vec2, angle float64) tscnparser.Vec2 {
+	sin, cos := math.Sincos(angle)
+	return tscnparser.Vec2{X: v.X*cos - v.Y*sin, Y: v.X*sin + v.Y*cos}
+}
@@ -0,0 +1,432 @@
+package ldtk
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// Options configure the export
+type Options struct {
+	LevelName string // identifier of the level, "Level_0" if empty
+	// ImagePath converts the texture paths of the scene to the image paths written to the
+	// tilesets. By default res:// is stripped, so images resolve for a project saved in the
+	// Godot project root.
+	ImagePath func(texturePath string) string
+}
+
+// Colors of the entity definitions
+const (
+	prefabColor  = "#94D9B3"
+	markerColor  = "#FFCC00"
+	triggerColor = "#BE4A2F"
+)
+
+// Export converts parsed scene data to an LDtk project with a single level.
+//
+// Output positions are converted back to scene space with the data's Coordinates, and the
+// level covers the tiles and entities of the scene, aligned to the tile grid. The level's
+// worldX and worldY hold the scene position of its top left corner, so a scene position is
+// (x + worldX, y + worldY) for a level position (x, y).
+func Export(data *tscnparser.MapData, opts Options) (*Project, error) {
+	if data == nil {
+		return nil, fmt.Errorf("no data to export")
+	}
+	if opts.LevelName == "" {
+		opts.LevelName = "Level_0"
+	}
+	if opts.ImagePath == nil {
+		opts.ImagePath = func(texturePath string) string {
+			return strings.TrimPrefix(texturePath, "res://")
+		}
+	}
+
+	tileSize := data.TileMap.TileSize
+	if tileSize.Width <= 0 || tileSize.Height <= 0 {
+		tileSize = tscnparser.TileSize{Width: 16, Height: 16}
+	}
+	e := &exporter{
+		data:     data,
+		opts:     opts,
+		coords:   data.Coordinates,
+		tileSize: tileSize,
+		grid:     tileSize.Width,
+		p:        newProject(tileSize.Width),
+		sources:  make(map[int]*sourceTileset),
+		entities: make(map[string]*entityType),
+	}
+	if tileSize.Width != tileSize.Height {
+		e.warn("tile size %dx%d is not square, LDtk layers use a grid of %d", tileSize.Width, tileSize.Height, e.grid)
+	}
+	e.tilesets()
+	e.collectEntities()
+	e.bounds()
+
+	level := &Level{
+		Identifier:      identifier(opts.LevelName, "Level_0"),
+		IID:             iid("level"),
+		UID:             e.uid(),
+		WorldX:          e.originX,
+		WorldY:          e.originY,
+		PxWid:           e.columns * e.grid,
+		PxHei:           e.rows * e.grid,
+		BgColorComputed: e.p.DefaultLevelBgColor,
+		BgPivotX:        0.5,
+		BgPivotY:        0.5,
+		SmartColor:      "#ADADB5",
+		FieldInstances:  []*FieldInstance{},
+		Neighbours:      []any{},
+	}
+	if layer := e.entityLayer(level); layer != nil {
+		level.LayerInstances = append(level.LayerInstances, layer)
+	}
+	level.LayerInstances = append(level.LayerInstances, e.tileLayers(level)...)
+	e.p.Levels = append(e.p.Levels, level)
+	e.p.NextUID = e.nextUID
+	return e.p, nil
+}
+
+func newProject(grid int) *Project {
+	return &Project{
+		Header: Header{
+			FileType:   "LDtk Project JSON",
+			App:        "LDtk",
+			Doc:        "https://ldtk.io/json",
+			Schema:     "https://ldtk.io/files/JSON_SCHEMA.json",
+			AppAuthor:  "Sebastien 'deepnight' Benard",
+			AppVersion: JSONVersion,
+			URL:        "https://ldtk.io",
+		},
+		IID:                 iid("project"),
+		JSONVersion:         JSONVersion,
+		AppBuildID:          473703,
+		IdentifierStyle:     "Free",
+		Toc:                 []any{},
+		WorldLayout:         "Free",
+		WorldGridWidth:      256,
+		WorldGridHeight:     256,
+		DefaultLevelWidth:   256,
+		DefaultLevelHeight:  256,
+		DefaultGridSize:     grid,
+		DefaultEntityWidth:  grid,
+		DefaultEntityHeight: grid,
+		BgColor:             "#40465B",
+		DefaultLevelBgColor: "#696A79",
+		ImageExportMode:     "None",
+		ExportLevelBg:       true,
+		BackupLimit:         10,
+		LevelNamePattern:    "Level_%idx",
+		CustomCommands:      []any{},
+		Flags:               []string{},
+		Defs: Defs{
+			Layers:        []*LayerDef{},
+			Entities:      []*EntityDef{},
+			Tilesets:      []*TilesetDef{},
+			Enums:         []any{},
+			ExternalEnums: []any{},
+			LevelFields:   []any{},
+		},
+		Levels:        []*Level{},
+		Worlds:        []any{},
+		DummyWorldIID: iid("world"),
+	}
+}
+
+type exporter struct {
+	data     *tscnparser.MapData
+	opts     Options
+	coords   tscnparser.Coordinates
+	p        *Project
+	tileSize tscnparser.TileSize
+	grid     int // grid size of the layers
+	nextUID  int
+	// Level bounds in scene pixels (Y axis down) and grid cells
+	originX, originY int
+	columns, rows    int
+	// Tilesets by tile source ID
+	sources map[int]*sourceTileset
+	// Entity types by prefab path or kind, in the order they were defined
+	entities     map[string]*entityType
+	entityOrder  []*entityType
+	placedEntity []*entity
+	// Identifiers taken by layer, entity and tileset definitions
+	identifiers map[string]bool
+}
+
+func (e *exporter) uid() int {
+	e.nextUID++
+	return e.nextUID
+}
+
+func (e *exporter) warn(format string, args ...any) {
+	e.p.Warnings = append(e.p.Warnings, fmt.Sprintf(format, args...))
+}
+
+// uniqueIdentifier returns an identifier for a definition not taken by another one
+func (e *exporter) uniqueIdentifier(name, fallback string) string {
+	if e.identifiers == nil {
+		e.identifiers = make(map[string]bool)
+	}
+	return unique(e.identifiers, identifier(name, fallback))
+}
+
+// cell is a tile of a layer in map columns and rows, with its TileFlip* flags
+type cell struct {
+	column, row int
+	source      int
+	atlas       tscnparser.Vec2i
+	flags       int
+}
+
+// cells decodes the [source_id, tile_x, tile_y, atlas_x, atlas_y] tile data of a layer
+func (e *exporter) cells(layer tscnparser.Layer) []cell {
+	var result []cell
+	for i := 0; i+4 < len(layer.TileData); i += 5 {
+		column, row := e.coords.SceneTile(layer.TileData[i+1], layer.TileData[i+2], e.tileSize)
+		c := cell{
+			column: column,
+			row:    row,
+			source: layer.TileData[i],
+			atlas:  tscnparser.Vec2i{X: layer.TileData[i+3], Y: layer.TileData[i+4]},
+		}
+		if i/5 < len(layer.TileFlags) {
+			c.flags = layer.TileFlags[i/5]
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// bounds sizes the level to the tiles and entities, aligned to the grid
+func (e *exporter) bounds() {
+	first := true
+	var minX, minY, maxX, maxY float64
+	add := func(x0, y0, x1, y1 float64) {
+		if first {
+			minX, minY, maxX, maxY = x0, y0, x1, y1
+			first = false
+		}
+		minX, minY = math.Min(minX, x0), math.Min(minY, y0)
+		maxX, maxY = math.Max(maxX, x1), math.Max(maxY, y1)
+	}
+	for _, layer := range e.data.TileMap.Layers {
+		for _, c := range e.cells(layer) {
+			size := tscnparser.Vec2i{X: 1, Y: 1}
+			if tileset, exists := e.sources[c.source]; exists {
+				size = tileset.size(c.atlas)
+			}
+			x, y := float64(c.column*e.tileSize.Width), float64(c.row*e.tileSize.Height)
+			add(x, y, x+float64(size.X*e.grid), y+float64(size.Y*e.grid))
+		}
+	}
+	for _, entity := range e.placedEntity {
+		x := entity.x - float64(entity.width)*entity.pivotX
+		y := entity.y - float64(entity.height)*entity.pivotY
+		add(x, y, x+float64(entity.width), y+float64(entity.height))
+	}
+	grid := float64(e.grid)
+	column0, row0 := math.Floor(minX/grid), math.Floor(minY/grid)
+	e.originX, e.originY = int(column0)*e.grid, int(row0)*e.grid
+	e.columns = max(int(math.Ceil(maxX/grid)-column0), 1)
+	e.rows = max(int(math.Ceil(maxY/grid)-row0), 1)
+}
+
+// scenePoint converts an output position to scene pixels
+func (e *exporter) scenePoint(p tscnparser.Vec2) tscnparser.Vec2 {
+	return e.coords.ScenePoint(p)
+}
+
+// tileLayers exports a tile layer for every layer and tile source it uses, top to bottom
+// like LDtk lists them. LDtk layers show a single tileset each.
+func (e *exporter) tileLayers(level *Level) []*LayerInstance {
+	layers := make([]tscnparser.Layer, len(e.data.TileMap.Layers))
+	copy(layers, e.data.TileMap.Layers)
+	sort.SliceStable(layers, func(i, j int) bool {
+		a, b := layers[i].DrawOrder, layers[j].DrawOrder
+		if a.CanvasLayer != b.CanvasLayer {
+			return a.CanvasLayer > b.CanvasLayer
+		}
+		return a.ZIndex > b.ZIndex
+	})
+	var instances []*LayerInstance
+	for _, layer := range layers {
+		bySource := make(map[int][]cell)
+		unknown, transposed := 0, 0
+		for _, c := range e.cells(layer) {
+			if _, exists := e.sources[c.source]; !exists {
+				unknown++
+				continue
+			}
+			if c.flags&tscnparser.TileTranspose != 0 {
+				transposed++
+			}
+			bySource[c.source] = append(bySource[c.source], c)
+		}
+		if unknown > 0 {
+			e.warn("layer %q: %d tiles of unknown sources dropped", layer.Name, unknown)
+		}
+		if transposed > 0 {
+			e.warn("layer %q: %d transposed tiles exported without the transpose, LDtk only flips tiles", layer.Name, transposed)
+		}
+		ids := make([]int, 0, len(bySource))
+		for id := range bySource {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			tileset := e.sources[id]
+			name := layer.Name
+			if len(ids) > 1 {
+				name += "_" + tileset.Identifier
+			}
+			def := e.layerDef("Tiles", name, "Tiles")
+			def.TilesetDefUID = &tileset.UID
+			instance := e.layerInstance(def, level)
+			instance.TilesetDefUID, instance.TilesetRelPath = &tileset.UID, tileset.RelPath
+			for _, c := range bySource[id] {
+				instance.GridTiles = append(instance.GridTiles, e.gridTiles(tileset, c)...)
+			}
+			instances = append(instances, instance)
+		}
+	}
+	return instances
+}
+
+// gridTiles places a tile, split into the cells it covers in the atlas
+func (e *exporter) gridTiles(tileset *sourceTileset, c cell) []GridTile {
+	size := tileset.size(c.atlas)
+	flip := 0
+	if c.flags&tscnparser.TileFlipH != 0 {
+		flip |= 1
+	}
+	if c.flags&tscnparser.TileFlipV != 0 {
+		flip |= 2
+	}
+	x := c.column*e.tileSize.Width - e.originX
+	y := c.row*e.tileSize.Height - e.originY
+	var tiles []GridTile
+	for dy := 0; dy < size.Y; dy++ {
+		for dx := 0; dx < size.X; dx++ {
+			// Flipped tiles mirror the cells they cover
+			cx, cy := dx, dy
+			if flip&1 != 0 {
+				cx = size.X - 1 - dx
+			}
+			if flip&2 != 0 {
+				cy = size.Y - 1 - dy
+			}
+			atlas := tscnparser.Vec2i{X: c.atlas.X + cx, Y: c.atlas.Y + cy}
+			px := [2]int{x + dx*e.grid, y + dy*e.grid}
+			tiles = append(tiles, GridTile{
+				Px:  px,
+				Src: tileset.src(atlas),
+				F:   flip,
+				T:   tileset.tileID(atlas),
+				D:   []int{px[1]/e.grid*e.columns + px[0]/e.grid},
+				A:   1,
+			})
+		}
+	}
+	return tiles
+}
+
+// layerDef adds a layer definition below the ones added so far
+func (e *exporter) layerDef(layerType, name, fallback string) *LayerDef {
+	def := &LayerDef{
+		TypeName:               layerType,
+		Identifier:             e.uniqueIdentifier(name, fallback),
+		Type:                   layerType,
+		UID:                    e.uid(),
+		GridSize:               e.grid,
+		DisplayOpacity:         1,
+		InactiveOpacity:        1,
+		HideFieldsWhenInactive: true,
+		CanSelectWhenInactive:  true,
+		RenderInWorldView:      true,
+		ParallaxScaling:        true,
+		RequiredTags:           []string{},
+		ExcludedTags:           []string{},
+		UIFilterTags:           []string{},
+		IntGridValues:          []any{},
+		IntGridValuesGroups:    []any{},
+		AutoRuleGroups:         []any{},
+	}
+	e.p.Defs.Layers = append(e.p.Defs.Layers, def)
+	return def
+}
+
+func (e *exporter) layerInstance(def *LayerDef, level *Level) *LayerInstance {
+	return &LayerInstance{
+		Identifier:      def.Identifier,
+		Type:            def.Type,
+		CWid:            e.columns,
+		CHei:            e.rows,
+		GridSize:        e.grid,
+		Opacity:         1,
+		IID:             iid("layer", def.Identifier),
+		LevelID:         level.UID,
+		LayerDefUID:     def.UID,
+		Visible:         true,
+		OptionalRules:   []int{},
+		IntGridCsv:      []int{},
+		AutoLayerTiles:  []GridTile{},
+		GridTiles:       []GridTile{},
+		EntityInstances: []*EntityInstance{},
+	}
+}
+
+// identifier converts a name to an LDtk identifier: letters, digits and underscores, not
+// starting with a digit. Names without any letter or digit get the fallback.
+func identifier(name, fallback string) string {
+	var b strings.Builder
+	valid := false
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+			valid = true
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if !valid {
+		return fallback
+	}
+	id := b.String()
+	if id[0] >= '0' && id[0] <= '9' {
+		id = "_" + id
+	}
+	return id
+}
+
+// unique returns the identifier, or the first of identifier_2, identifier_3... not taken yet
+func unique(taken map[string]bool, id string) string {
+	result := id
+	for i := 2; taken[result]; i++ {
+		result = fmt.Sprintf("%s_%d", id, i)
+	}
+	taken[result] = true
+	return result
+}
+
+// iid returns a stable UUID for the given key, so exporting a scene twice gives the same project
+func iid(key ...string) string {
+	sum := sha1.Sum([]byte("tscn_parser/" + strings.Join(key, "/")))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// jsonString returns the JSON of a value, for values LDtk fields cannot hold
+func jsonString(value any) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
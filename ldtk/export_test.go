@@ -0,0 +1,101 @@
+package ldtk
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+func TestGridTiles(t *testing.T) {
+	source := tscnparser.TileSource{
+		TexturePath:       "res://tiles.png",
+		TextureRegionSize: tscnparser.Vec2i{X: 16, Y: 16},
+		Margins:           tscnparser.Vec2i{X: 1, Y: 1},
+		Separation:        tscnparser.Vec2i{X: 2, Y: 2},
+		Tiles: []tscnparser.TileInfo{
+			{AtlasCoords: tscnparser.Vec2i{X: 0, Y: 0}, SizeInAtlas: tscnparser.Vec2i{X: 1, Y: 1}},
+			{AtlasCoords: tscnparser.Vec2i{X: 1, Y: 0}, SizeInAtlas: tscnparser.Vec2i{X: 2, Y: 1}},
+		},
+	}
+	// A plain tile at the scene tile (0, 0), and the 2x1 tile at (2, 1) flipped
+	tests := []struct {
+		name  string
+		flags int
+		want  []GridTile
+	}{
+		{"flipped horizontally", tscnparser.TileFlipH, []GridTile{
+			{Px: [2]int{32, 16}, Src: [2]int{37, 1}, F: 1, T: 2, D: []int{6}, A: 1},
+			{Px: [2]int{48, 16}, Src: [2]int{19, 1}, F: 1, T: 1, D: []int{7}, A: 1},
+		}},
+		{"flipped vertically", tscnparser.TileFlipV, []GridTile{
+			{Px: [2]int{32, 16}, Src: [2]int{19, 1}, F: 2, T: 1, D: []int{6}, A: 1},
+			{Px: [2]int{48, 16}, Src: [2]int{37, 1}, F: 2, T: 2, D: []int{7}, A: 1},
+		}},
+		{"flipped both ways", tscnparser.TileFlipH | tscnparser.TileFlipV, []GridTile{
+			{Px: [2]int{32, 16}, Src: [2]int{37, 1}, F: 3, T: 2, D: []int{6}, A: 1},
+			{Px: [2]int{48, 16}, Src: [2]int{19, 1}, F: 3, T: 1, D: []int{7}, A: 1},
+		}},
+	}
+	for _, tt := range tests {
+		data := &tscnparser.MapData{
+			Coordinates: tscnparser.Coordinates{System: tscnparser.CoordinatesGodot},
+			TileMap: tscnparser.TileMapData{
+				TileSize: tscnparser.TileSize{Width: 16, Height: 16},
+				TileSet:  tscnparser.TileSet{Sources: []tscnparser.TileSource{source}},
+				Layers: []tscnparser.Layer{{
+					Name:      "ground",
+					TileData:  []int{0, 0, 0, 0, 0, 0, 2, 1, 1, 0},
+					TileFlags: []int{0, tt.flags},
+				}},
+			},
+		}
+		p, err := Export(data, Options{})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		level := p.Levels[0]
+		if level.PxWid != 64 || level.PxHei != 32 {
+			t.Errorf("%s: level is %dx%d", tt.name, level.PxWid, level.PxHei)
+		}
+		tiles := level.LayerInstances[0].GridTiles
+		if len(tiles) != 3 {
+			t.Fatalf("%s: %d grid tiles", tt.name, len(tiles))
+		}
+		if want := (GridTile{Px: [2]int{0, 0}, Src: [2]int{1, 1}, T: 0, D: []int{0}, A: 1}); !reflect.DeepEqual(tiles[0], want) {
+			t.Errorf("%s: plain tile = %+v, want %+v", tt.name, tiles[0], want)
+		}
+		if !reflect.DeepEqual(tiles[1:], tt.want) {
+			t.Errorf("%s: grid tiles = %+v, want %+v", tt.name, tiles[1:], tt.want)
+		}
+		if len(p.Warnings) != 0 {
+			t.Errorf("%s: warnings %v", tt.name, p.Warnings)
+		}
+	}
+}
+
+func TestTransposedTiles(t *testing.T) {
+	data := &tscnparser.MapData{
+		Coordinates: tscnparser.Coordinates{System: tscnparser.CoordinatesGodot},
+		TileMap: tscnparser.TileMapData{
+			TileSet: tscnparser.TileSet{Sources: []tscnparser.TileSource{{TexturePath: "res://tiles.png"}}},
+			Layers: []tscnparser.Layer{{
+				Name:      "ground",
+				TileData:  []int{0, 0, 0, 0, 0, 0, 1, 0, 0, 0},
+				TileFlags: []int{tscnparser.TileTranspose | tscnparser.TileFlipH, 0},
+			}},
+		},
+	}
+	p, err := Export(data, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// LDtk cannot rotate tiles: the transpose is dropped with a warning and the flip kept
+	if tiles := p.Levels[0].LayerInstances[0].GridTiles; len(tiles) != 2 || tiles[0].F != 1 || tiles[1].F != 0 {
+		t.Errorf("grid tiles = %+v", tiles)
+	}
+	if len(p.Warnings) != 1 || !strings.Contains(p.Warnings[0], "1 transposed tiles") {
+		t.Errorf("warnings = %v", p.Warnings)
+	}
+}
@@ -0,0 +1,324 @@
+// Package ldtk exports parsed scenes as LDtk projects, so levels built in Godot can be
+// opened in LDtk.
+//
+// The project holds a single level covering the tiles and entities of the scene. Tile
+// sources become tilesets, tile layers become one LDtk tile layer per layer and tileset,
+// and prefab instances, markers and triggers become entities with their properties as
+// field values.
+package ldtk
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// JSONVersion is the LDtk version whose project format is written
+const JSONVersion = "1.5.3"
+
+// Project is an LDtk project (.ldtk file)
+type Project struct {
+	Header              Header   `json:"__header__"`
+	IID                 string   `json:"iid"`
+	JSONVersion         string   `json:"jsonVersion"`
+	AppBuildID          int      `json:"appBuildId"`
+	NextUID             int      `json:"nextUid"`
+	IdentifierStyle     string   `json:"identifierStyle"`
+	Toc                 []any    `json:"toc"`
+	WorldLayout         string   `json:"worldLayout"`
+	WorldGridWidth      int      `json:"worldGridWidth"`
+	WorldGridHeight     int      `json:"worldGridHeight"`
+	DefaultLevelWidth   int      `json:"defaultLevelWidth"`
+	DefaultLevelHeight  int      `json:"defaultLevelHeight"`
+	DefaultPivotX       float64  `json:"defaultPivotX"`
+	DefaultPivotY       float64  `json:"defaultPivotY"`
+	DefaultGridSize     int      `json:"defaultGridSize"`
+	DefaultEntityWidth  int      `json:"defaultEntityWidth"`
+	DefaultEntityHeight int      `json:"defaultEntityHeight"`
+	BgColor             string   `json:"bgColor"`
+	DefaultLevelBgColor string   `json:"defaultLevelBgColor"`
+	MinifyJSON          bool     `json:"minifyJson"`
+	ExternalLevels      bool     `json:"externalLevels"`
+	ExportTiled         bool     `json:"exportTiled"`
+	SimplifiedExport    bool     `json:"simplifiedExport"`
+	ImageExportMode     string   `json:"imageExportMode"`
+	ExportLevelBg       bool     `json:"exportLevelBg"`
+	PngFilePattern      *string  `json:"pngFilePattern"`
+	BackupOnSave        bool     `json:"backupOnSave"`
+	BackupLimit         int      `json:"backupLimit"`
+	BackupRelPath       *string  `json:"backupRelPath"`
+	LevelNamePattern    string   `json:"levelNamePattern"`
+	TutorialDesc        *string  `json:"tutorialDesc"`
+	CustomCommands      []any    `json:"customCommands"`
+	Flags               []string `json:"flags"`
+	Defs                Defs     `json:"defs"`
+	Levels              []*Level `json:"levels"`
+	Worlds              []any    `json:"worlds"`
+	DummyWorldIID       string   `json:"dummyWorldIid"`
+	Warnings            []string `json:"-"` // problems found while exporting
+}
+
+// Header identifies the file as an LDtk project
+type Header struct {
+	FileType   string `json:"fileType"`
+	App        string `json:"app"`
+	Doc        string `json:"doc"`
+	Schema     string `json:"schema"`
+	AppAuthor  string `json:"appAuthor"`
+	AppVersion string `json:"appVersion"`
+	URL        string `json:"url"`
+}
+
+// Defs holds the layer, entity and tileset definitions of a project
+type Defs struct {
+	Layers        []*LayerDef   `json:"layers"` // top to bottom
+	Entities      []*EntityDef  `json:"entities"`
+	Tilesets      []*TilesetDef `json:"tilesets"`
+	Enums         []any         `json:"enums"`
+	ExternalEnums []any         `json:"externalEnums"`
+	LevelFields   []any         `json:"levelFields"`
+}
+
+// LayerDef defines a "Tiles" or "Entities" layer
+type LayerDef struct {
+	TypeName                       string   `json:"__type"`
+	Identifier                     string   `json:"identifier"`
+	Type                           string   `json:"type"`
+	UID                            int      `json:"uid"`
+	Doc                            *string  `json:"doc"`
+	UIColor                        *string  `json:"uiColor"`
+	GridSize                       int      `json:"gridSize"`
+	GuideGridWid                   int      `json:"guideGridWid"`
+	GuideGridHei                   int      `json:"guideGridHei"`
+	DisplayOpacity                 float64  `json:"displayOpacity"`
+	InactiveOpacity                float64  `json:"inactiveOpacity"`
+	HideInList                     bool     `json:"hideInList"`
+	HideFieldsWhenInactive         bool     `json:"hideFieldsWhenInactive"`
+	CanSelectWhenInactive          bool     `json:"canSelectWhenInactive"`
+	RenderInWorldView              bool     `json:"renderInWorldView"`
+	PxOffsetX                      int      `json:"pxOffsetX"`
+	PxOffsetY                      int      `json:"pxOffsetY"`
+	ParallaxFactorX                float64  `json:"parallaxFactorX"`
+	ParallaxFactorY                float64  `json:"parallaxFactorY"`
+	ParallaxScaling                bool     `json:"parallaxScaling"`
+	RequiredTags                   []string `json:"requiredTags"`
+	ExcludedTags                   []string `json:"excludedTags"`
+	AutoTilesKilledByOtherLayerUID *int     `json:"autoTilesKilledByOtherLayerUid"`
+	UIFilterTags                   []string `json:"uiFilterTags"`
+	UseAsyncRender                 bool     `json:"useAsyncRender"`
+	IntGridValues                  []any    `json:"intGridValues"`
+	IntGridValuesGroups            []any    `json:"intGridValuesGroups"`
+	AutoRuleGroups                 []any    `json:"autoRuleGroups"`
+	AutoSourceLayerDefUID          *int     `json:"autoSourceLayerDefUid"`
+	TilesetDefUID                  *int     `json:"tilesetDefUid"`
+	TilePivotX                     float64  `json:"tilePivotX"`
+	TilePivotY                     float64  `json:"tilePivotY"`
+	BiomeFieldUID                  *int     `json:"biomeFieldUid"`
+}
+
+// TilesetDef defines a tileset laid out as a grid of square tiles
+type TilesetDef struct {
+	CWid              int          `json:"__cWid"`
+	CHei              int          `json:"__cHei"`
+	Identifier        string       `json:"identifier"`
+	UID               int          `json:"uid"`
+	RelPath           *string      `json:"relPath"`
+	EmbedAtlas        *string      `json:"embedAtlas"`
+	PxWid             int          `json:"pxWid"`
+	PxHei             int          `json:"pxHei"`
+	TileGridSize      int          `json:"tileGridSize"`
+	Spacing           int          `json:"spacing"`
+	Padding           int          `json:"padding"`
+	Tags              []string     `json:"tags"`
+	TagsSourceEnumUID *int         `json:"tagsSourceEnumUid"`
+	EnumTags          []any        `json:"enumTags"`
+	CustomData        []CustomData `json:"customData"`
+	SavedSelections   []any        `json:"savedSelections"`
+	CachedPixelData   any          `json:"cachedPixelData"`
+}
+
+// CustomData is the custom data string of a tile
+type CustomData struct {
+	TileID int    `json:"tileId"`
+	Data   string `json:"data"`
+}
+
+// EntityDef defines an entity type with its fields
+type EntityDef struct {
+	Identifier       string      `json:"identifier"`
+	UID              int         `json:"uid"`
+	Tags             []string    `json:"tags"`
+	ExportToToc      bool        `json:"exportToToc"`
+	AllowOutOfBounds bool        `json:"allowOutOfBounds"`
+	Doc              *string     `json:"doc"`
+	Width            int         `json:"width"`
+	Height           int         `json:"height"`
+	ResizableX       bool        `json:"resizableX"`
+	ResizableY       bool        `json:"resizableY"`
+	MinWidth         *int        `json:"minWidth"`
+	MaxWidth         *int        `json:"maxWidth"`
+	MinHeight        *int        `json:"minHeight"`
+	MaxHeight        *int        `json:"maxHeight"`
+	KeepAspectRatio  bool        `json:"keepAspectRatio"`
+	TileOpacity      float64     `json:"tileOpacity"`
+	FillOpacity      float64     `json:"fillOpacity"`
+	LineOpacity      float64     `json:"lineOpacity"`
+	Hollow           bool        `json:"hollow"`
+	Color            string      `json:"color"`
+	RenderMode       string      `json:"renderMode"`
+	ShowName         bool        `json:"showName"`
+	TilesetID        *int        `json:"tilesetId"`
+	TileRenderMode   string      `json:"tileRenderMode"`
+	TileRect         any         `json:"tileRect"`
+	UITileRect       any         `json:"uiTileRect"`
+	NineSliceBorders []int       `json:"nineSliceBorders"`
+	MaxCount         int         `json:"maxCount"`
+	LimitScope       string      `json:"limitScope"`
+	LimitBehavior    string      `json:"limitBehavior"`
+	PivotX           float64     `json:"pivotX"`
+	PivotY           float64     `json:"pivotY"`
+	FieldDefs        []*FieldDef `json:"fieldDefs"`
+}
+
+// FieldDef defines an "Int", "Float", "Bool" or "String" field of an entity
+type FieldDef struct {
+	Identifier           string   `json:"identifier"`
+	Doc                  *string  `json:"doc"`
+	TypeName             string   `json:"__type"`
+	UID                  int      `json:"uid"`
+	Type                 string   `json:"type"` // F_Int, F_Float, F_Bool or F_String
+	IsArray              bool     `json:"isArray"`
+	CanBeNull            bool     `json:"canBeNull"`
+	ArrayMinLength       *int     `json:"arrayMinLength"`
+	ArrayMaxLength       *int     `json:"arrayMaxLength"`
+	EditorDisplayMode    string   `json:"editorDisplayMode"`
+	EditorDisplayScale   float64  `json:"editorDisplayScale"`
+	EditorDisplayPos     string   `json:"editorDisplayPos"`
+	EditorLinkStyle      string   `json:"editorLinkStyle"`
+	EditorDisplayColor   *string  `json:"editorDisplayColor"`
+	EditorAlwaysShow     bool     `json:"editorAlwaysShow"`
+	EditorShowInWorld    bool     `json:"editorShowInWorld"`
+	EditorCutLongValues  bool     `json:"editorCutLongValues"`
+	EditorTextSuffix     *string  `json:"editorTextSuffix"`
+	EditorTextPrefix     *string  `json:"editorTextPrefix"`
+	UseForSmartColor     bool     `json:"useForSmartColor"`
+	ExportToToc          bool     `json:"exportToToc"`
+	Searchable           bool     `json:"searchable"`
+	Min                  *float64 `json:"min"`
+	Max                  *float64 `json:"max"`
+	Regex                *string  `json:"regex"`
+	AcceptFileTypes      []string `json:"acceptFileTypes"`
+	DefaultOverride      any      `json:"defaultOverride"`
+	TextLanguageMode     *string  `json:"textLanguageMode"`
+	SymmetricalRef       bool     `json:"symmetricalRef"`
+	AutoChainRef         bool     `json:"autoChainRef"`
+	AllowOutOfLevelRef   bool     `json:"allowOutOfLevelRef"`
+	AllowedRefs          string   `json:"allowedRefs"`
+	AllowedRefsEntityUID *int     `json:"allowedRefsEntityUid"`
+	AllowedRefTags       []string `json:"allowedRefTags"`
+	TilesetUID           *int     `json:"tilesetUid"`
+}
+
+// Level is a level of the project with its layer instances, top to bottom
+type Level struct {
+	Identifier        string           `json:"identifier"`
+	IID               string           `json:"iid"`
+	UID               int              `json:"uid"`
+	WorldX            int              `json:"worldX"`
+	WorldY            int              `json:"worldY"`
+	WorldDepth        int              `json:"worldDepth"`
+	PxWid             int              `json:"pxWid"`
+	PxHei             int              `json:"pxHei"`
+	BgColorComputed   string           `json:"__bgColor"`
+	BgColor           *string          `json:"bgColor"`
+	UseAutoIdentifier bool             `json:"useAutoIdentifier"`
+	BgRelPath         *string          `json:"bgRelPath"`
+	BgPos             *string          `json:"bgPos"`
+	BgPivotX          float64          `json:"bgPivotX"`
+	BgPivotY          float64          `json:"bgPivotY"`
+	SmartColor        string           `json:"__smartColor"`
+	BgPosComputed     any              `json:"__bgPos"`
+	ExternalRelPath   *string          `json:"externalRelPath"`
+	FieldInstances    []*FieldInstance `json:"fieldInstances"`
+	LayerInstances    []*LayerInstance `json:"layerInstances"`
+	Neighbours        []any            `json:"__neighbours"`
+}
+
+// LayerInstance is the content of a layer in a level
+type LayerInstance struct {
+	Identifier         string            `json:"__identifier"`
+	Type               string            `json:"__type"`
+	CWid               int               `json:"__cWid"`
+	CHei               int               `json:"__cHei"`
+	GridSize           int               `json:"__gridSize"`
+	Opacity            float64           `json:"__opacity"`
+	PxTotalOffsetX     int               `json:"__pxTotalOffsetX"`
+	PxTotalOffsetY     int               `json:"__pxTotalOffsetY"`
+	TilesetDefUID      *int              `json:"__tilesetDefUid"`
+	TilesetRelPath     *string           `json:"__tilesetRelPath"`
+	IID                string            `json:"iid"`
+	LevelID            int               `json:"levelId"`
+	LayerDefUID        int               `json:"layerDefUid"`
+	PxOffsetX          int               `json:"pxOffsetX"`
+	PxOffsetY          int               `json:"pxOffsetY"`
+	Visible            bool              `json:"visible"`
+	OptionalRules      []int             `json:"optionalRules"`
+	IntGridCsv         []int             `json:"intGridCsv"`
+	AutoLayerTiles     []GridTile        `json:"autoLayerTiles"`
+	Seed               int               `json:"seed"`
+	OverrideTilesetUID *int              `json:"overrideTilesetUid"`
+	GridTiles          []GridTile        `json:"gridTiles"`
+	EntityInstances    []*EntityInstance `json:"entityInstances"`
+}
+
+// GridTile is a tile placed in a tile layer
+type GridTile struct {
+	Px  [2]int  `json:"px"`  // position in the layer
+	Src [2]int  `json:"src"` // position in the tileset image
+	F   int     `json:"f"`   // flip bits: 1 for X, 2 for Y
+	T   int     `json:"t"`   // tile ID in the tileset
+	D   []int   `json:"d"`   // coordinate ID of the cell
+	A   float64 `json:"a"`   // alpha
+}
+
+// EntityInstance is an entity placed in a level
+type EntityInstance struct {
+	Identifier     string           `json:"__identifier"`
+	Grid           [2]int           `json:"__grid"`
+	Pivot          [2]float64       `json:"__pivot"`
+	Tags           []string         `json:"__tags"`
+	Tile           any              `json:"__tile"`
+	SmartColor     string           `json:"__smartColor"`
+	IID            string           `json:"iid"`
+	Width          int              `json:"width"`
+	Height         int              `json:"height"`
+	DefUID         int              `json:"defUid"`
+	Px             [2]int           `json:"px"` // pivot position in the level
+	FieldInstances []*FieldInstance `json:"fieldInstances"`
+	WorldX         int              `json:"__worldX"`
+	WorldY         int              `json:"__worldY"`
+}
+
+// FieldInstance is the value of a field of an entity
+type FieldInstance struct {
+	Identifier       string        `json:"__identifier"`
+	Type             string        `json:"__type"`
+	Value            any           `json:"__value"`
+	Tile             any           `json:"__tile"`
+	DefUID           int           `json:"defUid"`
+	RealEditorValues []EditorValue `json:"realEditorValues"`
+}
+
+// EditorValue is a field value as the LDtk editor stores it, e.g. {"id": "V_Int", "params": [3]}
+type EditorValue struct {
+	ID     string `json:"id"`
+	Params []any  `json:"params"`
+}
+
+// WriteJSON writes the project as an .ldtk file
+func (p *Project) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
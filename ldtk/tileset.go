@@ -0,0 +1,134 @@
+package ldtk
+
+import (
+	"path"
+	"strconv"
+	"strings"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// sourceTileset is the tileset exported for a tile source of the scene's TileSet
+type sourceTileset struct {
+	*TilesetDef
+	source tscnparser.TileSource
+	// Position of the source's texture region in the image
+	origin tscnparser.Vec2i
+	// Size in atlas cells of the tiles larger than a cell
+	sizes map[tscnparser.Vec2i]tscnparser.Vec2i
+}
+
+// tilesets exports a tileset for every tile source. LDtk tilesets are grids of square tiles
+// with the same padding and spacing on both axes, so other layouts are approximated.
+func (e *exporter) tilesets() {
+	// Atlas coordinates the layers use, which need a tile even if the source does not list them
+	used := make(map[int][]tscnparser.Vec2i)
+	for _, layer := range e.data.TileMap.Layers {
+		for _, c := range e.cells(layer) {
+			used[c.source] = append(used[c.source], c.atlas)
+		}
+	}
+	for _, source := range e.data.TileMap.TileSet.Sources {
+		name := "source_" + strconv.Itoa(source.ID)
+		if source.TexturePath != "" {
+			base := path.Base(source.TexturePath)
+			name = strings.TrimSuffix(base, path.Ext(base))
+		}
+		tileset := &sourceTileset{
+			TilesetDef: &TilesetDef{
+				Identifier:      e.uniqueIdentifier(name, "Tileset"),
+				UID:             e.uid(),
+				TileGridSize:    source.TextureRegionSize.X,
+				Spacing:         source.Separation.X,
+				Padding:         source.Margins.X,
+				Tags:            []string{},
+				EnumTags:        []any{},
+				CustomData:      []CustomData{},
+				SavedSelections: []any{},
+			},
+			source: source,
+			sizes:  make(map[tscnparser.Vec2i]tscnparser.Vec2i),
+		}
+		if tileset.TileGridSize <= 0 {
+			// Godot's default texture_region_size
+			tileset.TileGridSize = 16
+		}
+		if size := source.TextureRegionSize; size.X != size.Y {
+			e.warn("tileset %q: tiles of %dx%d are not square, using a grid of %d", tileset.Identifier, size.X, size.Y, tileset.TileGridSize)
+		}
+		if source.Margins.X != source.Margins.Y || source.Separation.X != source.Separation.Y {
+			e.warn("tileset %q: margins and separation differ between axes, using the horizontal ones", tileset.Identifier)
+		}
+		if source.TexturePath != "" {
+			relPath := e.opts.ImagePath(source.TexturePath)
+			tileset.RelPath = &relPath
+		}
+		e.layout(tileset, used[source.ID])
+		e.sources[source.ID] = tileset
+		e.p.Defs.Tilesets = append(e.p.Defs.Tilesets, tileset.TilesetDef)
+	}
+}
+
+// layout sizes the tileset from the image when its size is known and from the tiles
+// otherwise, and keeps the collision polygons of the tiles as custom data
+func (e *exporter) layout(tileset *sourceTileset, used []tscnparser.Vec2i) {
+	source := tileset.source
+	columns, rows := 1, 1
+	for _, tile := range source.Tiles {
+		size := tscnparser.Vec2i{X: max(tile.SizeInAtlas.X, 1), Y: max(tile.SizeInAtlas.Y, 1)}
+		if size.X > 1 || size.Y > 1 {
+			tileset.sizes[tile.AtlasCoords] = size
+		}
+		columns, rows = max(columns, tile.AtlasCoords.X+size.X), max(rows, tile.AtlasCoords.Y+size.Y)
+	}
+	for _, coords := range used {
+		columns, rows = max(columns, coords.X+1), max(rows, coords.Y+1)
+	}
+	step := tileset.TileGridSize + tileset.Spacing
+	texture := source.TextureRef
+	if texture != nil && texture.Region != nil {
+		// LDtk has no texture regions: tiles are placed by their position in the whole image
+		tileset.origin = tscnparser.Vec2i{X: int(texture.Region.X), Y: int(texture.Region.Y)}
+	}
+	if texture != nil && texture.ImageWidth > 0 && texture.ImageHeight > 0 {
+		tileset.PxWid, tileset.PxHei = texture.ImageWidth, texture.ImageHeight
+		columns = max(columns, (texture.ImageWidth-tileset.Padding+tileset.Spacing)/step)
+		rows = max(rows, (texture.ImageHeight-tileset.Padding+tileset.Spacing)/step)
+	} else {
+		tileset.PxWid = tileset.origin.X + tileset.Padding + columns*step - tileset.Spacing
+		tileset.PxHei = tileset.origin.Y + tileset.Padding + rows*step - tileset.Spacing
+	}
+	tileset.CWid, tileset.CHei = columns, rows
+
+	for _, tile := range source.Tiles {
+		if len(tile.Physics.CollisionPoints) < 3 && tileset.sizes[tile.AtlasCoords] == (tscnparser.Vec2i{}) {
+			continue
+		}
+		tileset.CustomData = append(tileset.CustomData, CustomData{
+			TileID: tileset.tileID(tile.AtlasCoords),
+			Data:   jsonString(tile),
+		})
+	}
+}
+
+// size returns the size in atlas cells of the tile at the given atlas coordinates
+func (t *sourceTileset) size(coords tscnparser.Vec2i) tscnparser.Vec2i {
+	if size, exists := t.sizes[coords]; exists {
+		return size
+	}
+	return tscnparser.Vec2i{X: 1, Y: 1}
+}
+
+// src returns the position in the image of the cell at the given atlas coordinates
+func (t *sourceTileset) src(coords tscnparser.Vec2i) [2]int {
+	step := t.TileGridSize + t.Spacing
+	return [2]int{
+		t.origin.X + t.Padding + coords.X*step,
+		t.origin.Y + t.Padding + coords.Y*step,
+	}
+}
+
+// tileID returns the LDtk tile ID of the cell at the given atlas coordinates
+func (t *sourceTileset) tileID(coords tscnparser.Vec2i) int {
+	return coords.Y*t.CWid + coords.X
+}
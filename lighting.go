@@ -0,0 +1,142 @@
+package tscnparser
+
+import (
+	"strconv"
+)
+
+// occluderPolygon is an OccluderPolygon2D sub_resource
+type occluderPolygon struct {
+	points   []Vec2
+	closed   bool
+	cullMode int64
+}
+
+var (
+	shadowFilters = []string{"none", "pcf5", "pcf13"}
+	cullModes     = []string{"disabled", "clockwise", "counter_clockwise"}
+)
+
+// parseOccluderProperty parses a property of an OccluderPolygon2D sub_resource
+func (c *TSCNConverter) parseOccluderProperty(occluder *occluderPolygon, line string) {
+	key, value, ok := splitProperty(line)
+	if occluder == nil || !ok {
+		return
+	}
+	switch key {
+	case "polygon":
+		occluder.points = c.extractPolygonPoints(value)
+	case "closed":
+		occluder.closed = value == "true"
+	case "cull_mode":
+		occluder.cullMode, _ = strconv.ParseInt(value, 10, 64)
+	}
+}
+
+// buildLighting collects the lights, light occluders and CanvasModulate of the scene,
+// returning nil if there are none
+func (c *TSCNConverter) buildLighting() *Lighting {
+	lighting := &Lighting{}
+	for _, node := range c.nodes.order {
+		switch node.Type {
+		case "PointLight2D", "DirectionalLight2D":
+			lighting.Lights = append(lighting.Lights, c.newLight(node))
+		case "LightOccluder2D":
+			if occluder, ok := c.newLightOccluder(node); ok {
+				lighting.Occluders = append(lighting.Occluders, occluder)
+			}
+		case "CanvasModulate":
+			ambient := Color{R: 1, G: 1, B: 1, A: 1}
+			if color, ok := parseColorValue(node.Properties["color"]); ok {
+				ambient = color
+			}
+			lighting.Ambient = &ambient
+		}
+	}
+	if lighting.Ambient == nil && lighting.Lights == nil && lighting.Occluders == nil {
+		return nil
+	}
+	return lighting
+}
+
+func (c *TSCNConverter) newLight(node *sceneNode) Light {
+	props := node.Properties
+	light := Light{
+		Name:               node.Name,
+		Path:               node.Path,
+		Type:               node.Type,
+		Transform:          outputTransform(c.nodes.globalTransform(node)),
+		Enabled:            props["enabled"] != "false",
+		Color:              Color{R: 1, G: 1, B: 1, A: 1},
+		Energy:             1,
+		ShadowEnabled:      props["shadow_enabled"] == "true",
+		ShadowFilter:       enumName(shadowFilters, node.intProperty("shadow_filter", 0)),
+		ShadowItemCullMask: uint32(node.intProperty("shadow_item_cull_mask", 1)),
+		Groups:             c.nodeGroups(node),
+		NodeScript:         c.nodeScript(node),
+		Metadata:           c.nodeMetadata(node),
+	}
+	if color, ok := parseColorValue(props["color"]); ok {
+		light.Color = color
+	}
+	if color, ok := parseColorValue(props["shadow_color"]); ok {
+		light.ShadowColor = color
+	}
+	light.Energy = floatProperty(props, "energy", 1)
+	light.Height = floatProperty(props, "height", 0)
+	light.ShadowFilterSmooth = floatProperty(props, "shadow_filter_smooth", 0)
+
+	if node.Type == "PointLight2D" {
+		if value, exists := props["texture"]; exists {
+			light.Texture = c.textures.resolve(value)
+		}
+		light.TextureScale = floatProperty(props, "texture_scale", 1)
+		if value, exists := props["offset"]; exists {
+			light.Offset = outputVector(parseVector2Value(value))
+		}
+	} else {
+		light.MaxDistance = floatProperty(props, "max_distance", 10000)
+	}
+	return light
+}
+
+func (c *TSCNConverter) newLightOccluder(node *sceneNode) (LightOccluder, bool) {
+	occluder, found := c.occluders[c.extractSubResourceReference(node.Properties["occluder"])]
+	if !found {
+		return LightOccluder{}, false
+	}
+	transform := c.nodes.globalTransform(node)
+	points := []Vec2{}
+	for _, p := range occluder.points {
+		points = append(points, outputPoint(transform.Xform(p)))
+	}
+	return LightOccluder{
+		Name:         node.Name,
+		Path:         node.Path,
+		Points:       points,
+		Closed:       occluder.closed,
+		CullMode:     enumName(cullModes, occluder.cullMode),
+		SDFCollision: node.Properties["sdf_collision"] != "false",
+		LightMask:    uint32(node.intProperty("occluder_light_mask", 1)),
+		Groups:       c.nodeGroups(node),
+		NodeScript:   c.nodeScript(node),
+		Metadata:     c.nodeMetadata(node),
+	}, true
+}
+
+// floatProperty returns a float property, or def if it is not set
+func floatProperty(props map[string]string, key string, def float64) float64 {
+	if value, exists := props[key]; exists {
+		if v, err := strconv.ParseFloat(value, 64); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// enumName returns the name of an enum value, or its number if it is out of range
+func enumName(names []string, value int64) string {
+	if value >= 0 && value < int64(len(names)) {
+		return names[value]
+	}
+	return strconv.FormatInt(value, 10)
+}
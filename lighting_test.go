@@ -0,0 +1,83 @@
+package tscnparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLighting(t *testing.T) {
+	data := parseNodes(t, `
+[sub_resource type="OccluderPolygon2D" id="OccluderPolygon2D_1"]
+closed = false
+cull_mode = 2
+polygon = PackedVector2Array(0, 0, 8, 0, 8, 8)
+`, `
+[node name="Ambient" type="CanvasModulate" parent="."]
+color = Color(0.5, 0.5, 0.5, 1)
+
+[node name="Lamp" type="PointLight2D" parent="."]
+position = Vector2(40, 24)
+rotation = 0.5
+color = Color(1, 0.5, 0, 1)
+energy = 2.0
+offset = Vector2(0, 8)
+shadow_enabled = true
+shadow_filter = 1
+
+[node name="Sun" type="DirectionalLight2D" parent="."]
+enabled = false
+
+[node name="Pillar" type="LightOccluder2D" parent="."]
+position = Vector2(40, 24)
+occluder = SubResource("OccluderPolygon2D_1")
+`)
+	if data.Lighting == nil {
+		t.Fatal("no lighting")
+	}
+	if ambient := data.Lighting.Ambient; ambient == nil || *ambient != (Color{R: 0.5, G: 0.5, B: 0.5, A: 1}) {
+		t.Errorf("ambient = %+v", ambient)
+	}
+
+	want := []Light{
+		{
+			Name:               "Lamp",
+			Path:               "Lamp",
+			Type:               "PointLight2D",
+			Transform:          Transform2D{Position: Vec2{X: 88, Y: -56}, Rotation: -0.5, Scale: Vec2{X: 1, Y: 1}},
+			Enabled:            true,
+			Color:              Color{R: 1, G: 0.5, B: 0, A: 1},
+			Energy:             2,
+			TextureScale:       1,
+			Offset:             Vec2{X: 0, Y: -8},
+			ShadowEnabled:      true,
+			ShadowFilter:       "pcf5",
+			ShadowItemCullMask: 1,
+		},
+		{
+			Name:               "Sun",
+			Path:               "Sun",
+			Type:               "DirectionalLight2D",
+			Transform:          Transform2D{Position: Vec2{X: 48, Y: -32}, Scale: Vec2{X: 1, Y: 1}},
+			Color:              Color{R: 1, G: 1, B: 1, A: 1},
+			Energy:             1,
+			MaxDistance:        10000,
+			ShadowFilter:       "none",
+			ShadowItemCullMask: 1,
+		},
+	}
+	if !reflect.DeepEqual(data.Lighting.Lights, want) {
+		t.Errorf("lights = %+v\nwant %+v", data.Lighting.Lights, want)
+	}
+
+	occluders := []LightOccluder{{
+		Name:         "Pillar",
+		Path:         "Pillar",
+		Points:       []Vec2{{X: 88, Y: -56}, {X: 96, Y: -56}, {X: 96, Y: -64}},
+		CullMode:     "counter_clockwise",
+		SDFCollision: true,
+		LightMask:    1,
+	}}
+	if !reflect.DeepEqual(data.Lighting.Occluders, occluders) {
+		t.Errorf("occluders = %+v\nwant %+v", data.Lighting.Occluders, occluders)
+	}
+}
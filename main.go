@@ -10,9 +10,38 @@ func SetTileSize(size int) {
 func SetOffset(x, y int) {
 	tilemapOffset = Vec2{float64(x), float64(y)}
 }
+
+// SetCoordinateSystem selects the coordinate system of the output. The offset only applies
+// to CoordinatesYUp and CoordinatesGodot; the other systems place the origin on the tile map.
+func SetCoordinateSystem(system CoordinateSystem) error {
+	system, err := parseCoordinateSystem(string(system))
+	if err != nil {
+		return err
+	}
+	coordinateSystem = system
+	return nil
+}
 func SetPrefabsDir(dir string) {
 	prefabsDirectory = dir
 }
+
+// SetProjectRoot sets the Godot project directory that res:// paths resolve against.
+// When set, referenced images are opened to record their size and validate regions.
+func SetProjectRoot(dir string) {
+	projectRoot = dir
+}
+
+// SetPathBakeTolerance sets the maximum distance in pixels between a Path2D curve
+// and its baked polyline. Zero disables baking.
+func SetPathBakeTolerance(tolerance float64) {
+	pathBakeTolerance = tolerance
+}
+
+// SetParseScripts enables reading the GDScript files attached to nodes for their class_name,
+// extends and @export variables. Scripts are resolved against the project root.
+func SetParseScripts(enabled bool) {
+	parseScripts = enabled
+}
 func Parse(inputFile string) (*MapData, error) {
 
 	if inputFile == "" {
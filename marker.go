@@ -0,0 +1,150 @@
+package tscnparser
+
+import (
+	"math"
+)
+
+// curvePoint is a Curve2D point in the Path2D's local space; in and out are relative to position
+type curvePoint struct {
+	in, out, position Vec2
+}
+
+// parseCurve2DData parses the _data property of a Curve2D sub_resource:
+// {"points": PackedVector2Array(in_x, in_y, out_x, out_y, pos_x, pos_y, ...), "tilts": ...}
+func parseCurve2DData(value string) []curvePoint {
+	parsed, err := parseVariant(value)
+	if err != nil {
+		return nil
+	}
+	data, ok := parsed.(map[string]any)
+	if !ok {
+		return nil
+	}
+	call, ok := data["points"].(variantCall)
+	if !ok {
+		return nil
+	}
+	values, ok := call.floatArgs()
+	if !ok {
+		return nil
+	}
+	var points []curvePoint
+	for i := 0; i+5 < len(values); i += 6 {
+		points = append(points, curvePoint{
+			in:       Vec2{X: values[i], Y: values[i+1]},
+			out:      Vec2{X: values[i+2], Y: values[i+3]},
+			position: Vec2{X: values[i+4], Y: values[i+5]},
+		})
+	}
+	return points
+}
+
+// buildMarkers collects the Marker2D nodes of the scene
+func (c *TSCNConverter) buildMarkers() []Marker {
+	var markers []Marker
+	for _, node := range c.nodes.order {
+		if node.Type != "Marker2D" {
+			continue
+		}
+		transform := outputTransform(c.nodes.globalTransform(node))
+		markers = append(markers, Marker{
+			Name:       node.Name,
+			Path:       node.Path,
+			Position:   transform.Position,
+			Rotation:   transform.Rotation,
+			Groups:     c.nodeGroups(node),
+			NodeScript: c.nodeScript(node),
+			Metadata:   c.nodeMetadata(node),
+		})
+	}
+	return markers
+}
+
+// buildPaths collects the Path2D nodes of the scene with their curves in world space
+func (c *TSCNConverter) buildPaths() []CurvePath {
+	var paths []CurvePath
+	for _, node := range c.nodes.order {
+		if node.Type != "Path2D" {
+			continue
+		}
+		path := CurvePath{
+			Name:       node.Name,
+			Path:       node.Path,
+			Points:     []PathPoint{},
+			Groups:     c.nodeGroups(node),
+			NodeScript: c.nodeScript(node),
+			Metadata:   c.nodeMetadata(node),
+		}
+		var curve []curvePoint
+		if value, exists := node.Properties["curve"]; exists {
+			curve = c.curves[c.extractSubResourceReference(value)]
+		}
+
+		// Bezier curves stay Bezier curves under affine transforms, so control points
+		// can be transformed to world space individually
+		transform := c.nodes.globalTransform(node)
+		world := make([]curvePoint, len(curve))
+		for i, point := range curve {
+			position := transform.Xform(point.position)
+			world[i] = curvePoint{
+				position: position,
+				in:       transform.Xform(Vec2{X: point.position.X + point.in.X, Y: point.position.Y + point.in.Y}),
+				out:      transform.Xform(Vec2{X: point.position.X + point.out.X, Y: point.position.Y + point.out.Y}),
+			}
+			path.Points = append(path.Points, PathPoint{
+				Position: outputPoint(world[i].position),
+				In:       outputPoint(world[i].in),
+				Out:      outputPoint(world[i].out),
+			})
+		}
+		if pathBakeTolerance > 0 {
+			for _, p := range bakeCurve(world, pathBakeTolerance) {
+				path.Baked = append(path.Baked, outputPoint(p))
+			}
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// bakeCurve approximates a curve with absolute control points by a polyline whose
+// distance from the curve stays within tolerance
+func bakeCurve(points []curvePoint, tolerance float64) []Vec2 {
+	if len(points) == 0 {
+		return nil
+	}
+	baked := []Vec2{points[0].position}
+	for i := 0; i+1 < len(points); i++ {
+		baked = subdivideBezier(baked, points[i].position, points[i].out, points[i+1].in, points[i+1].position, tolerance, 0)
+	}
+	return baked
+}
+
+// subdivideBezier appends the end points of a flattened cubic Bezier segment, excluding p0
+func subdivideBezier(out []Vec2, p0, c1, c2, p3 Vec2, tolerance float64, depth int) []Vec2 {
+	const maxDepth = 16
+	if depth >= maxDepth || (distanceToLine(c1, p0, p3) <= tolerance && distanceToLine(c2, p0, p3) <= tolerance) {
+		return append(out, p3)
+	}
+	// de Casteljau split at t = 0.5
+	p01, p12, p23 := midpoint(p0, c1), midpoint(c1, c2), midpoint(c2, p3)
+	p012, p123 := midpoint(p01, p12), midpoint(p12, p23)
+	mid := midpoint(p012, p123)
+	out = subdivideBezier(out, p0, p01, p012, mid, tolerance, depth+1)
+	return subdivideBezier(out, mid, p123, p23, p3, tolerance, depth+1)
+}
+
+func midpoint(a, b Vec2) Vec2 {
+	return Vec2{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+}
+
+// distanceToLine returns the distance from p to the segment a-b
+func distanceToLine(p, a, b Vec2) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	lengthSq := dx*dx + dy*dy
+	if lengthSq == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	t := math.Max(0, math.Min(1, ((p.X-a.X)*dx+(p.Y-a.Y)*dy)/lengthSq))
+	return math.Hypot(p.X-(a.X+t*dx), p.Y-(a.Y+t*dy))
+}
@@ -0,0 +1,44 @@
+package tscnparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMarkersAndPaths(t *testing.T) {
+	data := parseNodes(t, `
+[sub_resource type="Curve2D" id="Curve2D_1"]
+_data = {"points": PackedVector2Array(0, 0, 8, 0, 0, 0, -8, 0, 0, 0, 32, 16), "tilts": PackedFloat32Array(0, 0)}
+`, `
+[node name="Spawns" type="Node2D" parent="."]
+position = Vector2(32, 0)
+
+[node name="Spawn" type="Marker2D" parent="Spawns"]
+position = Vector2(8, 24)
+rotation = 0.5
+
+[node name="Patrol" type="Path2D" parent="."]
+position = Vector2(16, 0)
+curve = SubResource("Curve2D_1")
+`)
+	want := []Marker{{Name: "Spawn", Path: "Spawns/Spawn", Position: Vec2{X: 88, Y: -56}, Rotation: -0.5}}
+	if !reflect.DeepEqual(data.Markers, want) {
+		t.Errorf("markers = %+v, want %+v", data.Markers, want)
+	}
+
+	if len(data.Paths) != 1 || data.Paths[0].Path != "Patrol" {
+		t.Fatalf("paths = %+v", data.Paths)
+	}
+	// The control points are absolute, in world space
+	points := []PathPoint{
+		{Position: Vec2{X: 64, Y: -32}, In: Vec2{X: 64, Y: -32}, Out: Vec2{X: 72, Y: -32}},
+		{Position: Vec2{X: 96, Y: -48}, In: Vec2{X: 88, Y: -48}, Out: Vec2{X: 96, Y: -48}},
+	}
+	if !reflect.DeepEqual(data.Paths[0].Points, points) {
+		t.Errorf("points = %+v, want %+v", data.Paths[0].Points, points)
+	}
+	// The baked polyline runs from the first point to the last
+	if baked := data.Paths[0].Baked; len(baked) < 2 || baked[0] != points[0].Position || baked[len(baked)-1] != points[1].Position {
+		t.Errorf("baked = %v", baked)
+	}
+}
@@ -0,0 +1,183 @@
+package tscnparser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// sceneNode is a node declared in a .tscn file together with its raw properties
+type sceneNode struct {
+	Name       string
+	Type       string // empty for instanced scenes
+	Parent     string // parent path as written in the file, empty for the root node
+	Path       string // path from the root node, "." for the root itself
+	Instance   string // ExtResource ID of an instanced scene
+	Groups     []string
+	Properties map[string]string
+	Index      int // declaration order in the file
+}
+
+// nodeTree tracks the nodes of a .tscn file in declaration order
+type nodeTree struct {
+	nodes map[string]*sceneNode
+	order []*sceneNode
+}
+
+func newNodeTree() *nodeTree {
+	return &nodeTree{nodes: make(map[string]*sceneNode)}
+}
+
+var (
+	nodeNameRe     = regexp.MustCompile(`name="([^"]+)"`)
+	nodeTypeRe     = regexp.MustCompile(`\stype="([^"]+)"`)
+	nodeParentRe   = regexp.MustCompile(`parent="([^"]+)"`)
+	nodeInstanceRe = regexp.MustCompile(`instance=ExtResource\("([^"]+)"\)`)
+	nodeGroupsRe   = regexp.MustCompile(`groups=(\[[^\]]*\])`)
+)
+
+// add registers the node declared by a [node ...] header line
+func (t *nodeTree) add(line string) *sceneNode {
+	node := &sceneNode{Properties: make(map[string]string), Index: len(t.order)}
+	if matches := nodeNameRe.FindStringSubmatch(line); len(matches) > 1 {
+		node.Name = matches[1]
+	}
+	if matches := nodeTypeRe.FindStringSubmatch(line); len(matches) > 1 {
+		node.Type = matches[1]
+	}
+	if matches := nodeParentRe.FindStringSubmatch(line); len(matches) > 1 {
+		node.Parent = matches[1]
+	}
+	if matches := nodeInstanceRe.FindStringSubmatch(line); len(matches) > 1 {
+		node.Instance = matches[1]
+	}
+	if matches := nodeGroupsRe.FindStringSubmatch(line); len(matches) > 1 {
+		if groups, err := parseVariant(matches[1]); err == nil {
+			for _, group := range groups.([]any) {
+				node.Groups = append(node.Groups, fmt.Sprint(group))
+			}
+		}
+	}
+	node.Path = joinNodePath(node.Parent, node.Name)
+	t.nodes[node.Path] = node
+	t.order = append(t.order, node)
+	return node
+}
+
+// joinNodePath returns the path of a node from its parent attribute and name
+func joinNodePath(parent, name string) string {
+	switch parent {
+	case "":
+		return "."
+	case ".":
+		return name
+	}
+	return parent + "/" + name
+}
+
+// parent returns the parent node, or nil for the root node
+func (t *nodeTree) parent(node *sceneNode) *sceneNode {
+	if node.Parent == "" {
+		return nil
+	}
+	if node.Parent == "." {
+		return t.nodes["."]
+	}
+	return t.nodes[node.Parent]
+}
+
+// setProperty records a "key = value" property line on the node
+func (n *sceneNode) setProperty(line string) {
+	if key, value, ok := splitProperty(line); ok {
+		n.Properties[key] = value
+	}
+}
+
+// transform returns the node's local transform from its position, rotation, scale and skew properties
+func (n *sceneNode) transform() Transform2D {
+	t := IdentityTransform
+	if value, exists := n.Properties["position"]; exists {
+		t.Position = parseVector2Value(value)
+	}
+	if value, exists := n.Properties["rotation"]; exists {
+		t.Rotation, _ = strconv.ParseFloat(value, 64)
+	}
+	if value, exists := n.Properties["scale"]; exists {
+		t.Scale = parseVector2Value(value)
+	}
+	if value, exists := n.Properties["skew"]; exists {
+		t.Skew, _ = strconv.ParseFloat(value, 64)
+	}
+	return t
+}
+
+// metadata returns the node's metadata/* properties as typed values
+func (n *sceneNode) metadata() Properties {
+	var result Properties
+	for key, value := range n.Properties {
+		if name, found := strings.CutPrefix(key, "metadata/"); found {
+			if result == nil {
+				result = make(Properties)
+			}
+			result[name] = propertyValue(value)
+		}
+	}
+	return result
+}
+
+// intProperty returns an integer property, or def if it is not set
+func (n *sceneNode) intProperty(key string, def int64) int64 {
+	if value, exists := n.Properties[key]; exists {
+		if v, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			return v
+		}
+	}
+	return def
+}
+
+// globalTransform returns the node's transform in scene space
+func (t *nodeTree) globalTransform(node *sceneNode) Transform2D {
+	return t.relativeTransform("", node)
+}
+
+// relativeTransform returns the transform of a node relative to one of its ancestors,
+// e.g. "." for the node's transform in the root node's space
+func (t *nodeTree) relativeTransform(ancestor string, node *sceneNode) Transform2D {
+	var chain []*sceneNode
+	for n := node; n != nil && n.Path != ancestor; n = t.parent(n) {
+		chain = append(chain, n)
+	}
+	if len(chain) == 0 {
+		return IdentityTransform
+	}
+	result := chain[len(chain)-1].transform()
+	for i := len(chain) - 2; i >= 0; i-- {
+		result = result.Mul(chain[i].transform())
+	}
+	return result
+}
+
+// ancestor returns the closest ancestor whose type satisfies match, or nil
+func (t *nodeTree) ancestor(node *sceneNode, match func(nodeType string) bool) *sceneNode {
+	for n := t.parent(node); n != nil; n = t.parent(n) {
+		if match(n.Type) {
+			return n
+		}
+	}
+	return nil
+}
+
+// parseVector2Value parses a Vector2(x, y) value, returning zero on failure
+func parseVector2Value(value string) Vec2 {
+	parsed, err := parseVariant(strings.TrimSpace(value))
+	if err != nil {
+		return Vec2{}
+	}
+	if call, ok := parsed.(variantCall); ok && (call.Name == "Vector2" || call.Name == "Vector2i") {
+		if args, ok := call.floatArgs(); ok && len(args) == 2 {
+			return Vec2{X: args[0], Y: args[1]}
+		}
+	}
+	return Vec2{}
+}
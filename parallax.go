@@ -0,0 +1,120 @@
+package tscnparser
+
+import (
+	"slices"
+	"sort"
+)
+
+// buildParallax collects ParallaxLayer and Parallax2D nodes with their sprites,
+// ordered back to front by canvas layer and then by scene order
+func (c *TSCNConverter) buildParallax() []ParallaxLayer {
+	var layers []ParallaxLayer
+	for _, node := range c.nodes.order {
+		if !isParallaxLayer(node.Type) {
+			continue
+		}
+		props := node.Properties
+		layer := ParallaxLayer{
+			Name:        node.Name,
+			Path:        node.Path,
+			Type:        node.Type,
+			Transform:   outputTransform(c.nodes.globalTransform(node)),
+			MotionScale: Vec2{X: 1, Y: 1},
+			RepeatTimes: 1,
+			Sprites:     []ParallaxSprite{},
+			Groups:      c.nodeGroups(node),
+			NodeScript:  c.nodeScript(node),
+			Metadata:    c.nodeMetadata(node),
+		}
+		scaleKey, offsetKey, repeatKey := "motion_scale", "motion_offset", "motion_mirroring"
+		if node.Type == "Parallax2D" {
+			scaleKey, offsetKey, repeatKey = "scroll_scale", "scroll_offset", "repeat_size"
+			layer.RepeatTimes = node.intProperty("repeat_times", 1)
+			if value, exists := props["autoscroll"]; exists {
+				layer.Autoscroll = outputVector(parseVector2Value(value))
+			}
+		}
+		if value, exists := props[scaleKey]; exists {
+			layer.MotionScale = parseVector2Value(value)
+		}
+		if value, exists := props[offsetKey]; exists {
+			layer.MotionOffset = outputVector(parseVector2Value(value))
+		}
+		if value, exists := props[repeatKey]; exists {
+			layer.RepeatSize = parseVector2Value(value)
+		}
+		if canvas := c.nodes.ancestor(node, isCanvasLayer); canvas != nil {
+			layer.CanvasLayer = canvas.intProperty("layer", defaultCanvasLayer(canvas.Type))
+			if canvas.Type == "ParallaxBackground" {
+				layer.Background = canvas.Path
+			}
+		}
+		layer.Sprites = append(layer.Sprites, c.parallaxSprites(node)...)
+		layers = append(layers, layer)
+	}
+	sort.SliceStable(layers, func(i, j int) bool {
+		return layers[i].CanvasLayer < layers[j].CanvasLayer
+	})
+	return layers
+}
+
+// parallaxSprites returns the sprites whose closest parallax layer is the given node
+func (c *TSCNConverter) parallaxSprites(layer *sceneNode) []ParallaxSprite {
+	var sprites []ParallaxSprite
+	for _, node := range c.nodes.order {
+		if node.Type != "Sprite2D" && node.Type != "AnimatedSprite2D" {
+			continue
+		}
+		if c.nodes.ancestor(node, isParallaxLayer) != layer {
+			continue
+		}
+		var texture spriteTexture
+		for key, value := range node.Properties {
+			texture.parseProperty(c.textures, key, value)
+		}
+		sprite := ParallaxSprite{
+			Name:       node.Name,
+			Path:       node.Path,
+			Transform:  outputCoordinates.LocalTransform(c.nodes.relativeTransform(layer.Path, node)),
+			Texture:    texture.resolved(),
+			Animations: texture.animations,
+			Centered:   node.Properties["centered"] != "false",
+			FlipH:      node.Properties["flip_h"] == "true",
+			FlipV:      node.Properties["flip_v"] == "true",
+			ZIndex:     node.intProperty("z_index", 0),
+			Groups:     c.nodeGroups(node),
+			NodeScript: c.nodeScript(node),
+			Metadata:   c.nodeMetadata(node),
+		}
+		if value, exists := node.Properties["offset"]; exists {
+			sprite.Offset = outputVector(parseVector2Value(value))
+		}
+		sprites = append(sprites, sprite)
+	}
+	return sprites
+}
+
+// sceneDecorators returns the decorators outside of parallax layers, whose sprites are
+// exported with their layer instead
+func (c *TSCNConverter) sceneDecorators() []DecoratorNode {
+	return slices.DeleteFunc(c.decorators, func(decorator DecoratorNode) bool {
+		node, exists := c.nodes.nodes[joinNodePath(decorator.Parent, decorator.Name)]
+		return exists && c.nodes.ancestor(node, isParallaxLayer) != nil
+	})
+}
+
+func isParallaxLayer(nodeType string) bool {
+	return nodeType == "ParallaxLayer" || nodeType == "Parallax2D"
+}
+
+func isCanvasLayer(nodeType string) bool {
+	return nodeType == "CanvasLayer" || nodeType == "ParallaxBackground"
+}
+
+// defaultCanvasLayer returns the layer a CanvasLayer type draws on when its layer property is not set
+func defaultCanvasLayer(nodeType string) int64 {
+	if nodeType == "ParallaxBackground" {
+		return -100
+	}
+	return 1
+}
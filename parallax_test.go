@@ -0,0 +1,74 @@
+package tscnparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParallax(t *testing.T) {
+	data := parseNodes(t, "", `
+[node name="Hills" type="Parallax2D" parent="."]
+position = Vector2(16, 0)
+scroll_scale = Vector2(0.8, 1)
+scroll_offset = Vector2(0, 8)
+repeat_size = Vector2(256, 0)
+repeat_times = 2
+autoscroll = Vector2(10, 5)
+
+[node name="Background" type="ParallaxBackground" parent="."]
+
+[node name="Sky" type="ParallaxLayer" parent="Background"]
+motion_scale = Vector2(0.5, 0.5)
+motion_mirroring = Vector2(320, 0)
+
+[node name="Clouds" type="Node2D" parent="Background/Sky"]
+position = Vector2(0, 8)
+
+[node name="Sun" type="Sprite2D" parent="Background/Sky/Clouds"]
+position = Vector2(8, 0)
+offset = Vector2(0, 4)
+centered = false
+
+[node name="Tree" type="Sprite2D" parent="."]
+position = Vector2(40, 24)
+`)
+	// The background's layer draws on canvas layer -100, behind the Parallax2D
+	want := []ParallaxLayer{
+		{
+			Name:        "Sky",
+			Path:        "Background/Sky",
+			Type:        "ParallaxLayer",
+			Background:  "Background",
+			CanvasLayer: -100,
+			Transform:   Transform2D{Position: Vec2{X: 48, Y: -32}, Scale: Vec2{X: 1, Y: 1}},
+			MotionScale: Vec2{X: 0.5, Y: 0.5},
+			RepeatSize:  Vec2{X: 320, Y: 0},
+			RepeatTimes: 1,
+			Sprites: []ParallaxSprite{{
+				Name:      "Sun",
+				Path:      "Background/Sky/Clouds/Sun",
+				Transform: Transform2D{Position: Vec2{X: 8, Y: -8}, Scale: Vec2{X: 1, Y: 1}},
+				Offset:    Vec2{X: 0, Y: -4},
+			}},
+		},
+		{
+			Name:         "Hills",
+			Path:         "Hills",
+			Type:         "Parallax2D",
+			Transform:    Transform2D{Position: Vec2{X: 64, Y: -32}, Scale: Vec2{X: 1, Y: 1}},
+			MotionScale:  Vec2{X: 0.8, Y: 1},
+			MotionOffset: Vec2{X: 0, Y: -8},
+			RepeatSize:   Vec2{X: 256, Y: 0},
+			RepeatTimes:  2,
+			Autoscroll:   Vec2{X: 10, Y: -5},
+			Sprites:      []ParallaxSprite{},
+		},
+	}
+	if !reflect.DeepEqual(data.Parallax, want) {
+		t.Errorf("parallax = %+v\nwant %+v", data.Parallax, want)
+	}
+	// The sprite of the layer is not a decorator too
+	if len(data.Decorators) != 1 || data.Decorators[0].Name != "Tree" {
+		t.Errorf("decorators = %+v", data.Decorators)
+	}
+}
@@ -21,58 +21,95 @@ type ExtResource struct {
 
 // ShapeInfo contains shape type and dimensions
 type ShapeInfo struct {
-	Type       string
-	Dimensions Vec2      // For RectangleShape2D (width, height), CircleShape2D (radius in X, 0 in Y)
-	Points     []float64 // For polygon shapes (ConvexPolygonShape2D, ConcavePolygonShape2D)
+	Type         string
+	Dimensions   Vec2      // For RectangleShape2D (width, height), CircleShape2D (radius in X, 0 in Y), CapsuleShape2D (radius, height)
+	Points       []float64 // For polygon shapes (ConvexPolygonShape2D points, ConcavePolygonShape2D segments)
+	A, B         Vec2      // For SegmentShape2D
+	Length       float64   // For SeparationRayShape2D
+	SlideOnSlope bool      // For SeparationRayShape2D
+	Normal       Vec2      // For WorldBoundaryShape2D
+	Distance     float64   // For WorldBoundaryShape2D
 }
 
 // PrefabInfo contains information extracted from a prefab .tscn file
 type PrefabInfo struct {
-	Name           string
-	Pivot          Vec2
-	ZIndex         int32
-	Scale          Vec2
-	Rotation       float64
-	ColliderType   string
-	ColliderPivot  Vec2
-	ColliderParams []float64
-	Texture        string
-	ColliderParent string
+	Name                 string
+	Pivot                Vec2
+	ZIndex               int32
+	Scale                Vec2
+	Rotation             float64
+	ColliderType         string
+	ColliderPivot        Vec2
+	ColliderParams       []float64
+	ColliderRotation     float64
+	ColliderScale        Vec2
+	ColliderDisabled     bool
+	ColliderOneWay       bool
+	ColliderOneWayMargin float64
+	Colliders            []Collider
+	Texture              string
+	TextureRef           *TextureRef
+	Hframes              int
+	Vframes              int
+	Frame                int
+	Animations           []SpriteAnimation
+	ColliderParent       string
+	Nodes                *nodeTree               // Every node of the prefab scene with its type and raw properties
+	ExtResources         map[string]*ExtResource // ExtResources of the prefab scene
 }
 
 var (
-	tilemapTileSize  = TileSize{Width: 16, Height: 16}
-	tilemapOffset    = Vec2{X: 0, Y: 0}
-	prefabsDirectory string
+	tilemapTileSize   = TileSize{Width: 16, Height: 16}
+	tilemapOffset     = Vec2{X: 0, Y: 0}
+	coordinateSystem  = CoordinatesYUp
+	prefabsDirectory  string
+	projectRoot       string
+	pathBakeTolerance = 1.0
+	parseScripts      bool
 )
 
 // TSCNConverter handles conversion from TSCN to TileMap JSON
 type TSCNConverter struct {
-	tileSize            TileSize
-	sources             map[int]*TileSource
-	extResources        map[string]*ExtResource
-	subResourceTextures map[string]string      // Maps SubResource ID to ExtResource ID
-	tilePhysicsData     map[string][]Vec2      // Maps SubResource ID to physics points
-	decorators          []DecoratorNode        // Collected Decorator nodes
-	currentDecorator    *DecoratorNode         // Currently parsing Decorator node
-	sprites             []SpriteNode           // Collected Sprite nodes
-	currentSprite       *SpriteNode            // Currently parsing Sprite node
-	prefabCache         map[string]*PrefabInfo // Cache for parsed prefab files
-	subResourceShapes   map[string]*ShapeInfo  // Maps SubResource ID to shape info
+	tileSize          TileSize
+	sources           map[int]*TileSource
+	extResources      map[string]*ExtResource
+	atlasSources      map[string]*TileSource      // Maps TileSetAtlasSource SubResource ID to its texture and tiles
+	decorators        []DecoratorNode             // Collected Decorator nodes
+	currentDecorator  *DecoratorNode              // Currently parsing Decorator node
+	sprites           []SpriteNode                // Collected Sprite nodes
+	currentSprite     *SpriteNode                 // Currently parsing Sprite node
+	prefabCache       map[string]*PrefabInfo      // Cache for parsed prefab files
+	subResourceShapes map[string]*ShapeInfo       // Maps SubResource ID to shape info
+	textures          *textureResolver            // Resolves ExtResource and AtlasTexture references
+	nodes             *nodeTree                   // Every node of the scene with its raw properties
+	curves            map[string][]curvePoint     // Maps Curve2D SubResource ID to its points
+	occluders         map[string]*occluderPolygon // Maps OccluderPolygon2D SubResource ID to its polygon
+	connections       []Connection                // Signal connections in declaration order
+	scripts           map[string]*Script          // Parsed scripts by res:// path, nil if unreadable
+	nodeScripts       map[*sceneNode]NodeScript   // Script and exported variables per node
+	warnings          []string                    // Problems found while converting
+	currentNode       *sceneNode                  // Node whose properties are being parsed
+	currentTexture    spriteTexture               // Texture properties of the current Decorator node
 }
 
 // NewTSCNConverter creates a new converter instance
 func newTSCNConverter() *TSCNConverter {
+	extResources := make(map[string]*ExtResource)
 	return &TSCNConverter{
-		tileSize:            TileSize{Width: 16, Height: 16}, // Default tile size
-		sources:             make(map[int]*TileSource),
-		extResources:        make(map[string]*ExtResource),
-		subResourceTextures: make(map[string]string),
-		tilePhysicsData:     make(map[string][]Vec2),
-		decorators:          []DecoratorNode{},
-		sprites:             []SpriteNode{},
-		prefabCache:         make(map[string]*PrefabInfo),
-		subResourceShapes:   make(map[string]*ShapeInfo),
+		tileSize:          TileSize{Width: 16, Height: 16}, // Default tile size
+		sources:           make(map[int]*TileSource),
+		extResources:      extResources,
+		atlasSources:      make(map[string]*TileSource),
+		decorators:        []DecoratorNode{},
+		sprites:           []SpriteNode{},
+		prefabCache:       make(map[string]*PrefabInfo),
+		subResourceShapes: make(map[string]*ShapeInfo),
+		textures:          newTextureResolver(extResources),
+		nodes:             newNodeTree(),
+		curves:            make(map[string][]curvePoint),
+		occluders:         make(map[string]*occluderPolygon),
+		scripts:           make(map[string]*Script),
+		nodeScripts:       make(map[*sceneNode]NodeScript),
 	}
 }
 
@@ -135,14 +172,15 @@ func parseLayersFromTSCN(filename string) ([]Layer, error) {
 				}
 
 				// Convert from old format [encoded_position, source_id, atlas_coords] to new format [source_id, tile_x, tile_y, atlas_x, atlas_y]
-				convertedTileData := convertTileDataFormat(currentTileData)
+				convertedTileData, tileFlags := convertTileDataFormat(currentTileData)
 
 				// Create layer and add to result
 				layer := Layer{
-					ID:       currentLayerID,
-					Name:     currentLayerName,
-					ZIndex:   currentZIndex,
-					TileData: convertedTileData,
+					ID:        currentLayerID,
+					Name:      currentLayerName,
+					ZIndex:    currentZIndex,
+					TileData:  convertedTileData,
+					TileFlags: tileFlags,
 				}
 				layers = append(layers, layer)
 
@@ -158,20 +196,25 @@ func parseLayersFromTSCN(filename string) ([]Layer, error) {
 }
 
 // convertTileDataFormat converts tile data from old format to new format
-// Old format: [tilePos, source_id, atlas_coords_encoded] (3 elements per tile)
+// Old format: [tilePos, source_id | atlas_x << 16, atlas_y | alternative_tile << 16] (3 elements per tile)
 // New format: [source_id, tile_x, tile_y, atlas_x, atlas_y] (5 elements per tile)
+// The tile coordinates are the scene cells; ConvertTSCNToTileMap converts them to output
+// coordinates once the bounds of the map are known.
+// The flip and transpose flags of the alternative tiles are returned separately, one entry
+// per tile, or nil when no tile is transformed.
 // This function uses the original parsing logic from internal/tilemap/tilemap.go before commit f81157b
-func convertTileDataFormat(tileData []int) []int {
+func convertTileDataFormat(tileData []int) ([]int, []int) {
 	var newData []int
+	var flags []int
+	transformed := false
 	lenght := len(tileData)
-	tileOffsetX, tileOffsetY := int(tilemapOffset.X/float64(tilemapTileSize.Width)), int(tilemapOffset.Y/float64(tilemapTileSize.Height))
 	// Original parsing logic from internal/tilemap/tilemap.go
 	for i := 0; i < lenght; i += 3 {
 		if i+2 >= lenght {
 			break
 		}
 		tilePos := tileData[i]
-		sourceID := tileData[i+1]
+		sourceEncoded := tileData[i+1]
 		atlasEncoded := tileData[i+2]
 
 		// Decode tile position (Godot uses a specific encoding)
@@ -196,25 +239,72 @@ func convertTileDataFormat(tileData []int) []int {
 		if tileY > maxTileY {
 			maxTileY = tileY
 		}
-		// Decode atlas coordinates (usually just X and Y)
-		atlasX := atlasEncoded & 0xFFFF
-		atlasY := (atlasEncoded >> 16) & 0xFFFF
+		// Decode source and atlas coordinates, which share their int with the source ID
+		// and the alternative tile
+		sourceID := sourceEncoded & 0xFFFF
+		atlasX := (sourceEncoded >> 16) & 0xFFFF
+		atlasY := atlasEncoded & 0xFFFF
+		tileFlags := tileTransformFlags((atlasEncoded >> 16) & 0xFFFF)
+		transformed = transformed || tileFlags != 0
+		flags = append(flags, tileFlags)
 
-		tileX += tileOffsetX
-		tileY += tileOffsetY
 		tileTotalCount++
 		// Append in new format: [source_id, tile_x, tile_y, atlas_x, atlas_y]
-		newData = append(newData, sourceID, tileX, -tileY, atlasX, atlasY)
+		newData = append(newData, sourceID, tileX, tileY, atlasX, atlasY)
 	}
 
-	return newData
+	if !transformed {
+		flags = nil
+	}
+	return newData, flags
+}
+
+// tileTransformFlags converts the TRANSFORM_FLIP_H, TRANSFORM_FLIP_V and TRANSFORM_TRANSPOSE
+// bits of an alternative tile ID to the TileFlip* flags
+func tileTransformFlags(alternative int) int {
+	flags := 0
+	if alternative&(1<<12) != 0 {
+		flags |= TileFlipH
+	}
+	if alternative&(1<<13) != 0 {
+		flags |= TileFlipV
+	}
+	if alternative&(1<<14) != 0 {
+		flags |= TileTranspose
+	}
+	return flags
 }
 
 // ConvertTSCNToTileMap converts a TSCN file to TileMap data structure
 func (c *TSCNConverter) ConvertTSCNToTileMap(filename string) (*MapData, error) {
+	minTileX = 1000000
+	maxTileX = -1000000
+	minTileY = 1000000
+	maxTileY = -1000000
+	// Parse layer data directly from TSCN file since tscnparser doesn't handle it properly.
+	// The layers come first: their bounds place the origin of the output coordinates.
+	layers, _ := parseLayersFromTSCN(filename)
+	outputCoordinates = newCoordinates(coordinateSystem, tilemapOffset, tilemapTileSize, maxTileX >= minTileX, minTileX, minTileY, maxTileX, maxTileY)
+	for _, layer := range layers {
+		for i := 0; i+4 < len(layer.TileData); i += 5 {
+			layer.TileData[i+1], layer.TileData[i+2] = outputCoordinates.Tile(layer.TileData[i+1], layer.TileData[i+2], tilemapTileSize)
+		}
+	}
 	data, err := c.convertTSCNToTileMap(filename)
-	// Parse layer data directly from TSCN file since tscnparser doesn't handle it properly
-	data.TileMap.Layers, _ = parseLayersFromTSCN(filename)
+	if err != nil {
+		return nil, err
+	}
+	data.TileMap.Layers = layers
+	data.SchemaVersion = SchemaVersion
+	data.Coordinates = outputCoordinates
+	c.resolveDrawOrder(data)
+	c.resolveGroups(data)
+	data.Connections = c.connections
+	data.resolveConnections()
+	c.resolveScripts(data)
+	data.Warnings = append(data.Warnings, c.warnings...)
+	// Read image sizes from disk and validate regions against them
+	data.Warnings = append(data.Warnings, loadTextureSizes(data)...)
 
 	diffX := maxTileX - minTileX + 1
 	diffY := maxTileY - minTileY + 1
@@ -247,12 +337,9 @@ func (c *TSCNConverter) convertTSCNToTileMap(filename string) (*MapData, error)
 
 	var currentSection string
 	var currentSubResource string
+	var currentSubResourceType string
 	var format int
 	var layers []Layer
-	minTileX = 1000000
-	maxTileX = -1000000
-	minTileY = 1000000
-	maxTileY = -1000000
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 
@@ -263,66 +350,82 @@ func (c *TSCNConverter) convertTSCNToTileMap(filename string) (*MapData, error)
 
 		// Detect sections
 		if strings.HasPrefix(line, "[") {
-			if strings.Contains(line, "ext_resource") {
+			// Finish current nodes if we're leaving their sections
+			c.flushCurrentNodes()
+			c.currentNode = nil
+			if strings.HasPrefix(line, "[node ") {
+				c.currentNode = c.nodes.add(line)
+			}
+			if strings.HasPrefix(line, "[connection ") {
+				currentSection = "connection"
+				c.connections = append(c.connections, parseConnection(line))
+			} else if strings.Contains(line, "ext_resource") {
 				currentSection = "ext_resource"
 				// Parse ExtResource immediately since it's all on one line
 				c.parseExtResource(line)
 			} else if strings.Contains(line, "sub_resource") {
 				currentSection = "sub_resource"
 				currentSubResource = c.extractSubResourceID(line)
+				currentSubResourceType = extractSectionType(line)
 				// Extract shape type if it's a shape sub_resource
 				if shapeType := c.extractShapeType(line); shapeType != "" {
-					c.subResourceShapes[currentSubResource] = &ShapeInfo{Type: shapeType}
+					c.subResourceShapes[currentSubResource] = newShapeInfo(shapeType)
 				}
-			} else if strings.Contains(line, "node name=\"TileMap\"") {
-				currentSection = "tilemap"
-			} else if strings.Contains(line, "type=\"Sprite2D\"") {
-				// Finish current nodes if we were processing any
-				if currentSection == "decorator" && c.currentDecorator != nil {
-					c.decorators = append(c.decorators, *c.currentDecorator)
-					c.currentDecorator = nil
+				if currentSubResourceType == "AtlasTexture" {
+					c.textures.atlasTextures[currentSubResource] = &TextureRef{}
 				}
-				if currentSection == "sprite" && c.currentSprite != nil {
-					c.sprites = append(c.sprites, *c.currentSprite)
-					c.currentSprite = nil
+				if currentSubResourceType == "OccluderPolygon2D" {
+					c.occluders[currentSubResource] = &occluderPolygon{closed: true}
 				}
+				if currentSubResourceType == "TileSetAtlasSource" {
+					c.atlasSources[currentSubResource] = &TileSource{
+						TexturePath:       "unknown",
+						TextureRegionSize: Vec2i{X: 16, Y: 16}, // Godot's default region size
+					}
+				}
+			} else if strings.Contains(line, "node name=\"TileMap\"") {
+				currentSection = "tilemap"
+			} else if strings.Contains(line, "type=\"Sprite2D\"") || strings.Contains(line, "type=\"AnimatedSprite2D\"") {
 				currentSection = "decorator"
 				// Initialize new Decorator node
 				c.currentDecorator = c.parseDecoratorNode(line)
 			} else if strings.Contains(line, "instance=ExtResource") {
-				// Finish current nodes if we were processing any
-				if currentSection == "decorator" && c.currentDecorator != nil {
-					c.decorators = append(c.decorators, *c.currentDecorator)
-					c.currentDecorator = nil
-				}
-				if currentSection == "sprite" && c.currentSprite != nil {
-					c.sprites = append(c.sprites, *c.currentSprite)
-					c.currentSprite = nil
-				}
 				currentSection = "sprite"
 				// Initialize new Sprite node
 				c.currentSprite = c.parseSpriteNode(line)
 			} else {
-				// Finish current nodes if we're leaving their sections
-				if currentSection == "decorator" && c.currentDecorator != nil {
-					c.decorators = append(c.decorators, *c.currentDecorator)
-					c.currentDecorator = nil
-				}
-				if currentSection == "sprite" && c.currentSprite != nil {
-					c.sprites = append(c.sprites, *c.currentSprite)
-					c.currentSprite = nil
-				}
 				currentSection = "other"
 			}
 			continue
 		}
 
+		// Multi-line values (arrays, dictionaries, strings) continue on the following lines
+		line = readFullValue(scanner, line)
+		if c.currentNode != nil {
+			c.currentNode.setProperty(line)
+		}
+
 		// Parse content based on current section
 		switch currentSection {
 		case "ext_resource":
 			c.parseExtResource(line)
 		case "sub_resource":
-			c.parseSubResource(line, currentSubResource)
+			switch currentSubResourceType {
+			case "AtlasTexture":
+				c.textures.parseAtlasTextureProperty(currentSubResource, line)
+			case "SpriteFrames":
+				if key, value, ok := splitProperty(line); ok && key == "animations" {
+					c.textures.parseSpriteFrames(currentSubResource, value)
+				}
+			case "Curve2D":
+				if key, value, ok := splitProperty(line); ok && key == "_data" {
+					c.curves[currentSubResource] = parseCurve2DData(value)
+				}
+			case "OccluderPolygon2D":
+				c.parseOccluderProperty(c.occluders[currentSubResource], line)
+			default:
+				c.parseSubResource(line, currentSubResource)
+			}
 		case "decorator":
 			c.parseDecoratorProperty(line)
 		case "sprite":
@@ -339,15 +442,8 @@ func (c *TSCNConverter) convertTSCNToTileMap(filename string) (*MapData, error)
 		}
 	}
 
-	// Handle any remaining Decorator node
-	if c.currentDecorator != nil {
-		c.decorators = append(c.decorators, *c.currentDecorator)
-	}
-
-	// Handle any remaining Sprite node
-	if c.currentSprite != nil {
-		c.sprites = append(c.sprites, *c.currentSprite)
-	}
+	// Handle any remaining Decorator or Sprite node
+	c.flushCurrentNodes()
 
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading file: %w", err)
@@ -371,12 +467,58 @@ func (c *TSCNConverter) convertTSCNToTileMap(filename string) (*MapData, error)
 			},
 			Layers: layers,
 		},
-		Decorators: c.decorators,
+		Decorators: c.sceneDecorators(),
 		Sprites:    c.sprites,
 		Prefabs:    c.buildPrefabNodes(),
+		Triggers:   c.buildTriggers(),
+		Markers:    c.buildMarkers(),
+		Paths:      c.buildPaths(),
+		Geometry:   c.buildGeometry(),
+		Lighting:   c.buildLighting(),
+		Cameras:    c.buildCameras(),
+		Parallax:   c.buildParallax(),
 	}, nil
 }
 
+// flushCurrentNodes appends the Decorator or Sprite node currently being parsed to its collection
+func (c *TSCNConverter) flushCurrentNodes() {
+	if c.currentDecorator != nil {
+		if texture := c.currentTexture.resolved(); texture != nil {
+			c.currentDecorator.Path = texture.Path
+			c.currentDecorator.TextureRef = texture
+		}
+		c.currentDecorator.Hframes = c.currentTexture.hframes
+		c.currentDecorator.Vframes = c.currentTexture.vframes
+		c.currentDecorator.Frame = c.currentTexture.frame
+		c.currentDecorator.Animations = c.currentTexture.animations
+		c.decorators = append(c.decorators, *c.currentDecorator)
+		c.currentDecorator = nil
+	}
+	c.currentTexture = spriteTexture{}
+	if c.currentSprite != nil {
+		c.sprites = append(c.sprites, *c.currentSprite)
+		c.currentSprite = nil
+	}
+}
+
+// readFullValue appends continuation lines to a property line until its value is complete
+func readFullValue(scanner *bufio.Scanner, line string) string {
+	for !valueComplete(line) && scanner.Scan() {
+		line += "\n" + scanner.Text()
+	}
+	return line
+}
+
+// extractSectionType extracts the type attribute from a section header line
+func extractSectionType(line string) string {
+	re := regexp.MustCompile(`\stype="([^"]+)"`)
+	matches := re.FindStringSubmatch(line)
+	if len(matches) > 1 {
+		return matches[1]
+	}
+	return ""
+}
+
 // parseExtResource parses external resource declarations
 func (c *TSCNConverter) parseExtResource(line string) {
 	// ExtResource format: [ext_resource type="Texture2D" uid="uid://..." path="res://..." id="1_grrf0"]
@@ -443,76 +585,91 @@ func (c *TSCNConverter) extractShapeType(line string) string {
 
 // parseSubResource parses sub-resource data (TileSetAtlasSource, TileSet)
 func (c *TSCNConverter) parseSubResource(line, resourceID string) {
-	if strings.HasPrefix(line, "texture = ExtResource(") {
-		// Extract ExtResource ID from the line
-		extResourceID := c.extractExtResourceID(line)
-		if extResourceID != "" && resourceID != "" {
-			// Store the mapping for later use
-			c.subResourceTextures[resourceID] = extResourceID
-		}
-	} else if strings.HasPrefix(line, "size = Vector2(") {
-		// Parse shape size for RectangleShape2D
-		if shape, exists := c.subResourceShapes[resourceID]; exists {
-			shape.Dimensions = c.extractVector2(line)
-		}
-	} else if strings.HasPrefix(line, "radius = ") {
-		// Parse radius for CircleShape2D
-		if shape, exists := c.subResourceShapes[resourceID]; exists {
-			parts := strings.Split(line, "=")
-			if len(parts) > 1 {
-				radius, _ := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
-				shape.Dimensions = Vec2{X: radius, Y: 0}
-			}
-		}
-	} else if strings.HasPrefix(line, "points = PackedVector2Array(") {
-		// Parse polygon points for ConvexPolygonShape2D and ConcavePolygonShape2D
-		if shape, exists := c.subResourceShapes[resourceID]; exists {
-			points := c.extractPolygonPoints(line)
-			for _, p := range points {
-				shape.Points = append(shape.Points, p.X, p.Y)
-			}
-		}
-	} else if strings.HasPrefix(line, "0:0/0/physics_layer_0/polygon_0/points") {
-		// Parse collision polygon to determine tile size and store physics data
-		points := c.extractPolygonPoints(line)
-		if len(points) >= 4 {
-			// Calculate tile size from collision box
-			c.tileSize = c.calculateTileSizeFromPoints(points)
-			// Store physics data for this resource
-			c.tilePhysicsData[resourceID] = points
-		}
+	if atlas, exists := c.atlasSources[resourceID]; exists {
+		c.parseAtlasSourceProperty(atlas, line)
+	} else if shape, exists := c.subResourceShapes[resourceID]; exists {
+		// Parse Shape2D parameters
+		c.parseShapeProperty(shape, line)
 	} else if strings.HasPrefix(line, "sources/") {
 		// Parse tileset sources
 		sourceID := c.extractSourceID(line)
 		subResourceID := c.extractSubResourceReference(line)
 		if sourceID != -1 {
-			texturePath := "unknown"
-			// Try to resolve texture path using our mappings
-			if subResourceID != "" {
-				if extResourceID, exists := c.subResourceTextures[subResourceID]; exists {
-					if extRes, extExists := c.extResources[extResourceID]; extExists {
-						texturePath = extRes.Path
-					}
-				}
+			source := TileSource{TexturePath: "unknown"}
+			if atlas, exists := c.atlasSources[subResourceID]; exists {
+				source = *atlas
 			}
-
-			// Get physics data for this tile source
-			var physicsData PhysicsData
-			if subResourceID != "" {
-				if points, hasPhysics := c.tilePhysicsData[subResourceID]; hasPhysics {
-					physicsData = PhysicsData{CollisionPoints: points}
-				}
+			source.ID = sourceID
+			if len(source.Tiles) == 0 {
+				source.Tiles = []TileInfo{{AtlasCoords: Vec2i{X: 0, Y: 0}, SizeInAtlas: Vec2i{X: 1, Y: 1}}}
 			}
+			c.sources[sourceID] = &source
+		}
+	}
+}
+
+var atlasTileRe = regexp.MustCompile(`^(\d+):(\d+)/(\S+) = `)
+
+// parseAtlasSourceProperty parses a property of a TileSetAtlasSource sub_resource
+func (c *TSCNConverter) parseAtlasSourceProperty(atlas *TileSource, line string) {
+	key, value, ok := splitProperty(line)
+	if !ok {
+		return
+	}
+	switch key {
+	case "texture":
+		// Resolve the ExtResource or AtlasTexture SubResource
+		if texture := c.textures.resolve(value); texture != nil {
+			atlas.TexturePath = texture.Path
+			atlas.TextureRef = texture
+		}
+		return
+	case "texture_region_size":
+		atlas.TextureRegionSize = c.extractVector2i(line)
+		return
+	case "margins":
+		atlas.Margins = c.extractVector2i(line)
+		return
+	case "separation":
+		atlas.Separation = c.extractVector2i(line)
+		return
+	}
 
-			c.sources[sourceID] = &TileSource{
-				ID:          sourceID,
-				TexturePath: texturePath,
-				Tiles:       []TileInfo{{AtlasCoords: Vec2i{X: 0, Y: 0}, Physics: physicsData}},
+	// Per-tile properties: 0:0/0 = 0, 0:0/size_in_atlas = Vector2i(2, 3),
+	// 0:0/0/physics_layer_0/polygon_0/points = PackedVector2Array(...)
+	matches := atlasTileRe.FindStringSubmatch(line)
+	if len(matches) < 4 {
+		return
+	}
+	atlasX, _ := strconv.Atoi(matches[1])
+	atlasY, _ := strconv.Atoi(matches[2])
+	tile := atlasTile(atlas, Vec2i{X: atlasX, Y: atlasY})
+	switch property := matches[3]; {
+	case property == "size_in_atlas":
+		tile.SizeInAtlas = c.extractVector2i(line)
+	case strings.HasSuffix(property, "/physics_layer_0/polygon_0/points"):
+		points := c.extractPolygonPoints(line)
+		if len(points) >= 4 {
+			// Calculate tile size from collision box
+			if atlasX == 0 && atlasY == 0 {
+				c.tileSize = c.calculateTileSizeFromPoints(points)
 			}
+			tile.Physics = PhysicsData{CollisionPoints: outputVectors(points)}
 		}
 	}
 }
 
+// atlasTile returns the tile at the given atlas coordinates, adding it if needed
+func atlasTile(atlas *TileSource, coords Vec2i) *TileInfo {
+	for i := range atlas.Tiles {
+		if atlas.Tiles[i].AtlasCoords == coords {
+			return &atlas.Tiles[i]
+		}
+	}
+	atlas.Tiles = append(atlas.Tiles, TileInfo{AtlasCoords: coords, SizeInAtlas: Vec2i{X: 1, Y: 1}})
+	return &atlas.Tiles[len(atlas.Tiles)-1]
+}
+
 // extractLayerID extracts layer ID from tile_data line
 func (c *TSCNConverter) extractLayerID(line string) int {
 	re := regexp.MustCompile(`layer_(\d+)/`)
@@ -686,6 +843,24 @@ func (c *TSCNConverter) calculateTileSizeFromPoints(points []Vec2) TileSize {
 	}
 }
 
+// extractVector2i extracts Vector2i coordinates from a line like "texture_region_size = Vector2i(16, 16)"
+func (c *TSCNConverter) extractVector2i(line string) Vec2i {
+	re := regexp.MustCompile(`Vector2i\(([^,]+),\s*([^)]+)\)`)
+	matches := re.FindStringSubmatch(line)
+	if len(matches) < 3 {
+		return Vec2i{X: 0, Y: 0}
+	}
+
+	x, err1 := strconv.Atoi(strings.TrimSpace(matches[1]))
+	y, err2 := strconv.Atoi(strings.TrimSpace(matches[2]))
+
+	if err1 != nil || err2 != nil {
+		return Vec2i{X: 0, Y: 0}
+	}
+
+	return Vec2i{X: x, Y: y}
+}
+
 // parseDecoratorNode creates a new Decorator node from the node declaration line
 func (c *TSCNConverter) parseDecoratorNode(line string) *DecoratorNode {
 	// Extract node name and parent from line like: [node name="Cloud1" type="Sprite2D" parent="Decorations/Clouds"]
@@ -717,18 +892,13 @@ func (c *TSCNConverter) parseDecoratorProperty(line string) {
 
 	if strings.HasPrefix(line, "position = Vector2(") {
 		// Extract position coordinates
-		position := c.extractVector2(line)
-		position.Y = -position.Y
-		c.currentDecorator.Position = position
-	} else if strings.HasPrefix(line, "texture = ExtResource(") {
-		// Extract texture ExtResource ID and resolve path
-		extResourceID := c.extractExtResourceID(line)
-		if extRes, exists := c.extResources[extResourceID]; exists {
-			c.currentDecorator.Path = extRes.Path
-		}
+		c.currentDecorator.Position = outputPoint(c.extractVector2(line))
 	} else if strings.HasPrefix(line, "z_index = ") {
 		// Extract z_index
 		c.currentDecorator.ZIndex = int32(c.extractIntValue(line))
+	} else if key, value, ok := splitProperty(line); ok {
+		// Texture, region and sprite_frames are resolved when the node is finished
+		c.currentTexture.parseProperty(c.textures, key, value)
 	}
 }
 
@@ -756,8 +926,8 @@ func (c *TSCNConverter) parseSpriteNode(line string) *SpriteNode {
 	sprite := &SpriteNode{
 		Path:       "unknown",        // Default until we resolve ExtResource
 		Scale:      Vec2{X: 1, Y: 1}, // Default scale
-		Ratation:   0,                // Default rotation
-		Properties: make(map[string]any),
+		Rotation:   0,                // Default rotation
+		Properties: make(Properties),
 	}
 
 	// Extract name
@@ -795,11 +965,7 @@ func (c *TSCNConverter) parseSpriteProperty(line string) {
 
 	if strings.HasPrefix(line, "position = Vector2(") {
 		// Extract position coordinates
-		position := c.extractVector2(line)
-		position.X += tilemapOffset.X
-		position.Y += tilemapOffset.Y
-		position.Y = -position.Y
-		c.currentSprite.Position = position
+		c.currentSprite.Position = outputPoint(c.extractVector2(line))
 	} else if strings.HasPrefix(line, "scale = Vector2(") {
 		// Extract scale
 		c.currentSprite.Scale = c.extractVector2(line)
@@ -808,23 +974,22 @@ func (c *TSCNConverter) parseSpriteProperty(line string) {
 		parts := strings.Split(line, "=")
 		if len(parts) > 1 {
 			rotation, _ := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
-			c.currentSprite.Ratation = rotation
+			c.currentSprite.Rotation = outputRotation(rotation)
 		}
 	} else if strings.HasPrefix(line, "gid = ") {
 		// Extract gid (common in enemy nodes)
 		gidValue := c.extractIntValue(line)
 		c.currentSprite.Properties["gid"] = gidValue
-	} else if strings.HasPrefix(line, "zoom = Vector2(") {
-		// Extract zoom for Camera2D
-		zoom := c.extractVector2(line)
-		c.currentSprite.Properties["zoom"] = zoom
 	} else if strings.Contains(line, " = ") && !strings.HasPrefix(line, "[") {
-		// Generic property extraction
+		// Generic property extraction. Metadata and the script have their own fields.
 		parts := strings.SplitN(line, " = ", 2)
 		if len(parts) == 2 {
 			key := strings.TrimSpace(parts[0])
 			value := strings.TrimSpace(parts[1])
-			c.currentSprite.Properties[key] = value
+			if key == "script" || strings.HasPrefix(key, "metadata/") {
+				return
+			}
+			c.currentSprite.Properties[key] = c.resolveResourcePaths(propertyValue(value))
 		}
 	}
 }
@@ -867,6 +1032,23 @@ func (c *TSCNConverter) getPrefabInfo(resPath string) (*PrefabInfo, error) {
 	return info, nil
 }
 
+// instanceRoot returns the root node of an instanced scene, or nil if the node is not
+// an instance or its scene cannot be read
+func (c *TSCNConverter) instanceRoot(node *sceneNode) *sceneNode {
+	if node.Instance == "" {
+		return nil
+	}
+	extRes, exists := c.extResources[node.Instance]
+	if !exists {
+		return nil
+	}
+	info, err := c.getPrefabInfo(extRes.Path)
+	if err != nil {
+		return nil
+	}
+	return info.Nodes.nodes["."]
+}
+
 // parsePrefabFile parses a prefab .tscn file and extracts relevant information
 func (c *TSCNConverter) parsePrefabFile(filePath string) (*PrefabInfo, error) {
 	file, err := os.Open(filePath)
@@ -884,11 +1066,18 @@ func (c *TSCNConverter) parsePrefabFile(filePath string) (*PrefabInfo, error) {
 	prefabExtResources := make(map[string]*ExtResource)
 	// Create a temporary map for sub_resource shapes in this prefab file
 	prefabShapes := make(map[string]*ShapeInfo)
+	// Resolve textures against the prefab's own resources
+	prefabTextures := newTextureResolver(prefabExtResources)
+	var prefabTexture spriteTexture
 
 	scanner := bufio.NewScanner(file)
 	var currentSection string
 	var currentSubResource string
+	var currentSubResourceType string
 	var inSprite2D bool
+	// Track every node so colliders can be related to their physics bodies
+	prefabNodes := newNodeTree()
+	var currentNode *sceneNode
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -937,13 +1126,30 @@ func (c *TSCNConverter) parsePrefabFile(filePath string) (*PrefabInfo, error) {
 		if strings.Contains(line, "[sub_resource") {
 			currentSection = "sub_resource"
 			currentSubResource = c.extractSubResourceID(line)
+			currentSubResourceType = extractSectionType(line)
 			// Extract shape type if it's a shape sub_resource
 			if shapeType := c.extractShapeType(line); shapeType != "" {
-				prefabShapes[currentSubResource] = &ShapeInfo{Type: shapeType}
+				prefabShapes[currentSubResource] = newShapeInfo(shapeType)
+			}
+			if currentSubResourceType == "AtlasTexture" {
+				prefabTextures.atlasTextures[currentSubResource] = &TextureRef{}
 			}
 			continue
 		}
 
+		// Multi-line values (arrays, dictionaries, strings) continue on the following lines
+		if !strings.HasPrefix(line, "[") {
+			line = readFullValue(scanner, line)
+		}
+
+		if strings.HasPrefix(line, "[node ") {
+			currentNode = prefabNodes.add(line)
+		} else if strings.HasPrefix(line, "[") {
+			currentNode = nil
+		} else if currentNode != nil {
+			currentNode.setProperty(line)
+		}
+
 		// Detect root node (first node declaration)
 		if strings.HasPrefix(line, "[node name=") && info.Name == "" {
 			// Extract root node name
@@ -955,56 +1161,35 @@ func (c *TSCNConverter) parsePrefabFile(filePath string) (*PrefabInfo, error) {
 		}
 
 		// Detect Sprite2D node section
-		if strings.Contains(line, "type=\"Sprite2D\"") {
+		if strings.Contains(line, "type=\"Sprite2D\"") || strings.Contains(line, "type=\"AnimatedSprite2D\"") {
 			inSprite2D = true
 			currentSection = "sprite2d"
 			continue
 		}
 
 		// Detect CollisionShape2D or CollisionPolygon2D
-		if strings.Contains(line, "type=\"CollisionShape2D\"") || strings.Contains(line, "type=\"CollisionPolygon2D\"") {
+		if isCollisionNodeLine(line) {
 			inSprite2D = false
 			currentSection = "collision"
-			info.ColliderType = "auto"
-			if re := regexp.MustCompile(`parent="([^"]+)"`); re != nil {
-				if matches := re.FindStringSubmatch(line); len(matches) > 1 {
-					info.ColliderParent = matches[1]
-				}
-			}
 			continue
 		}
 
 		// Reset section on new node
-		if strings.HasPrefix(line, "[node ") && !strings.Contains(line, "type=\"Sprite2D\"") &&
-			!strings.Contains(line, "type=\"CollisionShape2D\"") && !strings.Contains(line, "type=\"CollisionPolygon2D\"") {
+		if strings.HasPrefix(line, "[node ") && !strings.Contains(line, "type=\"Sprite2D\"") && !strings.Contains(line, "type=\"AnimatedSprite2D\"") &&
+			!isCollisionNodeLine(line) {
 			inSprite2D = false
 			currentSection = ""
 		}
 
 		// Parse sub_resource properties
 		if currentSection == "sub_resource" && currentSubResource != "" {
-			if strings.HasPrefix(line, "size = Vector2(") {
-				// Parse shape size for RectangleShape2D
-				if shape, exists := prefabShapes[currentSubResource]; exists {
-					shape.Dimensions = c.extractVector2(line)
-				}
-			} else if strings.HasPrefix(line, "radius = ") {
-				// Parse radius for CircleShape2D
-				if shape, exists := prefabShapes[currentSubResource]; exists {
-					parts := strings.Split(line, "=")
-					if len(parts) > 1 {
-						radius, _ := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
-						shape.Dimensions = Vec2{X: radius, Y: 0}
-					}
-				}
-			} else if strings.HasPrefix(line, "points = PackedVector2Array(") {
-				// Parse polygon points for ConvexPolygonShape2D and ConcavePolygonShape2D
-				if shape, exists := prefabShapes[currentSubResource]; exists {
-					points := c.extractPolygonPoints(line)
-					for _, p := range points {
-						shape.Points = append(shape.Points, p.X, p.Y)
-					}
-				}
+			if currentSubResourceType == "AtlasTexture" {
+				prefabTextures.parseAtlasTextureProperty(currentSubResource, line)
+			} else if key, value, ok := splitProperty(line); ok && currentSubResourceType == "SpriteFrames" && key == "animations" {
+				prefabTextures.parseSpriteFrames(currentSubResource, value)
+			} else if shape, exists := prefabShapes[currentSubResource]; exists {
+				// Parse Shape2D parameters
+				c.parseShapeProperty(shape, line)
 			}
 		}
 
@@ -1026,56 +1211,49 @@ func (c *TSCNConverter) parsePrefabFile(filePath string) (*PrefabInfo, error) {
 					zIndex, _ := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 32)
 					info.ZIndex = int32(zIndex)
 				}
-			} else if strings.HasPrefix(line, "texture = ExtResource(") {
-				extResourceID := c.extractExtResourceID(line)
-				// Look up in prefab's own ext_resources
-				if extRes, exists := prefabExtResources[extResourceID]; exists {
-					info.Texture = extRes.Path
-				}
+			} else if key, value, ok := splitProperty(line); ok {
+				// Look up textures in prefab's own resources
+				prefabTexture.parseProperty(prefabTextures, key, value)
 			}
 		}
+	}
 
-		// Parse collision properties
-		if currentSection == "collision" {
-			if strings.HasPrefix(line, "position = Vector2(") {
-				info.ColliderPivot = c.extractVector2(line)
-			} else if strings.Contains(line, "polygon = PackedVector2Array(") {
-				// Parse collision polygon points
-				points := c.extractPolygonPoints(line)
-				for _, p := range points {
-					info.ColliderParams = append(info.ColliderParams, p.X, p.Y)
-				}
-			} else if strings.HasPrefix(line, "shape = SubResource(") {
-				// Extract shape SubResource ID and determine shape type
-				shapeID := c.extractSubResourceReference(line)
-				if shapeInfo, exists := prefabShapes[shapeID]; exists {
-					// Convert shape type to collider type
-					switch shapeInfo.Type {
-					case "RectangleShape2D":
-						info.ColliderType = "rect"
-						info.ColliderParams = []float64{
-							shapeInfo.Dimensions.X, shapeInfo.Dimensions.Y,
-						}
-					case "CircleShape2D":
-						info.ColliderType = "circle"
-						// For circle, store radius in ColliderParams
-						info.ColliderParams = []float64{shapeInfo.Dimensions.X}
-					case "CapsuleShape2D":
-						info.ColliderType = "capsule"
-					case "ConvexPolygonShape2D", "ConcavePolygonShape2D":
-						info.ColliderType = "polygon"
-						// Use the points from the shape
-						info.ColliderParams = shapeInfo.Points
-					default:
-						info.ColliderType = "auto"
-					}
-				}
-			}
+	// Build a collider for every collision node, together with its physics body
+	var firstCollisionNode *sceneNode
+	for _, node := range prefabNodes.order {
+		if node.Type != "CollisionShape2D" && node.Type != "CollisionPolygon2D" {
+			continue
+		}
+		if firstCollisionNode == nil {
+			firstCollisionNode = node
 		}
+		info.Colliders = append(info.Colliders, c.newCollider(prefabNodes, node, prefabShapes))
+	}
+	info.Nodes = prefabNodes
+	info.ExtResources = prefabExtResources
+	// The single collider fields describe the first collision node in its parent's space
+	if firstCollisionNode != nil {
+		shape := c.newCollisionShape(firstCollisionNode, prefabShapes)
+		info.ColliderType, info.ColliderParams = shape.Type, shape.Params
+		info.ColliderParent = firstCollisionNode.Parent
+		info.ColliderPivot = shape.Transform.Position
+		info.ColliderRotation = shape.Transform.Rotation
+		info.ColliderScale = shape.Transform.Scale
+		info.ColliderDisabled = shape.Disabled
+		info.ColliderOneWay = shape.OneWay
+		info.ColliderOneWayMargin = shape.OneWayMargin
 	}
 	if info.ColliderParent == "." {
 		info.ColliderPivot.Sub(info.Pivot)
 	}
+	if texture := prefabTexture.resolved(); texture != nil {
+		info.Texture = texture.Path
+		info.TextureRef = texture
+	}
+	info.Hframes = prefabTexture.hframes
+	info.Vframes = prefabTexture.vframes
+	info.Frame = prefabTexture.frame
+	info.Animations = prefabTexture.animations
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading prefab file: %w", err)
 	}
@@ -1095,7 +1273,7 @@ func (c *TSCNConverter) buildPrefabNodes() []PrefabNode {
 			Path:       sprite.Path,
 			Position:   sprite.Position,
 			Scale:      sprite.Scale,
-			Ratation:   sprite.Ratation,
+			Rotation:   sprite.Rotation,
 			Properties: sprite.Properties,
 		}
 
@@ -1105,24 +1283,40 @@ func (c *TSCNConverter) buildPrefabNodes() []PrefabNode {
 			prefab.Name = prefabInfo.Name
 			// Set the texture path from prefab
 			prefab.Texture = prefabInfo.Texture
-
-			prefab.Pivot = prefabInfo.Pivot
+			prefab.TextureRef = prefabInfo.TextureRef
+			prefab.Hframes = prefabInfo.Hframes
+			prefab.Vframes = prefabInfo.Vframes
+			prefab.Frame = prefabInfo.Frame
+			prefab.Animations = prefabInfo.Animations
+
+			// The prefab's nodes are in its local space, which only changes axes
+			prefab.Pivot = outputVector(prefabInfo.Pivot)
 			prefab.ZIndex = prefabInfo.ZIndex
 			prefab.ColliderType = prefabInfo.ColliderType
-			prefab.ColliderPivot = prefabInfo.ColliderPivot
+			prefab.ColliderPivot = outputVector(prefabInfo.ColliderPivot)
 
-			prefab.ColliderParams = prefabInfo.ColliderParams
+			prefab.ColliderParams = outputCoordinates.ColliderParams(prefabInfo.ColliderType, prefabInfo.ColliderParams)
 			prefab.ColliderParent = prefabInfo.ColliderParent
+			prefab.ColliderRotation = outputRotation(prefabInfo.ColliderRotation)
+			prefab.ColliderScale = prefabInfo.ColliderScale
+			prefab.ColliderDisabled = prefabInfo.ColliderDisabled
+			prefab.ColliderOneWay = prefabInfo.ColliderOneWay
+			prefab.ColliderOneWayMargin = prefabInfo.ColliderOneWayMargin
+			prefab.Colliders = outputColliders(prefabInfo.Colliders)
+			if root := prefabInfo.Nodes.nodes["."]; root != nil {
+				prefab.Groups = root.Groups
+				prefab.Metadata = root.metadata()
+			}
 
 			// For scale: if sprite scale is default (1,1), use prefab scale
 			// Otherwise, multiply sprite scale with prefab scale for proper transformation
 			prefab.Scale.X = prefabInfo.Scale.X
 			prefab.Scale.Y = prefabInfo.Scale.Y
 			// Note: Rotation should also consider prefab rotation
-			if prefab.Ratation == 0 {
-				prefab.Ratation = prefabInfo.Rotation
+			if prefab.Rotation == 0 {
+				prefab.Rotation = outputRotation(prefabInfo.Rotation)
 			} else {
-				prefab.Ratation += prefabInfo.Rotation
+				prefab.Rotation += outputRotation(prefabInfo.Rotation)
 			}
 		}
 		if _, exists := prefabMap[prefab.Name]; !exists {
@@ -0,0 +1,419 @@
+package tscnparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Properties holds node property values converted to Go types:
+//   - null -> nil, bool -> bool, int -> int, float -> float64
+//   - String, StringName, NodePath -> string
+//   - Vector2 -> Vec2, Vector2i -> Vec2i, Color -> Color, Rect2 and Rect2i -> Rect2
+//   - arrays, typed arrays and packed arrays -> []any
+//   - dictionaries -> map[string]any
+//   - ExtResource and SubResource -> ResourceRef
+//   - any other value, e.g. Vector3 or Transform2D -> Variant
+//
+// Its JSON encoding keeps these types apart: floats are always written with a
+// fraction or exponent, and the struct types decode back from their field names.
+// Dictionaries whose keys match one of the struct types decode as that type.
+type Properties map[string]any
+
+// Values is a list of typed values, encoded in JSON like Properties
+type Values []any
+
+// ResourceRef references an ExtResource or SubResource of the scene
+type ResourceRef struct {
+	Kind string `json:"resource"` // ExtResource or SubResource
+	ID   string `json:"id"`
+	Path string `json:"path,omitempty"` // res:// path of an ExtResource
+}
+
+// Variant is a Godot value without a dedicated Go type, e.g. Vector3(1, 2, 3)
+type Variant struct {
+	Type string `json:"type"`
+	Args []any  `json:"args"`
+}
+
+// propertyValue converts a raw property value to a typed Go value, keeping the raw text if it cannot be parsed
+func propertyValue(raw string) any {
+	value, err := parseVariant(raw)
+	if err != nil {
+		return raw
+	}
+	return typedValue(value)
+}
+
+// typedValue converts a value returned by parseVariant to the types documented on Properties
+func typedValue(value any) any {
+	switch v := value.(type) {
+	case []any:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = typedValue(item)
+		}
+		return items
+	case map[string]any:
+		dict := make(map[string]any, len(v))
+		for key, item := range v {
+			dict[key] = typedValue(item)
+		}
+		return dict
+	case resourceRef:
+		return ResourceRef{Kind: v.Kind, ID: v.ID}
+	case variantCall:
+		return typedCall(v)
+	}
+	return value
+}
+
+func typedCall(call variantCall) any {
+	args, numeric := call.floatArgs()
+	switch call.Name {
+	case "Vector2":
+		if numeric && len(args) == 2 {
+			return Vec2{X: args[0], Y: args[1]}
+		}
+	case "Vector2i":
+		if numeric && len(args) == 2 {
+			return Vec2i{X: int(args[0]), Y: int(args[1])}
+		}
+	case "Color":
+		if color, ok := parseColorCall(call); ok {
+			return color
+		}
+	case "Rect2", "Rect2i":
+		if numeric && len(args) == 4 {
+			return Rect2{X: args[0], Y: args[1], Width: args[2], Height: args[3]}
+		}
+	case "NodePath", "StringName":
+		if len(call.Args) == 1 {
+			if s, ok := call.Args[0].(string); ok {
+				return s
+			}
+		}
+	case "PackedVector2Array", "PackedVector2iArray":
+		if numeric {
+			items := make([]any, 0, len(args)/2)
+			for i := 0; i+1 < len(args); i += 2 {
+				if call.Name == "PackedVector2iArray" {
+					items = append(items, Vec2i{X: int(args[i]), Y: int(args[i+1])})
+				} else {
+					items = append(items, Vec2{X: args[i], Y: args[i+1]})
+				}
+			}
+			return items
+		}
+	case "PackedColorArray":
+		if numeric {
+			items := make([]any, 0, len(args)/4)
+			for i := 0; i+3 < len(args); i += 4 {
+				items = append(items, Color{R: args[i], G: args[i+1], B: args[i+2], A: args[i+3]})
+			}
+			return items
+		}
+	case "PackedFloat32Array", "PackedFloat64Array":
+		if numeric {
+			items := make([]any, len(args))
+			for i, arg := range args {
+				items[i] = arg
+			}
+			return items
+		}
+	case "PackedInt32Array", "PackedInt64Array", "PackedByteArray", "PackedStringArray":
+		return typedValue(append([]any{}, call.Args...))
+	}
+	return Variant{Type: call.Name, Args: typedValue(append([]any{}, call.Args...)).([]any)}
+}
+
+// parseColorCall converts a parsed Color(r, g, b[, a]) call
+func parseColorCall(call variantCall) (Color, bool) {
+	args, ok := call.floatArgs()
+	if !ok || call.Name != "Color" || len(args) < 3 {
+		return Color{}, false
+	}
+	color := Color{R: args[0], G: args[1], B: args[2], A: 1}
+	if len(args) > 3 {
+		color.A = args[3]
+	}
+	return color, true
+}
+
+// resolveResourcePaths fills the paths of the ExtResource references in a typed value
+func (c *TSCNConverter) resolveResourcePaths(value any) any {
+	return resolveExtResourcePaths(value, c.extResources)
+}
+
+// resolveExtResourcePaths fills the paths of the ExtResource references in a typed value
+// from the ext resources of the file the value was read from
+func resolveExtResourcePaths(value any, extResources map[string]*ExtResource) any {
+	switch v := value.(type) {
+	case ResourceRef:
+		if extRes, exists := extResources[v.ID]; exists && v.Kind == "ExtResource" {
+			v.Path = extRes.Path
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = resolveExtResourcePaths(item, extResources)
+		}
+	case map[string]any:
+		for key, item := range v {
+			v[key] = resolveExtResourcePaths(item, extResources)
+		}
+	case Properties:
+		for key, item := range v {
+			v[key] = resolveExtResourcePaths(item, extResources)
+		}
+	case Variant:
+		resolveExtResourcePaths(v.Args, extResources)
+	}
+	return value
+}
+
+// MarshalJSON encodes the properties so that UnmarshalJSON restores their Go types
+func (p Properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeTypedValue(&buf, map[string]any(p)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalJSON encodes the values so that UnmarshalJSON restores their Go types
+func (v Values) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeTypedValue(&buf, []any(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes values written by MarshalJSON
+func (v *Values) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw []any
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*v = nil
+		return nil
+	}
+	*v = decodeTypedValue(raw).([]any)
+	return nil
+}
+
+// UnmarshalJSON decodes properties written by MarshalJSON
+func (p *Properties) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var raw map[string]any
+	if err := decoder.Decode(&raw); err != nil {
+		return err
+	}
+	if raw == nil {
+		*p = nil
+		return nil
+	}
+	*p = decodeTypedValue(raw).(map[string]any)
+	return nil
+}
+
+func encodeTypedValue(buf *bytes.Buffer, value any) error {
+	switch v := value.(type) {
+	case float64:
+		buf.WriteString(formatTypedFloat(v))
+	case Vec2:
+		fmt.Fprintf(buf, `{"x":%s,"y":%s}`, formatTypedFloat(v.X), formatTypedFloat(v.Y))
+	case Color:
+		fmt.Fprintf(buf, `{"r":%s,"g":%s,"b":%s,"a":%s}`,
+			formatTypedFloat(v.R), formatTypedFloat(v.G), formatTypedFloat(v.B), formatTypedFloat(v.A))
+	case Rect2:
+		fmt.Fprintf(buf, `{"x":%s,"y":%s,"width":%s,"height":%s}`,
+			formatTypedFloat(v.X), formatTypedFloat(v.Y), formatTypedFloat(v.Width), formatTypedFloat(v.Height))
+	case Variant:
+		typeName, _ := json.Marshal(v.Type)
+		fmt.Fprintf(buf, `{"type":%s,"args":`, typeName)
+		if err := encodeTypedValue(buf, v.Args); err != nil {
+			return err
+		}
+		buf.WriteByte('}')
+	case []any:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeTypedValue(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('{')
+		for i, key := range keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			name, _ := json.Marshal(key)
+			buf.Write(name)
+			buf.WriteByte(':')
+			if err := encodeTypedValue(buf, v[key]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		// nil, bool, int, string, Vec2i and ResourceRef encode as usual
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
+
+// formatTypedFloat formats a float so that it does not read back as an integer.
+// JSON has no infinities or NaN, so those are written as a typed value with Godot's
+// name for them, e.g. {"type":"float","value":"inf"}.
+func formatTypedFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return `{"type":"float","value":"inf"}`
+	case math.IsInf(f, -1):
+		return `{"type":"float","value":"inf_neg"}`
+	case math.IsNaN(f):
+		return `{"type":"float","value":"nan"}`
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+func decodeTypedValue(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		return decodeNumber(v)
+	case []any:
+		for i, item := range v {
+			v[i] = decodeTypedValue(item)
+		}
+		return v
+	case map[string]any:
+		if typed, ok := decodeStruct(v); ok {
+			return typed
+		}
+		for key, item := range v {
+			v[key] = decodeTypedValue(item)
+		}
+		return v
+	}
+	return value
+}
+
+func decodeNumber(n json.Number) any {
+	if !strings.ContainsAny(n.String(), ".eE") {
+		if i, err := strconv.Atoi(n.String()); err == nil {
+			return i
+		}
+	}
+	f, _ := n.Float64()
+	return f
+}
+
+// decodeStruct recognizes the JSON objects written for Vec2, Vec2i, Color, Rect2, ResourceRef and Variant
+func decodeStruct(obj map[string]any) (any, bool) {
+	numbers := func(keys ...string) ([]any, bool) {
+		if len(obj) != len(keys) {
+			return nil, false
+		}
+		values := make([]any, len(keys))
+		for i, key := range keys {
+			switch v := obj[key].(type) {
+			case json.Number:
+				values[i] = decodeNumber(v)
+			case map[string]any:
+				f, ok := decodeSpecialFloat(v)
+				if !ok {
+					return nil, false
+				}
+				values[i] = f
+			default:
+				return nil, false
+			}
+		}
+		return values, true
+	}
+	floats := func(values []any) []float64 {
+		result := make([]float64, len(values))
+		for i, value := range values {
+			result[i], _ = toFloat(value)
+		}
+		return result
+	}
+
+	if values, ok := numbers("x", "y"); ok {
+		x, xInt := values[0].(int)
+		y, yInt := values[1].(int)
+		if xInt && yInt {
+			return Vec2i{X: x, Y: y}, true
+		}
+		f := floats(values)
+		return Vec2{X: f[0], Y: f[1]}, true
+	}
+	if values, ok := numbers("r", "g", "b", "a"); ok {
+		f := floats(values)
+		return Color{R: f[0], G: f[1], B: f[2], A: f[3]}, true
+	}
+	if values, ok := numbers("x", "y", "width", "height"); ok {
+		f := floats(values)
+		return Rect2{X: f[0], Y: f[1], Width: f[2], Height: f[3]}, true
+	}
+	if kind, ok := obj["resource"].(string); ok && (len(obj) == 2 || len(obj) == 3) {
+		id, idOK := obj["id"].(string)
+		path, pathOK := obj["path"].(string)
+		if idOK && (len(obj) == 2 || pathOK) {
+			return ResourceRef{Kind: kind, ID: id, Path: path}, true
+		}
+	}
+	if f, ok := decodeSpecialFloat(obj); ok {
+		return f, true
+	}
+	if typeName, ok := obj["type"].(string); ok && len(obj) == 2 {
+		if args, ok := obj["args"].([]any); ok {
+			return Variant{Type: typeName, Args: decodeTypedValue(args).([]any)}, true
+		}
+	}
+	return nil, false
+}
+
+// decodeSpecialFloat recognizes the typed values formatTypedFloat writes for infinities and NaN
+func decodeSpecialFloat(obj map[string]any) (float64, bool) {
+	if len(obj) != 2 || obj["type"] != "float" {
+		return 0, false
+	}
+	switch obj["value"] {
+	case "inf":
+		return math.Inf(1), true
+	case "inf_neg":
+		return math.Inf(-1), true
+	case "nan":
+		return math.NaN(), true
+	}
+	return 0, false
+}
@@ -0,0 +1,48 @@
+package tscnparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestPropertiesSpecialFloats(t *testing.T) {
+	inf, nan := math.Inf(1), math.NaN()
+	properties := Properties{
+		"inf":     inf,
+		"inf_neg": math.Inf(-1),
+		"nan":     nan,
+		"vec2":    Vec2{X: 1, Y: inf},
+		"color":   Color{R: nan, G: 0, B: 0, A: 1},
+		"rect":    Rect2{X: 0, Y: 0, Width: inf, Height: 2},
+		"variant": Variant{Type: "Vector3", Args: []any{0.5, nan, -inf}},
+		"array":   []any{inf, 1, "inf"},
+		// Strings with the same names stay strings
+		"name": "nan",
+	}
+	encoded, err := json.Marshal(properties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`"inf":{"type":"float","value":"inf"}`,
+		`"inf_neg":{"type":"float","value":"inf_neg"}`,
+		`"vec2":{"x":1.0,"y":{"type":"float","value":"inf"}}`,
+		`"name":"nan"`,
+	} {
+		if !strings.Contains(string(encoded), want) {
+			t.Errorf("%s does not contain %s", encoded, want)
+		}
+	}
+
+	var decoded Properties
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	// NaN is not equal to itself, so compare the printed values
+	if got, want := fmt.Sprintf("%#v", decoded), fmt.Sprintf("%#v", properties); got != want {
+		t.Errorf("decoded:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,413 @@
+package tscnparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the version of the JSON layout of MapData, written as its schema_version.
+// It changes when a field is renamed, removed or changes meaning, not when one is added.
+const SchemaVersion = 2
+
+// LegacySchemaVersion is the layout from before schema_version was written. It has the same
+// field names as version 2 but no schema_version, and files written by earlier versions of
+// the parser lack the fields added since.
+const LegacySchemaVersion = 1
+
+// schemaDescriptions describe fields whose meaning is not obvious from their name, keyed
+// by type and JSON field name
+var schemaDescriptions = map[string]string{
+	"MapData.schema_version": "Version of this layout",
+	"MapData.coordinates":    "Coordinate system of every position",
+	"TileMapData.format":     "Format number of the Godot TileMap the map was read from, not the version of this layout",
+	"Layer.tile_data":        "Five integers per tile: source id, x, y, atlas x and atlas y",
+}
+
+// schemaEnums are the values of string types that only take a fixed set of values
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(CoordinateSystem("")): func() []string {
+		names := make([]string, len(CoordinateSystems))
+		for i, system := range CoordinateSystems {
+			names[i] = string(system)
+		}
+		return names
+	}(),
+}
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of the JSON of MapData in a schema
+// version. Objects do not allow fields the schema does not list.
+func JSONSchema(version int) ([]byte, error) {
+	schema, err := mapDataSchema(version)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// mapDataSchema builds the schema of a version
+func mapDataSchema(version int) (map[string]any, error) {
+	if version != SchemaVersion && version != LegacySchemaVersion {
+		return nil, fmt.Errorf("unknown schema version %d", version)
+	}
+	builder := &schemaBuilder{defs: map[string]any{}}
+	root := builder.schema(reflect.TypeOf(MapData{}))
+	mapData := builder.defs["MapData"].(map[string]any)
+	mapData["properties"].(map[string]any)["schema_version"].(map[string]any)["const"] = version
+	if version == LegacySchemaVersion {
+		// Legacy files leave out the version and the fields added after them, and early ones
+		// write the untagged tiles of the layers
+		for _, def := range builder.defs {
+			delete(def.(map[string]any), "required")
+		}
+		layer := builder.defs["Layer"].(map[string]any)["properties"].(map[string]any)
+		layer["Tiles"] = map[string]any{"type": []string{"array", "null"}}
+	}
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = fmt.Sprintf("MapData, schema version %d", version)
+	root["$defs"] = builder.defs
+	return root, nil
+}
+
+// schemaBuilder generates schemas from Go types the way encoding/json encodes them, with
+// one definition per struct type
+type schemaBuilder struct {
+	defs map[string]any
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.String:
+		if values, ok := schemaEnums[t]; ok {
+			return map[string]any{"type": "string", "enum": values}
+		}
+		return map[string]any{"type": "string"}
+	case reflect.Pointer:
+		return map[string]any{"anyOf": []any{b.schema(t.Elem()), map[string]any{"type": "null"}}}
+	case reflect.Slice:
+		// A nil slice is written as null unless its field is omitempty
+		return map[string]any{"type": []string{"array", "null"}, "items": b.schema(t.Elem())}
+	case reflect.Array:
+		return map[string]any{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": []string{"object", "null"}, "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		return b.ref(t)
+	}
+	// Interfaces, e.g. property values, take any value
+	return map[string]any{}
+}
+
+// ref returns a reference to the definition of a struct type, adding it if needed
+func (b *schemaBuilder) ref(t reflect.Type) map[string]any {
+	name := t.Name()
+	ref := map[string]any{"$ref": "#/$defs/" + name}
+	if _, ok := b.defs[name]; ok {
+		return ref
+	}
+	def := map[string]any{"type": "object", "additionalProperties": false}
+	b.defs[name] = def // before the fields, for recursive types
+	properties := map[string]any{}
+	required := []string{}
+	for _, field := range binaryFields(t) {
+		schema := b.schema(t.FieldByIndex(field.index).Type)
+		if description, ok := schemaDescriptions[name+"."+field.name]; ok {
+			schema["description"] = description
+		}
+		properties[field.name] = schema
+		if !field.omitEmpty {
+			required = append(required, field.name)
+		}
+	}
+	def["properties"] = properties
+	def["required"] = required
+	return ref
+}
+
+// SchemaError is a place where a JSON document does not match the schema
+type SchemaError struct {
+	Path    string // JSON Pointer of the value
+	Message string
+}
+
+func (e SchemaError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+	return path + ": " + e.Message
+}
+
+// ValidateJSON checks a map JSON document against the schema of the version in its
+// schema_version, LegacySchemaVersion when it has none. The error is set when the document
+// is not JSON or has an unknown version; mismatches are returned as SchemaErrors.
+func ValidateJSON(document []byte) ([]SchemaError, error) {
+	var value any
+	if err := json.Unmarshal(document, &value); err != nil {
+		return nil, err
+	}
+	version := LegacySchemaVersion
+	if object, ok := value.(map[string]any); ok {
+		if number, ok := object["schema_version"].(float64); ok {
+			version = int(number)
+		}
+	}
+	schema, err := mapDataSchema(version)
+	if err != nil {
+		return nil, err
+	}
+	// Validate against the schema as it is written to a file
+	encoded, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		return nil, err
+	}
+	v := &schemaValidator{defs: decoded["$defs"].(map[string]any)}
+	v.validate(decoded, value, "")
+	return v.errors, nil
+}
+
+// schemaValidator checks values against the keywords the schemaBuilder generates
+type schemaValidator struct {
+	defs   map[string]any
+	errors []SchemaError
+}
+
+func (v *schemaValidator) fail(path, format string, args ...any) {
+	v.errors = append(v.errors, SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *schemaValidator) validate(schema map[string]any, value any, path string) {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := v.defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		if !ok {
+			v.fail(path, "unknown reference %s", ref)
+			return
+		}
+		v.validate(def, value, path)
+	}
+	if branches, ok := schema["anyOf"].([]any); ok {
+		// Report the branch that came closest to matching
+		var closest []SchemaError
+		for i, branch := range branches {
+			branchValidator := &schemaValidator{defs: v.defs}
+			branchValidator.validate(branch.(map[string]any), value, path)
+			if len(branchValidator.errors) == 0 {
+				closest = nil
+				break
+			}
+			if i == 0 || len(branchValidator.errors) < len(closest) {
+				closest = branchValidator.errors
+			}
+		}
+		v.errors = append(v.errors, closest...)
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(value, constant) {
+		v.fail(path, "is %s, want %s", jsonText(value), jsonText(constant))
+	}
+	if values, ok := schema["enum"].([]any); ok {
+		found := false
+		for _, allowed := range values {
+			found = found || reflect.DeepEqual(value, allowed)
+		}
+		if !found {
+			v.fail(path, "is %s, want one of %s", jsonText(value), jsonText(values))
+		}
+	}
+	if types, ok := schema["type"]; ok && !v.checkType(types, value, path) {
+		return
+	}
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if number, ok := value.(float64); ok && number < minimum {
+			v.fail(path, "is %v, want at least %v", number, minimum)
+		}
+	}
+	switch value := value.(type) {
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range value {
+				v.validate(items, item, path+"/"+strconv.Itoa(i))
+			}
+		}
+	case map[string]any:
+		v.validateObject(schema, value, path)
+	}
+}
+
+// checkType reports whether a value has one of the types of a type keyword
+func (v *schemaValidator) checkType(types any, value any, path string) bool {
+	var names []string
+	switch types := types.(type) {
+	case string:
+		names = []string{types}
+	case []any:
+		for _, name := range types {
+			names = append(names, name.(string))
+		}
+	}
+	actual := jsonType(value)
+	for _, name := range names {
+		if name == actual || name == "number" && actual == "integer" {
+			return true
+		}
+	}
+	v.fail(path, "is %s, want %s", actual, strings.Join(names, " or "))
+	return false
+}
+
+func (v *schemaValidator) validateObject(schema map[string]any, value map[string]any, path string) {
+	properties, _ := schema["properties"].(map[string]any)
+	if required, ok := schema["required"].([]any); ok {
+		for _, name := range required {
+			if _, ok := value[name.(string)]; !ok {
+				v.fail(path, "missing field %q", name)
+			}
+		}
+	}
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fieldPath := path + "/" + jsonPointerEscaper.Replace(name)
+		if property, ok := properties[name].(map[string]any); ok {
+			v.validate(property, value[name], fieldPath)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				v.fail(fieldPath, "unknown field")
+			}
+		case map[string]any:
+			v.validate(additional, value[name], fieldPath)
+		}
+	}
+}
+
+// jsonPointerEscaper escapes a field name for a JSON Pointer
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// jsonType is the JSON Schema type of a decoded JSON value
+func jsonType(value any) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if value == math.Trunc(value) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	}
+	return "object"
+}
+
+// jsonText formats a decoded JSON value for an error message
+func jsonText(value any) string {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
+
+// LegacyMapData returns a value that encodes the data as JSON in LegacySchemaVersion, the
+// layout without schema_version, for consumers that do not accept the field. The fields
+// version 1 wrote keep the values it gave them, see legacyValues.
+func LegacyMapData(data *MapData) any {
+	return &legacyMapData{MapData: legacyValues(data)}
+}
+
+// legacyValues returns a copy of the data with the values version 1 wrote: rotations,
+// pivots, collision points and collider params as in Godot, the collider pivots of
+// decorators Y-up, and collider types it did not know as auto without params. Positions
+// stay in the coordinates of the data, which are those of version 1 in the default Y-up
+// system, and the fields added since keep their current values.
+func legacyValues(data *MapData) *MapData {
+	c := data.Coordinates
+	legacy := *data
+	legacy.TileMap.TileSet.Sources = slices.Clone(data.TileMap.TileSet.Sources)
+	for i := range legacy.TileMap.TileSet.Sources {
+		source := &legacy.TileMap.TileSet.Sources[i]
+		source.Tiles = slices.Clone(source.Tiles)
+		for j := range source.Tiles {
+			physics := &source.Tiles[j].Physics
+			if physics.CollisionPoints == nil {
+				continue
+			}
+			points := make([]Vec2, len(physics.CollisionPoints))
+			for k, point := range physics.CollisionPoints {
+				points[k] = c.Vector(point)
+			}
+			physics.CollisionPoints = points
+		}
+	}
+
+	legacy.Decorators = slices.Clone(data.Decorators)
+	for i := range legacy.Decorators {
+		decorator := &legacy.Decorators[i]
+		decorator.Rotation = c.Rotation(decorator.Rotation)
+		decorator.Pivot = c.Vector(decorator.Pivot)
+		decorator.ColliderPivot = Coordinates{System: CoordinatesYUp}.Vector(c.Vector(decorator.ColliderPivot))
+		decorator.ColliderType, decorator.ColliderParams = legacyCollider(c, decorator.ColliderType, decorator.ColliderParams)
+	}
+	legacy.Sprites = slices.Clone(data.Sprites)
+	for i := range legacy.Sprites {
+		legacy.Sprites[i].Rotation = c.Rotation(legacy.Sprites[i].Rotation)
+	}
+	legacy.Prefabs = slices.Clone(data.Prefabs)
+	for i := range legacy.Prefabs {
+		prefab := &legacy.Prefabs[i]
+		prefab.Rotation = c.Rotation(prefab.Rotation)
+		prefab.Pivot = c.Vector(prefab.Pivot)
+		prefab.ColliderPivot = c.Vector(prefab.ColliderPivot)
+		prefab.ColliderType, prefab.ColliderParams = legacyCollider(c, prefab.ColliderType, prefab.ColliderParams)
+	}
+	return &legacy
+}
+
+// legacyCollider returns the collider type and params version 1 wrote for a collider.
+// It had no params for capsules, wrote concave polygons as polygons and knew no other
+// types.
+func legacyCollider(c Coordinates, colliderType string, params []float64) (string, []float64) {
+	params = c.ColliderParams(colliderType, params)
+	switch colliderType {
+	case "", ColliderAuto, ColliderRect, ColliderCircle, ColliderPolygon:
+		return colliderType, params
+	case ColliderCapsule:
+		return colliderType, nil
+	case ColliderConcave:
+		return ColliderPolygon, params
+	}
+	return ColliderAuto, nil
+}
+
+// legacyMapData hides the schema_version of MapData behind a shallower field of the same
+// JSON name, which is always nil
+type legacyMapData struct {
+	SchemaVersion *struct{} `json:"schema_version,omitempty"`
+	*MapData
+}
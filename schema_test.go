@@ -0,0 +1,146 @@
+package tscnparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSchemaFile(t *testing.T) {
+	schema, err := JSONSchema(SchemaVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.ReadFile("mapdata.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(bytes.TrimSpace(file), schema) {
+		t.Error("mapdata.schema.json is out of date, regenerate it with the test program's -schema flag")
+	}
+}
+
+func TestValidateJSON(t *testing.T) {
+	data := parseCoordinateScene(t, CoordinatesYUp)
+	if data.SchemaVersion != SchemaVersion {
+		t.Errorf("schema_version = %d", data.SchemaVersion)
+	}
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs, err := ValidateJSON(encoded); err != nil || len(errs) != 0 {
+		t.Fatalf("valid map: %v %v", errs, err)
+	}
+
+	tests := []struct {
+		name, old, new, path string
+	}{
+		{"unknown field", `"name":"Spawn"`, `"name":"Spawn","speed":1`, "/markers/0/speed"},
+		{"misspelled field", `"rotation":-0.5`, `"ratation":-0.5`, "/sprites/0/ratation"},
+		{"wrong type", `"name":"Spawn"`, `"name":3`, "/markers/0/name"},
+		{"fraction", `"format":2`, `"format":2.5`, "/tilemap/format"},
+		{"negative", `"collision_layer":1`, `"collision_layer":-1`, "/prefabs/0/colliders/0/collision_layer"},
+		{"enum", `"system":"y-up"`, `"system":"up"`, "/coordinates/system"},
+		{"missing", `"tilemap":`, `"map":`, "/map"},
+	}
+	for _, tt := range tests {
+		document := strings.Replace(string(encoded), tt.old, tt.new, 1)
+		if document == string(encoded) {
+			t.Fatalf("%s: %s is not in the map", tt.name, tt.old)
+		}
+		errs, err := ValidateJSON([]byte(document))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		found := false
+		for _, e := range errs {
+			found = found || e.Path == tt.path
+		}
+		if !found {
+			t.Errorf("%s: errors %v, want one at %s", tt.name, errs, tt.path)
+		}
+	}
+
+	if _, err := ValidateJSON([]byte(`{"schema_version":99}`)); err == nil {
+		t.Error("unknown version: expected an error")
+	}
+}
+
+// baselineCoordinates is the coordinate scene converted by the parser before schema_version
+// was written, with an offset of (48, 32)
+const baselineCoordinates = "testdata/baseline_coordinates.json"
+
+// compareBaseline reports the values of a baseline JSON value that a current one lacks or
+// has differently. The null of a field the baseline wrote may be left out.
+func compareBaseline(t *testing.T, pointer string, current, baseline any) {
+	t.Helper()
+	switch baseline := baseline.(type) {
+	case map[string]any:
+		object, ok := current.(map[string]any)
+		if !ok {
+			t.Errorf("%s = %s, baseline %s", pointer, jsonText(current), jsonText(baseline))
+			return
+		}
+		for name, value := range baseline {
+			if _, ok := object[name]; ok || value != nil {
+				compareBaseline(t, pointer+"/"+name, object[name], value)
+			}
+		}
+	case []any:
+		array, ok := current.([]any)
+		if !ok || len(array) != len(baseline) {
+			t.Errorf("%s = %s, baseline %s", pointer, jsonText(current), jsonText(baseline))
+			return
+		}
+		for i, value := range baseline {
+			compareBaseline(t, fmt.Sprintf("%s/%d", pointer, i), array[i], value)
+		}
+	default:
+		if !reflect.DeepEqual(current, baseline) {
+			t.Errorf("%s = %s, baseline %s", pointer, jsonText(current), jsonText(baseline))
+		}
+	}
+}
+
+func TestLegacyMapData(t *testing.T) {
+	baseline, err := os.ReadFile(baselineCoordinates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs, err := ValidateJSON(baseline); err != nil || len(errs) != 0 {
+		t.Errorf("baseline map: %v %v", errs, err)
+	}
+
+	data := parseCoordinateScene(t, CoordinatesYUp)
+	ConvertToTilemap(data)
+	legacy, err := json.Marshal(LegacyMapData(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs, err := ValidateJSON(legacy); err != nil || len(errs) != 0 {
+		t.Errorf("legacy map: %v %v", errs, err)
+	}
+
+	// The legacy map has every field of the baseline with the same value, and no version
+	var old, current map[string]any
+	if err := json.Unmarshal(baseline, &old); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(legacy, &current); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := current["schema_version"]; ok {
+		t.Error("legacy map has a schema_version")
+	}
+	compareBaseline(t, "", current, old)
+
+	// The data itself keeps the values of the current version
+	if want := parseCoordinateScene(t, CoordinatesYUp); !reflect.DeepEqual(data.Prefabs, want.Prefabs) || !reflect.DeepEqual(data.TileMap.TileSet, want.TileMap.TileSet) {
+		t.Error("LegacyMapData changed the data")
+	}
+}
@@ -0,0 +1,375 @@
+package tscnparser
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Script describes a GDScript file attached to nodes of the scene
+type Script struct {
+	Path      string         `json:"path"`
+	ClassName string         `json:"class_name,omitempty"`
+	Extends   string         `json:"extends,omitempty"` // class name or quoted script path
+	Exports   []ScriptExport `json:"exports,omitempty"`
+	Defaults  Properties     `json:"defaults,omitempty"` // typed default values of the exports that have a literal default
+}
+
+// ScriptExport is a variable declared with an @export annotation
+type ScriptExport struct {
+	Name        string `json:"name"`
+	Type        string `json:"type,omitempty"`         // declared or inferred type, e.g. float, Vector2 or Array[int]
+	Hint        string `json:"hint"`                   // the annotation, e.g. @export_range(0, 10)
+	DefaultExpr string `json:"default_expr,omitempty"` // default value source when it is not a literal, e.g. Vector2.RIGHT * 2
+}
+
+// NodeScript is the script attached to an exported node together with its exported variables
+type NodeScript struct {
+	Script  string     `json:"script,omitempty"`  // res:// path of the attached script
+	Exports Properties `json:"exports,omitempty"` // exported variables: overrides typed after the script, then defaults
+}
+
+// scriptConstants are the named constants accepted as literal defaults
+var scriptConstants = map[string]any{
+	"Vector2.ZERO":  Vec2{},
+	"Vector2.ONE":   Vec2{X: 1, Y: 1},
+	"Vector2.UP":    Vec2{Y: -1},
+	"Vector2.DOWN":  Vec2{Y: 1},
+	"Vector2.LEFT":  Vec2{X: -1},
+	"Vector2.RIGHT": Vec2{X: 1},
+	"Vector2i.ZERO": Vec2i{},
+	"Vector2i.ONE":  Vec2i{X: 1, Y: 1},
+	"Color.WHITE":   Color{R: 1, G: 1, B: 1, A: 1},
+	"Color.BLACK":   Color{A: 1},
+}
+
+var (
+	scriptClassNameRe  = regexp.MustCompile(`(?:^|\s)class_name\s+(\w+)`)
+	scriptExtendsRe    = regexp.MustCompile(`(?:^|\s)extends\s+("[^"]*"|[\w.]+)`)
+	scriptAnnotationRe = regexp.MustCompile(`^@(\w+)`)
+	scriptVarRe        = regexp.MustCompile(`^var\s+(\w+)\s*(?::\s*([\w.\[\], ]*?))?\s*(?:(:?=)\s*(.+))?$`)
+)
+
+// parseScriptFile reads the class_name, extends and @export variables of a GDScript file
+func parseScriptFile(resPath, filePath string) (*Script, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open script %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	script := &Script{Path: resPath}
+	scanner := bufio.NewScanner(file)
+	var pendingHint string
+	for scanner.Scan() {
+		// Only top level declarations can be exported
+		raw := scanner.Text()
+		if raw == "" || raw[0] == ' ' || raw[0] == '\t' {
+			continue
+		}
+		line := strings.TrimSpace(stripScriptComment(raw))
+		if line == "" {
+			continue
+		}
+		if matches := scriptClassNameRe.FindStringSubmatch(line); matches != nil && !strings.HasPrefix(line, "@") {
+			script.ClassName = matches[1]
+		}
+		if matches := scriptExtendsRe.FindStringSubmatch(line); matches != nil && !strings.HasPrefix(line, "@") {
+			script.Extends = matches[1]
+		}
+
+		// Annotations may precede the variable on the same line or on their own lines
+		hint := pendingHint
+		for strings.HasPrefix(line, "@") {
+			name := scriptAnnotationRe.FindStringSubmatch(line)
+			if name == nil {
+				break
+			}
+			end := len(name[0])
+			if end < len(line) && line[end] == '(' {
+				end += annotationArgsLength(line[end:])
+			}
+			if strings.HasPrefix(name[1], "export") && !isExportGrouping(name[1]) {
+				hint = line[:end]
+			}
+			line = strings.TrimSpace(line[end:])
+		}
+		if line == "" {
+			pendingHint = hint
+			continue
+		}
+		pendingHint = ""
+		if hint == "" {
+			continue
+		}
+		matches := scriptVarRe.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		export := ScriptExport{Name: matches[1], Type: strings.TrimSpace(matches[2]), Hint: hint}
+		if expr := strings.TrimSpace(matches[4]); expr != "" {
+			value, ok := scriptConstants[expr]
+			if !ok {
+				if parsed, err := parseVariant(expr); err == nil {
+					value, ok = typedValue(parsed), true
+				}
+			}
+			if ok {
+				if script.Defaults == nil {
+					script.Defaults = make(Properties)
+				}
+				if export.Type == "" {
+					export.Type = variantTypeName(value)
+				}
+				// e.g. "var speed: float = 2" holds a float
+				if typed, fits := coerceExport(export.Type, value); fits {
+					value = typed
+				}
+				script.Defaults[export.Name] = value
+			} else {
+				export.DefaultExpr = expr
+			}
+		}
+		script.Exports = append(script.Exports, export)
+	}
+	return script, scanner.Err()
+}
+
+// stripScriptComment removes a trailing # comment outside of string literals
+func stripScriptComment(line string) string {
+	inString := byte(0)
+	for i := 0; i < len(line); i++ {
+		switch ch := line[i]; {
+		case inString != 0 && ch == '\\':
+			i++
+		case inString != 0 && ch == inString:
+			inString = 0
+		case inString == 0 && (ch == '"' || ch == '\''):
+			inString = ch
+		case inString == 0 && ch == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// annotationArgsLength returns the length of the parenthesized annotation arguments at the start of s
+func annotationArgsLength(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(s)
+}
+
+func isExportGrouping(annotation string) bool {
+	return annotation == "export_group" || annotation == "export_subgroup" || annotation == "export_category"
+}
+
+// variantTypeName returns the GDScript type of a typed value, or "" if it is unknown
+func variantTypeName(value any) string {
+	switch v := value.(type) {
+	case bool:
+		return "bool"
+	case int:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "String"
+	case Vec2:
+		return "Vector2"
+	case Vec2i:
+		return "Vector2i"
+	case Color:
+		return "Color"
+	case Rect2:
+		return "Rect2"
+	case []any:
+		return "Array"
+	case map[string]any:
+		return "Dictionary"
+	case Variant:
+		return v.Type
+	}
+	return ""
+}
+
+// coerceExport converts a value to the declared type of an export, reporting false if it does not fit
+func coerceExport(exportType string, value any) (any, bool) {
+	if exportType == "" || value == nil {
+		return value, true
+	}
+	switch exportType {
+	case "float":
+		if n, ok := toFloat(value); ok {
+			return n, true
+		}
+	case "int":
+		switch n := value.(type) {
+		case int:
+			return n, true
+		case float64:
+			if n == float64(int(n)) {
+				return int(n), true
+			}
+		}
+	case "bool", "String", "StringName", "NodePath", "Vector2", "Vector2i", "Color", "Rect2":
+		if exportType == "Vector2" {
+			if v, ok := value.(Vec2i); ok {
+				return Vec2{X: float64(v.X), Y: float64(v.Y)}, true
+			}
+		}
+		name := variantTypeName(value)
+		if name == exportType || (name == "String" && (exportType == "StringName" || exportType == "NodePath")) {
+			return value, true
+		}
+	case "Dictionary":
+		_, ok := value.(map[string]any)
+		return value, ok
+	default:
+		if exportType == "Array" || strings.HasPrefix(exportType, "Array[") || strings.HasPrefix(exportType, "Packed") {
+			_, ok := value.([]any)
+			return value, ok
+		}
+		// Enums are stored as integers, resources and nodes as references or paths
+		return value, true
+	}
+	return value, false
+}
+
+// getScript returns the parsed script for a res:// path, or nil if scripts are not read
+func (c *TSCNConverter) getScript(resPath string) *Script {
+	if !parseScripts || resPath == "" {
+		return nil
+	}
+	if script, exists := c.scripts[resPath]; exists {
+		return script
+	}
+	var script *Script
+	if filePath := resolveResourcePath(resPath); filePath == "" {
+		c.warnings = append(c.warnings, fmt.Sprintf("script %s: project root not set", resPath))
+	} else if parsed, err := parseScriptFile(resPath, filePath); err != nil {
+		c.warnings = append(c.warnings, err.Error())
+	} else {
+		script = parsed
+	}
+	c.scripts[resPath] = script
+	return script
+}
+
+// nodeScript returns the script of a node, or of the root of its instanced scene, with the
+// node's exported variables. Overrides that do not fit their declared type are reported as warnings
+// and replaced by the default.
+func (c *TSCNConverter) nodeScript(node *sceneNode) NodeScript {
+	if result, exists := c.nodeScripts[node]; exists {
+		return result
+	}
+	var result NodeScript
+	// Values set in the instanced scene apply first, then the node's own. The ExtResource ids
+	// of each layer belong to the file it was read from.
+	type layer struct {
+		node         *sceneNode
+		extResources map[string]*ExtResource
+	}
+	layers := []layer{{node, c.extResources}}
+	if value, exists := node.Properties["script"]; exists {
+		result.Script = c.scriptPath(value, c.extResources)
+	}
+	if root := c.instanceRoot(node); root != nil {
+		info := c.prefabCache[c.extResources[node.Instance].Path]
+		layers = []layer{{root, info.ExtResources}, {node, c.extResources}}
+		if result.Script == "" {
+			result.Script = c.scriptPath(root.Properties["script"], info.ExtResources)
+		}
+	}
+	if script := c.getScript(result.Script); script != nil && len(script.Exports) > 0 {
+		result.Exports = make(Properties)
+		for _, export := range script.Exports {
+			value, set := script.Defaults[export.Name]
+			for _, layer := range layers {
+				raw, exists := layer.node.Properties[export.Name]
+				if !exists {
+					continue
+				}
+				override := resolveExtResourcePaths(propertyValue(raw), layer.extResources)
+				if typed, ok := coerceExport(export.Type, override); ok {
+					value, set = typed, true
+				} else {
+					c.warnings = append(c.warnings, fmt.Sprintf("node %s: %s expects %s, got %s", node.Path, export.Name, export.Type, raw))
+				}
+			}
+			if set {
+				result.Exports[export.Name] = value
+			}
+		}
+	}
+	c.nodeScripts[node] = result
+	return result
+}
+
+// scriptPath resolves a script = ExtResource("id") value against the given ext resources
+func (c *TSCNConverter) scriptPath(value string, extResources map[string]*ExtResource) string {
+	parsed, err := parseVariant(strings.TrimSpace(value))
+	if err != nil {
+		return ""
+	}
+	if ref, ok := parsed.(resourceRef); ok && ref.Kind == "ExtResource" {
+		if extRes, exists := extResources[ref.ID]; exists {
+			return extRes.Path
+		}
+	}
+	return ""
+}
+
+// resolveScripts attaches scripts to the decorators, instanced sprites and prefabs, types the
+// script variables among the sprite property overrides, and lists the parsed scripts
+func (c *TSCNConverter) resolveScripts(data *MapData) {
+	for i := range data.Decorators {
+		if node, exists := c.nodes.nodes[data.Decorators[i].NodePath]; exists {
+			data.Decorators[i].NodeScript = c.nodeScript(node)
+		}
+	}
+	for i := range data.Sprites {
+		sprite := &data.Sprites[i]
+		if node, exists := c.nodes.nodes[joinNodePath(sprite.Parent, sprite.Name)]; exists {
+			sprite.NodeScript = c.nodeScript(node)
+			for key := range sprite.Properties {
+				if value, exists := sprite.Exports[key]; exists {
+					sprite.Properties[key] = value
+				}
+			}
+		}
+	}
+	for i := range data.Prefabs {
+		prefab := &data.Prefabs[i]
+		if info, exists := c.prefabCache[prefab.Path]; exists && info != nil && info.Nodes != nil {
+			if root := info.Nodes.nodes["."]; root != nil {
+				prefab.Script = c.scriptPath(root.Properties["script"], info.ExtResources)
+				c.getScript(prefab.Script)
+			}
+		}
+	}
+
+	var paths []string
+	for path, script := range c.scripts {
+		if script != nil {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		data.Scripts = append(data.Scripts, *c.scripts[path])
+	}
+}
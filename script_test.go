@@ -0,0 +1,90 @@
+package tscnparser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestInstancedScriptExports(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"door.gd": "extends Node2D\n@export var icon: Texture2D\n@export var sound: AudioStream\n",
+		// The ids of the prefab's own ext resources overlap those of the main scene
+		"door.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="Script" path="res://door.gd" id="1_s"]
+[ext_resource type="Texture2D" path="res://icons/door.png" id="2_t"]
+
+[node name="Door" type="Node2D"]
+script = ExtResource("1_s")
+icon = ExtResource("2_t")
+metadata/badge = ExtResource("2_t")
+`,
+		"main.tscn": `[gd_scene load_steps=3 format=3]
+
+[ext_resource type="PackedScene" path="res://scenes/door.tscn" id="1_s"]
+[ext_resource type="Texture2D" path="res://icons/wall.png" id="2_t"]
+[ext_resource type="AudioStream" path="res://sounds/creak.ogg" id="3_a"]
+[ext_resource type="Script" path="res://door.gd" id="4_g"]
+
+[sub_resource type="TileSetAtlasSource" id="TileSetAtlasSource_1"]
+texture_region_size = Vector2i(16, 16)
+0:0/0 = 0
+
+[sub_resource type="TileSet" id="TileSet_1"]
+sources/0 = SubResource("TileSetAtlasSource_1")
+
+[node name="Root" type="Node2D"]
+
+[node name="TileMap" type="TileMap" parent="."]
+tile_set = SubResource("TileSet_1")
+format = 2
+layer_0/name = "ground"
+layer_0/tile_data = PackedInt32Array(0, 0, 0, 65537, 0, 0)
+
+[node name="Door" parent="." instance=ExtResource("1_s")]
+script = ExtResource("4_g")
+sound = ExtResource("3_a")
+metadata/kind = "wood"
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		SetPrefabsDir("")
+		SetProjectRoot("")
+		SetParseScripts(false)
+	})
+	SetPrefabsDir(dir)
+	SetProjectRoot(dir)
+	SetParseScripts(true)
+	data, err := Parse(filepath.Join(dir, "main.tscn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Sprites) != 1 {
+		t.Fatalf("%d sprites", len(data.Sprites))
+	}
+	door := data.Sprites[0]
+	if door.Script != "res://door.gd" {
+		t.Errorf("script = %q", door.Script)
+	}
+	// The script and metadata are not repeated among the properties
+	if len(door.Properties) != 1 || door.Metadata["kind"] != "wood" {
+		t.Errorf("properties = %v, metadata = %v", door.Properties, door.Metadata)
+	}
+	// The metadata of the prefab's root refers to the prefab's own ext resources
+	if badge, ok := door.Metadata["badge"].(ResourceRef); !ok || badge.Path != "res://icons/door.png" {
+		t.Errorf("inherited metadata = %#v", door.Metadata["badge"])
+	}
+	// The icon is set in the prefab, the sound by the instance
+	for name, want := range map[string]string{"icon": "res://icons/door.png", "sound": "res://sounds/creak.ogg"} {
+		if ref, ok := door.Exports[name].(ResourceRef); !ok || ref.Path != want {
+			t.Errorf("%s = %#v, want a reference to %s", name, door.Exports[name], want)
+		}
+	}
+}
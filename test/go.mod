@@ -4,4 +4,6 @@ go 1.21
 
 require github.com/JiepengTan/tscn_parser v0.0.1
 
+require golang.org/x/image v0.23.0 // indirect
+
 replace github.com/JiepengTan/tscn_parser => ..
@@ -10,17 +10,12 @@ import (
 	"strings"
 
 	tscnparser "github.com/JiepengTan/tscn_parser"
+	"github.com/JiepengTan/tscn_parser/codegen"
+	"github.com/JiepengTan/tscn_parser/godot"
+	"github.com/JiepengTan/tscn_parser/ldtk"
+	"github.com/JiepengTan/tscn_parser/tiled"
 )
 
-type Replacement struct {
-	Old string `json:"old"`
-	New string `json:"new"`
-}
-
-type ReplacementConfig struct {
-	Replacements []Replacement `json:"replacements"`
-}
-
 func convertToTilemap(data *tscnparser.MapData) {
 
 }
@@ -29,12 +24,58 @@ func main() {
 	var inputFile = flag.String("input", "", "Input TSCN file path")
 	var outputFile = flag.String("output", "", "Output JSON file path")
 	var tileSize = flag.Int("tilesize", 16, "Tile size")
-	var replacementsFile = flag.String("replacements", "", "JSON file containing replacement rules")
+	var replacementsFile = flag.String("replacements", "", "JSON file containing asset path rewriting rules")
+	var pathReport = flag.String("pathReport", "", "With -replacements, write the list of rewritten asset paths to this JSON file")
 	var offsetX = flag.Int("offsetx", 0, "X offset")
 	var offsetY = flag.Int("offsety", 0, "Y offset")
+	var coords = flag.String("coords", string(tscnparser.CoordinatesYUp), "Output coordinate system: y-up, godot, y-up-bottom or centered")
 	var prefabsDir = flag.String("prefabs", "", "Directory containing prefab .tscn files")
+	var projectDir = flag.String("project", "", "Godot project directory used to read image sizes")
+	var pathTolerance = flag.Float64("pathtolerance", 1, "Max distance in pixels between a Path2D curve and its baked polyline (0 disables baking)")
+	var generateGo = flag.Bool("generateGo", false, "Also generate Go source (.go.txt) declaring the map data")
+	var goPackage = flag.String("goPackage", "main", "Package name of the generated Go source")
+	var goVar = flag.String("goVar", "MapData", "Variable name of the generated Go source")
+	var goPrefix = flag.String("goPrefix", "tscn", "Prefix of the types declared in the generated Go source")
+	var goImportTypes = flag.Bool("goImportTypes", false, "Import the parser's types in the generated Go source instead of declaring them")
+	var exportTiled = flag.Bool("tiled", false, "Also export a Tiled map (.tmx with .tsx tilesets, and .tmj)")
+	var tiledEncoding = flag.String("tiledEncoding", tiled.EncodingCSV, "Tile layer encoding of the Tiled map: csv, base64, base64-zlib or base64-gzip")
+	var exportLDtk = flag.Bool("ldtk", false, "Also export an LDtk project (.ldtk)")
+	var exportBinary = flag.Bool("binary", false, "Also write the map in the compact binary format (.tmb)")
+	var binaryCompression = flag.String("binaryCompression", "deflate", "Compression of the binary map: none or deflate")
+	var chunkSize = flag.Int("chunkSize", 0, "Write the tiles in chunks of this many tiles square to <output>_chunks, with a manifest (0 disables chunking)")
+	var scripts = flag.Bool("scripts", false, "Read attached GDScript files for class_name, extends and @export variables (needs -project)")
+	var fromJSON = flag.String("fromJSON", "", "Generate a Godot scene from a map JSON (or .tmb) file instead of converting a TSCN file")
+	var tileMapNode = flag.Bool("tileMapNode", false, "With -fromJSON, write the layers to a TileMap node (Godot 4.0-4.2) instead of TileMapLayer nodes")
+	var legacySchema = flag.Bool("legacySchema", false, "Write the JSON in schema version 1, without schema_version")
+	var schemaFile = flag.String("schema", "", "Write the JSON Schema of the map JSON to this file instead of converting a TSCN file")
+	var validateFile = flag.String("validate", "", "Check a map JSON file against the JSON Schema of its schema_version instead of converting a TSCN file")
 	flag.Parse()
 
+	if *schemaFile != "" {
+		schema, err := tscnparser.JSONSchema(tscnparser.SchemaVersion)
+		if err != nil {
+			log.Fatalf("Error generating schema: %v", err)
+		}
+		if err := os.WriteFile(*schemaFile, append(schema, '\n'), 0644); err != nil {
+			log.Fatalf("Error writing schema: %v", err)
+		}
+		fmt.Printf("Wrote the JSON Schema of version %d to %s\n", tscnparser.SchemaVersion, *schemaFile)
+		return
+	}
+
+	if *validateFile != "" {
+		validate(*validateFile)
+		return
+	}
+
+	if *fromJSON != "" {
+		if *outputFile == "" {
+			*outputFile = strings.TrimSuffix(*fromJSON, filepath.Ext(*fromJSON)) + ".tscn"
+		}
+		generateScene(*fromJSON, *outputFile, *tileMapNode)
+		return
+	}
+
 	if *inputFile == "" {
 		log.Fatal("Please provide input TSCN file with -input flag")
 	}
@@ -47,59 +88,275 @@ func main() {
 
 	tscnparser.SetTileSize(int(*tileSize))
 	tscnparser.SetOffset(*offsetX, *offsetY)
+	if err := tscnparser.SetCoordinateSystem(tscnparser.CoordinateSystem(*coords)); err != nil {
+		log.Fatal(err)
+	}
 	tscnparser.SetPrefabsDir(*prefabsDir)
+	tscnparser.SetProjectRoot(*projectDir)
+	tscnparser.SetPathBakeTolerance(*pathTolerance)
+	tscnparser.SetParseScripts(*scripts)
 
 	// Parse TSCN file
 	tileMapData, err := tscnparser.Parse(*inputFile)
 	if err != nil {
 		log.Fatalf("Error converting TSCN: %v", err)
 	}
+	for _, warning := range tileMapData.Warnings {
+		log.Printf("Warning: %s", warning)
+	}
 
 	tscnparser.ConvertToTilemap(tileMapData)
+
+	// Rewrite the asset paths before any output is written, so that every output uses them
+	if *replacementsFile != "" {
+		rewriteAssetPaths(tileMapData, *replacementsFile, *pathReport)
+	}
+
 	// Output to JSON with custom layers if available
 	var jsonData []byte
-	jsonData, err = json.MarshalIndent(tileMapData, "", "  ")
+	if *legacySchema {
+		jsonData, err = json.MarshalIndent(tscnparser.LegacyMapData(tileMapData), "", "  ")
+	} else {
+		jsonData, err = json.MarshalIndent(tileMapData, "", "  ")
+	}
 	if err != nil {
 		log.Fatalf("Error marshaling JSON: %v", err)
 	}
 
-	// Apply string replacements
-	jsonStr := string(jsonData)
-
-	// Apply replacements from JSON file first (if provided)
-	if *replacementsFile != "" {
-		jsonStr = applyReplacementsFromFile(jsonStr, *replacementsFile)
+	output := string(jsonData)
+	if *chunkSize > 0 {
+		chunkDir := strings.TrimSuffix(*outputFile, filepath.Ext(*outputFile)) + "_chunks"
+		writeChunks(tileMapData, *chunkSize, chunkDir)
+		// The tiles are in the chunks, the output keeps the layers without them
+		var stripped []byte
+		if *legacySchema {
+			stripped, err = json.MarshalIndent(tscnparser.LegacyMapData(withoutTiles(tileMapData)), "", "  ")
+		} else {
+			stripped, err = json.MarshalIndent(withoutTiles(tileMapData), "", "  ")
+		}
+		if err != nil {
+			log.Fatalf("Error marshaling JSON: %v", err)
+		}
+		output = string(stripped)
 	}
 
-	err = os.WriteFile(*outputFile, []byte(jsonStr), 0644)
+	err = os.WriteFile(*outputFile, []byte(output), 0644)
 	if err != nil {
 		log.Fatalf("Error writing output file: %v", err)
 	}
 	fmt.Printf("Successfully converted %s to %s\n", *inputFile, *outputFile)
+
+	// Generate from the data as read back from the JSON, so that the other outputs match it
+	var converted tscnparser.MapData
+	if *generateGo || *exportTiled || *exportLDtk || *exportBinary {
+		if err := json.Unmarshal(jsonData, &converted); err != nil {
+			log.Fatalf("Error reading converted data: %v", err)
+		}
+	}
+
+	if *generateGo {
+		source, err := codegen.Generate(&converted, codegen.Options{
+			Package:     *goPackage,
+			VarName:     *goVar,
+			TypePrefix:  *goPrefix,
+			ImportTypes: *goImportTypes,
+			Source:      filepath.Base(*inputFile),
+		})
+		if err != nil {
+			log.Fatalf("Error generating Go code: %v", err)
+		}
+		goFile := strings.TrimSuffix(*outputFile, filepath.Ext(*outputFile)) + ".go.txt"
+		if err := os.WriteFile(goFile, source, 0644); err != nil {
+			log.Fatalf("Error writing Go file: %v", err)
+		}
+		fmt.Printf("Generated Go code in %s\n", goFile)
+	}
+
+	if *exportTiled {
+		tiledMap, err := tiled.Export(&converted, tiled.Options{Encoding: *tiledEncoding})
+		if err != nil {
+			log.Fatalf("Error exporting Tiled map: %v", err)
+		}
+		for _, warning := range tiledMap.Warnings {
+			log.Printf("Warning: %s", warning)
+		}
+		base := strings.TrimSuffix(*outputFile, filepath.Ext(*outputFile))
+		if err := tiledMap.WriteTMX(base + ".tmx"); err != nil {
+			log.Fatalf("Error writing Tiled map: %v", err)
+		}
+		if err := tiledMap.WriteJSON(base + ".tmj"); err != nil {
+			log.Fatalf("Error writing Tiled map: %v", err)
+		}
+		fmt.Printf("Exported Tiled map to %s.tmx and %s.tmj\n", base, base)
+	}
+
+	if *exportLDtk {
+		project, err := ldtk.Export(&converted, ldtk.Options{
+			LevelName: strings.TrimSuffix(filepath.Base(*inputFile), filepath.Ext(*inputFile)),
+		})
+		if err != nil {
+			log.Fatalf("Error exporting LDtk project: %v", err)
+		}
+		for _, warning := range project.Warnings {
+			log.Printf("Warning: %s", warning)
+		}
+		ldtkFile := strings.TrimSuffix(*outputFile, filepath.Ext(*outputFile)) + ".ldtk"
+		if err := project.WriteJSON(ldtkFile); err != nil {
+			log.Fatalf("Error writing LDtk project: %v", err)
+		}
+		fmt.Printf("Exported LDtk project to %s\n", ldtkFile)
+	}
+
+	if *exportBinary {
+		compression := tscnparser.BinaryCompressionDeflate
+		switch *binaryCompression {
+		case "none":
+			compression = tscnparser.BinaryCompressionNone
+		case "deflate":
+		default:
+			log.Fatalf("Unknown binary compression %q", *binaryCompression)
+		}
+		binaryFile := strings.TrimSuffix(*outputFile, filepath.Ext(*outputFile)) + ".tmb"
+		file, err := os.Create(binaryFile)
+		if err != nil {
+			log.Fatalf("Error writing binary map: %v", err)
+		}
+		if err := tscnparser.EncodeBinary(file, &converted, compression); err != nil {
+			file.Close()
+			log.Fatalf("Error writing binary map: %v", err)
+		}
+		if err := file.Close(); err != nil {
+			log.Fatalf("Error writing binary map: %v", err)
+		}
+		fmt.Printf("Wrote binary map to %s\n", binaryFile)
+	}
 }
 
-func applyReplacementsFromFile(jsonStr, replacementsFile string) string {
-	// Read replacements file
-	data, err := os.ReadFile(replacementsFile)
+// generateScene writes the Godot scene of a map JSON or binary file
+func generateScene(inputFile, outputFile string, tileMapNode bool) {
+	file, err := os.Open(inputFile)
+	if err != nil {
+		log.Fatalf("Error reading map: %v", err)
+	}
+	defer file.Close()
+	data := &tscnparser.MapData{}
+	if filepath.Ext(inputFile) == ".tmb" {
+		data, err = tscnparser.DecodeBinary(file)
+	} else {
+		err = json.NewDecoder(file).Decode(data)
+	}
+	if err != nil {
+		log.Fatalf("Error reading map: %v", err)
+	}
+	scene, err := godot.Generate(data, godot.Options{
+		Name:        strings.TrimSuffix(filepath.Base(outputFile), filepath.Ext(outputFile)),
+		TileMapNode: tileMapNode,
+	})
 	if err != nil {
-		log.Printf("Warning: Could not read replacements file %s: %v", replacementsFile, err)
-		return jsonStr
+		log.Fatalf("Error generating scene: %v", err)
 	}
+	for _, warning := range scene.Warnings {
+		log.Printf("Warning: %s", warning)
+	}
+	if err := scene.WriteFile(outputFile); err != nil {
+		log.Fatalf("Error writing scene: %v", err)
+	}
+	fmt.Printf("Generated %s from %s\n", outputFile, inputFile)
+}
 
-	// Parse JSON
-	var config ReplacementConfig
-	if err := json.Unmarshal(data, &config); err != nil {
-		log.Printf("Warning: Could not parse replacements file %s: %v", replacementsFile, err)
-		return jsonStr
+// validate checks a map JSON file against the schema and exits with an error when it does not match
+func validate(file string) {
+	document, err := os.ReadFile(file)
+	if err != nil {
+		log.Fatalf("Error reading map: %v", err)
+	}
+	errs, err := tscnparser.ValidateJSON(document)
+	if err != nil {
+		log.Fatalf("Error validating %s: %v", file, err)
+	}
+	for _, e := range errs {
+		fmt.Println(e)
+	}
+	if len(errs) > 0 {
+		log.Fatalf("%s does not match the schema", file)
 	}
+	fmt.Printf("%s matches the schema\n", file)
+}
 
-	// Apply all replacements
-	result := jsonStr
-	for _, replacement := range config.Replacements {
-		if replacement.Old != "" {
-			result = strings.ReplaceAll(result, replacement.Old, replacement.New)
+// writeChunks writes the chunk manifest and one file per chunk to dir
+func writeChunks(data *tscnparser.MapData, size int, dir string) {
+	manifest, chunks, err := tscnparser.ChunkTiles(&data.TileMap, size)
+	if err != nil {
+		log.Fatalf("Error chunking tiles: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		log.Fatalf("Error writing chunks: %v", err)
+	}
+	write := func(name string, value any) {
+		encoded, err := json.MarshalIndent(value, "", "  ")
+		if err != nil {
+			log.Fatalf("Error marshaling chunk: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), encoded, 0644); err != nil {
+			log.Fatalf("Error writing chunks: %v", err)
+		}
+	}
+	for i, chunk := range chunks {
+		write(manifest.Chunks[i].File, chunk)
+	}
+	write("manifest.json", manifest)
+	fmt.Printf("Wrote %d chunks of %dx%d tiles to %s\n", len(chunks), size, size, dir)
+}
+
+// withoutTiles returns a copy of the data whose layers have no tiles
+func withoutTiles(data *tscnparser.MapData) *tscnparser.MapData {
+	stripped := *data
+	stripped.TileMap.Layers = make([]tscnparser.Layer, len(data.TileMap.Layers))
+	for i, layer := range data.TileMap.Layers {
+		layer.TileData, layer.TileFlags = []int{}, nil
+		stripped.TileMap.Layers[i] = layer
+	}
+	return &stripped
+}
+
+// rewriteAssetPaths rewrites the asset paths of the data with the rules of a replacements
+// file, and reports what it rewrote
+func rewriteAssetPaths(data *tscnparser.MapData, rulesFile, reportFile string) {
+	mapper, err := tscnparser.LoadPathMapper(rulesFile)
+	if err != nil {
+		log.Fatalf("Error reading replacements: %v", err)
+	}
+	rewrites := mapper.Apply(data)
+
+	// One line per distinct rewrite, the report file has every field
+	type change struct {
+		kind     tscnparser.AssetKind
+		old, new string
+	}
+	counts := map[change]int{}
+	var changes []change
+	for _, rewrite := range rewrites {
+		c := change{rewrite.Kind, rewrite.Old, rewrite.New}
+		if counts[c] == 0 {
+			changes = append(changes, c)
 		}
+		counts[c]++
 	}
+	for _, c := range changes {
+		fmt.Printf("Rewrote %s %s -> %s (%d fields)\n", c.kind, c.old, c.new, counts[c])
+	}
+	fmt.Printf("Rewrote %d asset paths in %d fields\n", len(changes), len(rewrites))
 
-	return result
+	if reportFile != "" {
+		if rewrites == nil {
+			rewrites = []tscnparser.PathRewrite{}
+		}
+		report, err := json.MarshalIndent(rewrites, "", "  ")
+		if err != nil {
+			log.Fatalf("Error marshaling path report: %v", err)
+		}
+		if err := os.WriteFile(reportFile, report, 0644); err != nil {
+			log.Fatalf("Error writing path report: %v", err)
+		}
+	}
 }
@@ -0,0 +1,263 @@
+package tscnparser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// textureResolver resolves texture references against the resources declared in a single .tscn file
+type textureResolver struct {
+	extResources  map[string]*ExtResource
+	atlasTextures map[string]*TextureRef       // Maps AtlasTexture SubResource ID to its texture
+	atlasOffsets  map[string]Vec2              // Region origin of nested AtlasTextures
+	spriteFrames  map[string][]SpriteAnimation // Maps SpriteFrames SubResource ID to its animations
+}
+
+func newTextureResolver(extResources map[string]*ExtResource) *textureResolver {
+	return &textureResolver{
+		extResources:  extResources,
+		atlasTextures: make(map[string]*TextureRef),
+		atlasOffsets:  make(map[string]Vec2),
+		spriteFrames:  make(map[string][]SpriteAnimation),
+	}
+}
+
+// resolve resolves a texture property value such as ExtResource("1_abc") or SubResource("AtlasTexture_xyz")
+func (r *textureResolver) resolve(value string) *TextureRef {
+	parsed, err := parseVariant(strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	ref, ok := parsed.(resourceRef)
+	if !ok {
+		return nil
+	}
+	return r.resolveRef(ref)
+}
+
+func (r *textureResolver) resolveRef(ref resourceRef) *TextureRef {
+	switch ref.Kind {
+	case "ExtResource":
+		if extRes, exists := r.extResources[ref.ID]; exists {
+			return &TextureRef{Path: extRes.Path}
+		}
+	case "SubResource":
+		if atlas, exists := r.atlasTextures[ref.ID]; exists {
+			texture := *atlas
+			return &texture
+		}
+	}
+	return nil
+}
+
+// parseAtlasTextureProperty parses a property line of an AtlasTexture sub_resource
+func (r *textureResolver) parseAtlasTextureProperty(resourceID, line string) {
+	atlas, exists := r.atlasTextures[resourceID]
+	if !exists {
+		return
+	}
+	key, value, ok := splitProperty(line)
+	if !ok {
+		return
+	}
+	switch key {
+	case "atlas":
+		// The atlas may itself be an AtlasTexture; its region becomes the base of ours
+		if base := r.resolve(value); base != nil {
+			atlas.Path = base.Path
+			if base.Region != nil {
+				offset := Vec2{X: base.Region.X, Y: base.Region.Y}
+				if atlas.Region != nil {
+					atlas.Region.X += offset.X
+					atlas.Region.Y += offset.Y
+				}
+				r.atlasOffsets[resourceID] = offset
+			}
+		}
+	case "region":
+		if rect, ok := parseRect2(value); ok {
+			offset := r.atlasOffsets[resourceID]
+			rect.X += offset.X
+			rect.Y += offset.Y
+			atlas.Region = &rect
+		}
+	case "margin":
+		if rect, ok := parseRect2(value); ok {
+			atlas.Margin = &rect
+		}
+	case "filter_clip":
+		atlas.FilterClip = strings.TrimSpace(value) == "true"
+	}
+}
+
+// parseSpriteFrames parses the animations property of a SpriteFrames sub_resource
+func (r *textureResolver) parseSpriteFrames(resourceID, value string) {
+	parsed, err := parseVariant(value)
+	if err != nil {
+		return
+	}
+	items, ok := parsed.([]any)
+	if !ok {
+		return
+	}
+
+	var animations []SpriteAnimation
+	for _, item := range items {
+		dict, ok := item.(map[string]any)
+		if !ok {
+			continue
+		}
+		animation := SpriteAnimation{Speed: 5, Loop: true}
+		if name, ok := dict["name"].(string); ok {
+			animation.Name = name
+		}
+		if speed, ok := toFloat(dict["speed"]); ok {
+			animation.Speed = speed
+		}
+		if loop, ok := dict["loop"].(bool); ok {
+			animation.Loop = loop
+		}
+		frames, _ := dict["frames"].([]any)
+		for _, frameItem := range frames {
+			frameDict, ok := frameItem.(map[string]any)
+			if !ok {
+				continue
+			}
+			frame := SpriteFrame{Duration: 1}
+			if duration, ok := toFloat(frameDict["duration"]); ok {
+				frame.Duration = duration
+			}
+			if ref, ok := frameDict["texture"].(resourceRef); ok {
+				if texture := r.resolveRef(ref); texture != nil {
+					frame.Texture = *texture
+				}
+			}
+			animation.Frames = append(animation.Frames, frame)
+		}
+		animations = append(animations, animation)
+	}
+	r.spriteFrames[resourceID] = animations
+}
+
+// resolveSpriteFrames resolves a sprite_frames property value to its animations
+func (r *textureResolver) resolveSpriteFrames(value string) []SpriteAnimation {
+	parsed, err := parseVariant(strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	if ref, ok := parsed.(resourceRef); ok && ref.Kind == "SubResource" {
+		return r.spriteFrames[ref.ID]
+	}
+	return nil
+}
+
+// withRegion returns the texture restricted to a region given in its own coordinates,
+// as Sprite2D does with region_enabled/region_rect
+func (t *TextureRef) withRegion(region Rect2) *TextureRef {
+	result := *t
+	if t.Region != nil {
+		region.X += t.Region.X
+		region.Y += t.Region.Y
+	}
+	result.Region = &region
+	return &result
+}
+
+// splitProperty splits a "key = value" property line
+func splitProperty(line string) (string, string, bool) {
+	parts := strings.SplitN(line, " = ", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+// parseRect2 parses a Rect2(x, y, w, h) value
+func parseRect2(value string) (Rect2, bool) {
+	parsed, err := parseVariant(strings.TrimSpace(value))
+	if err != nil {
+		return Rect2{}, false
+	}
+	call, ok := parsed.(variantCall)
+	if !ok || call.Name != "Rect2" {
+		return Rect2{}, false
+	}
+	args, ok := call.floatArgs()
+	if !ok || len(args) != 4 {
+		return Rect2{}, false
+	}
+	return Rect2{X: args[0], Y: args[1], Width: args[2], Height: args[3]}, true
+}
+
+func toFloat(value any) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// spriteTexture collects the texture properties of a Sprite2D or AnimatedSprite2D node,
+// which may appear in any order within the node section
+type spriteTexture struct {
+	texture       *TextureRef
+	regionEnabled bool
+	regionRect    *Rect2
+	filterClip    bool
+	hframes       int
+	vframes       int
+	frame         int
+	animations    []SpriteAnimation
+}
+
+// parseProperty consumes a texture related node property and reports whether it did so
+func (s *spriteTexture) parseProperty(r *textureResolver, key, value string) bool {
+	switch key {
+	case "texture":
+		s.texture = r.resolve(value)
+	case "region_enabled":
+		s.regionEnabled = value == "true"
+	case "region_rect":
+		if rect, ok := parseRect2(value); ok {
+			s.regionRect = &rect
+		}
+	case "region_filter_clip_enabled":
+		s.filterClip = value == "true"
+	case "hframes":
+		s.hframes, _ = strconv.Atoi(value)
+	case "vframes":
+		s.vframes, _ = strconv.Atoi(value)
+	case "frame":
+		s.frame, _ = strconv.Atoi(value)
+	case "sprite_frames":
+		s.animations = r.resolveSpriteFrames(value)
+	default:
+		return false
+	}
+	return true
+}
+
+// resolved returns the texture the node displays, or nil if it has none.
+// For AnimatedSprite2D nodes this is the first frame of the first animation.
+func (s *spriteTexture) resolved() *TextureRef {
+	texture := s.texture
+	if texture == nil {
+		for _, animation := range s.animations {
+			if len(animation.Frames) > 0 {
+				frame := animation.Frames[0].Texture
+				texture = &frame
+				break
+			}
+		}
+	}
+	if texture == nil {
+		return nil
+	}
+	if s.regionEnabled && s.regionRect != nil {
+		texture = texture.withRegion(*s.regionRect)
+		texture.FilterClip = texture.FilterClip || s.filterClip
+	}
+	return texture
+}
@@ -0,0 +1,63 @@
+package tscnparser
+
+import (
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDecoratorTextureSize(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Mkdir(filepath.Join(root, "sprites"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Create(filepath.Join(root, "sprites", "sheet.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, 64, 32))); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+	t.Cleanup(func() { SetProjectRoot("") })
+	SetProjectRoot(root)
+
+	data := parseNodes(t, `
+[ext_resource type="Texture2D" path="res://sprites/sheet.png" id="1_s"]
+
+[sub_resource type="AtlasTexture" id="AtlasTexture_1"]
+atlas = ExtResource("1_s")
+region = Rect2(16, 8, 32, 24)
+
+[sub_resource type="AtlasTexture" id="AtlasTexture_2"]
+atlas = SubResource("AtlasTexture_1")
+region = Rect2(4, 4, 8, 8)
+`, `
+[node name="Gem" type="Sprite2D" parent="."]
+texture = SubResource("AtlasTexture_2")
+
+[node name="Tile" type="Sprite2D" parent="."]
+texture = ExtResource("1_s")
+region_enabled = true
+region_rect = Rect2(0, 16, 16, 16)
+`)
+	// The nested atlas region is offset by the region of the atlas it cuts from
+	want := map[string]TextureRef{
+		"Gem":  {Path: "res://sprites/sheet.png", Region: &Rect2{X: 20, Y: 12, Width: 8, Height: 8}, ImageWidth: 64, ImageHeight: 32},
+		"Tile": {Path: "res://sprites/sheet.png", Region: &Rect2{X: 0, Y: 16, Width: 16, Height: 16}, ImageWidth: 64, ImageHeight: 32},
+	}
+	if len(data.Decorators) != len(want) {
+		t.Fatalf("decorators = %+v", data.Decorators)
+	}
+	for _, decorator := range data.Decorators {
+		if texture := decorator.TextureRef; texture == nil || !reflect.DeepEqual(*texture, want[decorator.Name]) {
+			t.Errorf("%s: texture = %+v, want %+v", decorator.Name, texture, want[decorator.Name])
+		}
+	}
+	if len(data.Warnings) != 0 {
+		t.Errorf("warnings = %v", data.Warnings)
+	}
+}
@@ -0,0 +1,663 @@
+package tiled
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// Options configure the export
+type Options struct {
+	Encoding string // tile layer data encoding, EncodingCSV if empty
+	// ImagePath converts the texture paths of the scene to the image paths written to the
+	// tilesets. By default res:// is stripped, so images resolve for a map saved in the
+	// project root.
+	ImagePath func(texturePath string) string
+}
+
+// Export converts parsed scene data to a Tiled map.
+//
+// Output positions are converted back to scene space with the data's Coordinates, and the
+// map covers the bounding box of the tiles. The map properties offset_x and offset_y hold
+// the scene position of the map's top left corner, so a scene position is
+// (x + offset_x, y + offset_y) for a map position (x, y).
+func Export(data *tscnparser.MapData, opts Options) (*Map, error) {
+	if data == nil {
+		return nil, fmt.Errorf("no data to export")
+	}
+	switch opts.Encoding {
+	case "":
+		opts.Encoding = EncodingCSV
+	case EncodingCSV, EncodingBase64, EncodingBase64Zlib, EncodingBase64Gzip:
+	default:
+		return nil, fmt.Errorf("unknown tile layer encoding %q", opts.Encoding)
+	}
+	if opts.ImagePath == nil {
+		opts.ImagePath = func(texturePath string) string {
+			return strings.TrimPrefix(texturePath, "res://")
+		}
+	}
+
+	tileSize := data.TileMap.TileSize
+	if tileSize.Width <= 0 || tileSize.Height <= 0 {
+		tileSize = tscnparser.TileSize{Width: 16, Height: 16}
+	}
+	e := &exporter{
+		data:   data,
+		opts:   opts,
+		coords: data.Coordinates,
+		m: &Map{
+			Type:             "map",
+			Version:          Version,
+			TiledVersion:     TiledVersion,
+			Orientation:      "orthogonal",
+			RenderOrder:      "right-down",
+			TileWidth:        tileSize.Width,
+			TileHeight:       tileSize.Height,
+			CompressionLevel: -1,
+			NextLayerID:      1,
+			NextObjectID:     1,
+		},
+		sources:    make(map[int]*sourceTileset),
+		spriteGIDs: make(map[string]uint32),
+	}
+	e.bounds()
+	e.tilesets()
+	if err := e.tileLayers(); err != nil {
+		return nil, err
+	}
+	e.objectLayers()
+	e.mapProperties()
+	return e.m, nil
+}
+
+type exporter struct {
+	data   *tscnparser.MapData
+	opts   Options
+	coords tscnparser.Coordinates
+	m      *Map
+	// Tile bounds of the map in scene tile columns and rows
+	minColumn, minRow int
+	// Tilesets by tile source ID
+	sources map[int]*sourceTileset
+	// Image collection holding the textures of decorators and prefab instances
+	sprites    *Tileset
+	spriteGIDs map[string]uint32
+}
+
+// cell is a tile of a layer in map columns and rows, with its TileFlip* flags
+type cell struct {
+	column, row int
+	source      int
+	atlas       tscnparser.Vec2i
+	flags       int
+}
+
+// cells decodes the [source_id, tile_x, tile_y, atlas_x, atlas_y] tile data of a layer
+func (e *exporter) cells(layer tscnparser.Layer) []cell {
+	var result []cell
+	for i := 0; i+4 < len(layer.TileData); i += 5 {
+		column, row := e.coords.SceneTile(layer.TileData[i+1], layer.TileData[i+2], tscnparser.TileSize{Width: e.m.TileWidth, Height: e.m.TileHeight})
+		c := cell{
+			column: column,
+			row:    row,
+			source: layer.TileData[i],
+			atlas:  tscnparser.Vec2i{X: layer.TileData[i+3], Y: layer.TileData[i+4]},
+		}
+		if i/5 < len(layer.TileFlags) {
+			c.flags = layer.TileFlags[i/5]
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// bounds sizes the map to the tiles of all layers
+func (e *exporter) bounds() {
+	first := true
+	maxColumn, maxRow := 0, 0
+	for _, layer := range e.data.TileMap.Layers {
+		for _, c := range e.cells(layer) {
+			if first {
+				e.minColumn, maxColumn, e.minRow, maxRow = c.column, c.column, c.row, c.row
+				first = false
+			}
+			e.minColumn, maxColumn = min(e.minColumn, c.column), max(maxColumn, c.column)
+			e.minRow, maxRow = min(e.minRow, c.row), max(maxRow, c.row)
+		}
+	}
+	e.m.Width, e.m.Height = maxColumn-e.minColumn+1, maxRow-e.minRow+1
+}
+
+// point converts an output position to map pixels
+func (e *exporter) point(p tscnparser.Vec2) tscnparser.Vec2 {
+	p = e.coords.ScenePoint(p)
+	return tscnparser.Vec2{
+		X: round(p.X - float64(e.minColumn*e.m.TileWidth)),
+		Y: round(p.Y - float64(e.minRow*e.m.TileHeight)),
+	}
+}
+
+// sceneTransform converts an output transform to scene space relative to the map
+func (e *exporter) sceneTransform(t tscnparser.Transform2D) tscnparser.Transform2D {
+	position := e.point(t.Position)
+	t = e.coords.SceneTransform(t)
+	t.Position = position
+	return t
+}
+
+func (e *exporter) tileLayers() error {
+	layers := make([]tscnparser.Layer, len(e.data.TileMap.Layers))
+	copy(layers, e.data.TileMap.Layers)
+	// Tiled draws layers in list order
+	sort.SliceStable(layers, func(i, j int) bool {
+		a, b := layers[i].DrawOrder, layers[j].DrawOrder
+		if a.CanvasLayer != b.CanvasLayer {
+			return a.CanvasLayer < b.CanvasLayer
+		}
+		return a.ZIndex < b.ZIndex
+	})
+	for _, layer := range layers {
+		gids := make([]uint32, e.m.Width*e.m.Height)
+		unknown := 0
+		for _, c := range e.cells(layer) {
+			tileset, exists := e.sources[c.source]
+			if !exists {
+				unknown++
+				continue
+			}
+			gid := uint32(tileset.FirstGID+tileset.tileID(c.atlas)) | flipFlags(c.flags)
+			gids[(c.row-e.minRow)*e.m.Width+c.column-e.minColumn] = gid
+		}
+		if unknown > 0 {
+			e.m.Warnings = append(e.m.Warnings, fmt.Sprintf("layer %q: %d tiles of unknown sources left empty", layer.Name, unknown))
+		}
+		tileLayer := &TileLayer{
+			ID:         e.layerID(),
+			Name:       layer.Name,
+			Type:       "tilelayer",
+			Width:      e.m.Width,
+			Height:     e.m.Height,
+			Opacity:    1,
+			Visible:    true,
+			Properties: entryProperties(layer, "name", "tile_data", "tile_flags"),
+		}
+		if err := e.encode(tileLayer, gids); err != nil {
+			return err
+		}
+		e.m.Layers = append(e.m.Layers, tileLayer)
+	}
+	return nil
+}
+
+// flipFlags converts TileFlip* flags to gid flags
+func flipFlags(flags int) uint32 {
+	var result uint32
+	if flags&tscnparser.TileFlipH != 0 {
+		result |= FlipHorizontal
+	}
+	if flags&tscnparser.TileFlipV != 0 {
+		result |= FlipVertical
+	}
+	if flags&tscnparser.TileTranspose != 0 {
+		result |= FlipDiagonal
+	}
+	return result
+}
+
+// encode sets the layer data in the configured encoding
+func (e *exporter) encode(layer *TileLayer, gids []uint32) error {
+	if e.opts.Encoding == EncodingCSV {
+		layer.Data = gids
+		return nil
+	}
+	raw := make([]byte, len(gids)*4)
+	for i, gid := range gids {
+		binary.LittleEndian.PutUint32(raw[i*4:], gid)
+	}
+	var compressed bytes.Buffer
+	switch e.opts.Encoding {
+	case EncodingBase64Zlib:
+		w := zlib.NewWriter(&compressed)
+		if _, err := w.Write(raw); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		raw, layer.Compression = compressed.Bytes(), "zlib"
+	case EncodingBase64Gzip:
+		w := gzip.NewWriter(&compressed)
+		if _, err := w.Write(raw); err != nil {
+			return err
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+		raw, layer.Compression = compressed.Bytes(), "gzip"
+	}
+	layer.Data, layer.Encoding = base64.StdEncoding.EncodeToString(raw), "base64"
+	return nil
+}
+
+func (e *exporter) layerID() int {
+	id := e.m.NextLayerID
+	e.m.NextLayerID++
+	return id
+}
+
+func (e *exporter) objectID() int {
+	id := e.m.NextObjectID
+	e.m.NextObjectID++
+	return id
+}
+
+func (e *exporter) objectLayers() {
+	groups := []struct {
+		name    string
+		objects []*Object
+	}{
+		{"geometry", e.geometry()},
+		{"paths", e.paths()},
+		{"triggers", e.triggers()},
+		{"decorators", e.decorators()},
+		{"prefabs", e.prefabs()},
+		{"markers", e.markers()},
+	}
+	for _, group := range groups {
+		if len(group.objects) == 0 {
+			continue
+		}
+		e.m.Layers = append(e.m.Layers, &ObjectGroup{
+			ID:        e.layerID(),
+			Name:      group.name,
+			Type:      "objectgroup",
+			DrawOrder: "index",
+			Opacity:   1,
+			Visible:   true,
+			Objects:   group.objects,
+		})
+	}
+}
+
+func (e *exporter) newObject(name, kind string, properties []Property) *Object {
+	return &Object{ID: e.objectID(), Name: name, Type: kind, Visible: true, Properties: properties}
+}
+
+// decorators exports decorators as tile objects showing their texture. Decorators built
+// from instanced sprites by ConvertToTilemap keep the sprite in a "sprite" property.
+func (e *exporter) decorators() []*Object {
+	sprites := make(map[string]tscnparser.SpriteNode)
+	for _, sprite := range e.data.Sprites {
+		sprites[nodePath(sprite.Parent, sprite.Name)] = sprite
+	}
+	var objects []*Object
+	for _, decorator := range e.data.Decorators {
+		properties := entryProperties(decorator, "name")
+		if sprite, exists := sprites[decorator.NodePath]; exists {
+			properties = appendJSONProperty(properties, "sprite", sprite)
+		}
+		texture := decorator.TextureRef
+		if texture == nil && decorator.Path != "" {
+			texture = &tscnparser.TextureRef{Path: decorator.Path}
+		}
+		object := e.newObject(decorator.Name, "decorator", properties)
+		e.placeSprite(object, decorator.Position, decorator.Pivot, decorator.Rotation, decorator.Scale, texture)
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// prefabs exports the instanced sprites that were not converted to decorators, showing
+// the texture of their prefab
+func (e *exporter) prefabs() []*Object {
+	converted := make(map[string]bool)
+	for _, decorator := range e.data.Decorators {
+		converted[decorator.NodePath] = true
+	}
+	prefabs := make(map[string]tscnparser.PrefabNode)
+	for _, prefab := range e.data.Prefabs {
+		prefabs[prefab.Path] = prefab
+	}
+	var objects []*Object
+	for _, sprite := range e.data.Sprites {
+		if converted[nodePath(sprite.Parent, sprite.Name)] {
+			continue
+		}
+		object := e.newObject(sprite.Name, "prefab", entryProperties(sprite, "name"))
+		prefab, exists := prefabs[sprite.Path]
+		scale := sprite.Scale
+		if exists {
+			scale.X *= prefab.Scale.X
+			scale.Y *= prefab.Scale.Y
+		}
+		e.placeSprite(object, sprite.Position, prefab.Pivot, sprite.Rotation, scale, prefab.TextureRef)
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// nodePath returns the scene path of a node from its parent path and name
+func nodePath(parent, name string) string {
+	switch parent {
+	case "":
+		return "."
+	case ".":
+		return name
+	}
+	return parent + "/" + name
+}
+
+// placeSprite makes the object a tile object covering a centered sprite, or a point object
+// when the size of its texture is unknown. The position, the pivot (the sprite's offset
+// from it) and the rotation in radians are in output coordinates.
+func (e *exporter) placeSprite(object *Object, position, pivot tscnparser.Vec2, rotation float64, scale tscnparser.Vec2, texture *tscnparser.TextureRef) {
+	pivot, rotation = e.coords.Vector(pivot), e.coords.Rotation(rotation)
+	center := e.point(position)
+	offset := rotate(pivot, rotation)
+	center.X, center.Y = round(center.X+offset.X), round(center.Y+offset.Y)
+	object.Rotation = degrees(rotation)
+
+	gid, width, height := e.spriteTile(texture)
+	if gid == 0 {
+		object.X, object.Y, object.Point = center.X, center.Y, true
+		return
+	}
+	// Decorators of Sprite2D nodes leave the scale unset
+	if scale.X == 0 && scale.Y == 0 {
+		scale = tscnparser.Vec2{X: 1, Y: 1}
+	}
+	if scale.X < 0 {
+		gid |= FlipHorizontal
+	}
+	if scale.Y < 0 {
+		gid |= FlipVertical
+	}
+	object.GID = gid
+	object.Width, object.Height = round(width*math.Abs(scale.X)), round(height*math.Abs(scale.Y))
+	// Tile objects are anchored at their bottom left corner
+	corner := rotate(tscnparser.Vec2{X: -object.Width / 2, Y: object.Height / 2}, rotation)
+	object.X, object.Y = round(center.X+corner.X), round(center.Y+corner.Y)
+}
+
+// spriteTile returns the gid and size of the tile showing a texture in the sprites tileset,
+// or zero if the texture's size is unknown
+func (e *exporter) spriteTile(texture *tscnparser.TextureRef) (uint32, float64, float64) {
+	if texture == nil || texture.Path == "" {
+		return 0, 0, 0
+	}
+	tile := &Tile{
+		Image:       e.opts.ImagePath(texture.Path),
+		ImageWidth:  texture.ImageWidth,
+		ImageHeight: texture.ImageHeight,
+		Width:       texture.ImageWidth,
+		Height:      texture.ImageHeight,
+	}
+	if region := texture.Region; region != nil {
+		tile.X, tile.Y = int(region.X), int(region.Y)
+		tile.Width, tile.Height = int(region.Width), int(region.Height)
+	}
+	if tile.Width <= 0 || tile.Height <= 0 {
+		return 0, 0, 0
+	}
+	key := fmt.Sprintf("%s %d %d %d %d", texture.Path, tile.X, tile.Y, tile.Width, tile.Height)
+	if gid, exists := e.spriteGIDs[key]; exists {
+		return gid, float64(tile.Width), float64(tile.Height)
+	}
+	if e.sprites == nil {
+		e.sprites = &Tileset{Name: "sprites", FirstGID: e.nextGID()}
+		e.m.Tilesets = append(e.m.Tilesets, e.sprites)
+	}
+	tile.ID = len(e.sprites.Tiles)
+	e.sprites.Tiles = append(e.sprites.Tiles, tile)
+	e.sprites.TileCount = len(e.sprites.Tiles)
+	e.sprites.TileWidth = max(e.sprites.TileWidth, tile.Width)
+	e.sprites.TileHeight = max(e.sprites.TileHeight, tile.Height)
+	gid := uint32(e.sprites.FirstGID + tile.ID)
+	e.spriteGIDs[key] = gid
+	return gid, float64(tile.Width), float64(tile.Height)
+}
+
+func (e *exporter) markers() []*Object {
+	var objects []*Object
+	for _, marker := range e.data.Markers {
+		object := e.newObject(marker.Name, "marker", entryProperties(marker, "name"))
+		p := e.point(marker.Position)
+		object.X, object.Y, object.Point = p.X, p.Y, true
+		object.Rotation = degrees(e.coords.Rotation(marker.Rotation))
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// triggers exports one object per shape of each trigger, the shape itself in a "shape" property
+func (e *exporter) triggers() []*Object {
+	var objects []*Object
+	for _, trigger := range e.data.Triggers {
+		properties := entryProperties(trigger, "name", "shapes")
+		transform := e.sceneTransform(trigger.Transform)
+		if len(trigger.Shapes) == 0 {
+			object := e.newObject(trigger.Name, "trigger", properties)
+			object.X, object.Y, object.Point = transform.Position.X, transform.Position.Y, true
+			objects = append(objects, object)
+			continue
+		}
+		for _, shape := range trigger.Shapes {
+			object := e.newObject(trigger.Name, "trigger", appendJSONProperty(properties, "shape", shape))
+			local := e.coords.LocalTransform(shape.Transform)
+			placeShape(object, transform.Mul(local), shape.Type, e.coords.ColliderParams(shape.Type, shape.Params))
+			objects = append(objects, object)
+		}
+	}
+	return objects
+}
+
+// placeShape shapes the object after a collision shape with the given transform in map pixels
+func placeShape(object *Object, transform tscnparser.Transform2D, shapeType string, params []float64) {
+	center := transform.Position
+	switch {
+	case shapeType == tscnparser.ColliderRect && len(params) >= 2,
+		shapeType == tscnparser.ColliderCircle && len(params) >= 1,
+		shapeType == tscnparser.ColliderCapsule && len(params) >= 2:
+		width, height := params[0], params[0]
+		switch shapeType {
+		case tscnparser.ColliderRect:
+			height = params[1]
+		case tscnparser.ColliderCircle:
+			width, height = params[0]*2, params[0]*2
+		case tscnparser.ColliderCapsule:
+			width, height = params[0]*2, params[1]
+		}
+		object.Width = round(width * math.Abs(transform.Scale.X))
+		object.Height = round(height * math.Abs(transform.Scale.Y))
+		object.Ellipse = shapeType != tscnparser.ColliderRect
+		// Rectangles and ellipses are anchored at their top left corner
+		corner := rotate(tscnparser.Vec2{X: -object.Width / 2, Y: -object.Height / 2}, transform.Rotation)
+		object.X, object.Y = round(center.X+corner.X), round(center.Y+corner.Y)
+		object.Rotation = degrees(transform.Rotation)
+	case shapeType == tscnparser.ColliderPolygon && len(params) >= 6:
+		object.X, object.Y = round(center.X), round(center.Y)
+		object.Polygon = relativePoints(transform, params)
+	case (shapeType == tscnparser.ColliderConcave || shapeType == tscnparser.ColliderSegment) && len(params) >= 4:
+		object.X, object.Y = round(center.X), round(center.Y)
+		object.Polyline = relativePoints(transform, params)
+	default:
+		object.X, object.Y, object.Point = round(center.X), round(center.Y), true
+	}
+}
+
+// relativePoints transforms flat local points and makes them relative to the transform's position
+func relativePoints(transform tscnparser.Transform2D, points []float64) []Point {
+	var result []Point
+	for i := 0; i+1 < len(points); i += 2 {
+		p := transform.Xform(tscnparser.Vec2{X: points[i], Y: points[i+1]})
+		result = append(result, Point{X: round(p.X - transform.Position.X), Y: round(p.Y - transform.Position.Y)})
+	}
+	return result
+}
+
+// paths exports paths as polylines through their baked points, or their curve points
+func (e *exporter) paths() []*Object {
+	var objects []*Object
+	for _, curve := range e.data.Paths {
+		points := curve.Baked
+		if len(points) == 0 {
+			for _, p := range curve.Points {
+				points = append(points, p.Position)
+			}
+		}
+		object := e.newObject(curve.Name, "path", entryProperties(curve, "name"))
+		object.Polyline = e.relativeTo(object, points)
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// geometry exports closed geometry as polygons and open geometry as polylines
+func (e *exporter) geometry() []*Object {
+	var objects []*Object
+	for _, geometry := range e.data.Geometry {
+		object := e.newObject(geometry.Name, "geometry", entryProperties(geometry, "name"))
+		if points := e.relativeTo(object, geometry.Points); geometry.Closed && len(points) >= 3 {
+			object.Polygon = points
+		} else {
+			object.Polyline = points
+		}
+		objects = append(objects, object)
+	}
+	return objects
+}
+
+// relativeTo places the object at the first of the output points and returns them relative to it
+func (e *exporter) relativeTo(object *Object, points []tscnparser.Vec2) []Point {
+	if len(points) == 0 {
+		object.Point = true
+		return nil
+	}
+	origin := e.point(points[0])
+	object.X, object.Y = origin.X, origin.Y
+	result := make([]Point, len(points))
+	for i, p := range points {
+		p = e.point(p)
+		result[i] = Point{X: round(p.X - origin.X), Y: round(p.Y - origin.Y)}
+	}
+	return result
+}
+
+// mapProperties stores the parts of the scene that have no Tiled counterpart
+func (e *exporter) mapProperties() {
+	properties := []Property{
+		{Name: "offset_x", Type: "int", Value: e.minColumn * e.m.TileWidth},
+		{Name: "offset_y", Type: "int", Value: e.minRow * e.m.TileHeight},
+	}
+	for _, property := range entryProperties(e.data.TileMap, "layers", "tileset") {
+		property.Name = "tilemap/" + property.Name
+		properties = append(properties, property)
+	}
+	properties = append(properties, entryProperties(e.data,
+		"tilemap", "decorators", "sprites", "triggers", "markers", "paths", "geometry")...)
+	sort.SliceStable(properties, func(i, j int) bool { return properties[i].Name < properties[j].Name })
+	e.m.Properties = properties
+}
+
+// entryProperties lists the JSON fields of an exported entry as custom properties, so the
+// map keeps everything the parser found. Objects and arrays are stored as JSON strings.
+func entryProperties(entry any, skip ...string) []Property {
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var properties []Property
+	for _, name := range names {
+		if slices.Contains(skip, name) {
+			continue
+		}
+		if property, ok := jsonProperty(name, fields[name]); ok {
+			properties = append(properties, property)
+		}
+	}
+	return properties
+}
+
+// appendJSONProperty adds a property holding the JSON of a value, keeping the list sorted
+func appendJSONProperty(properties []Property, name string, value any) []Property {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return properties
+	}
+	result := append([]Property{}, properties...)
+	result = append(result, Property{Name: name, Type: "string", Value: string(encoded)})
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// jsonProperty converts a JSON value to a property of the matching type
+func jsonProperty(name string, raw json.RawMessage) (Property, bool) {
+	text := string(raw)
+	switch {
+	case text == "null":
+		return Property{}, false
+	case text == "true" || text == "false":
+		return Property{Name: name, Type: "bool", Value: text == "true"}, true
+	case strings.HasPrefix(text, `"`):
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return Property{}, false
+		}
+		return Property{Name: name, Type: "string", Value: value}, true
+	case strings.HasPrefix(text, "{") || strings.HasPrefix(text, "["):
+		return Property{Name: name, Type: "string", Value: text}, true
+	}
+	if !strings.ContainsAny(text, ".eE") {
+		if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return Property{Name: name, Type: "int", Value: value}, true
+		}
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Property{}, false
+	}
+	return Property{Name: name, Type: "float", Value: value}, true
+}
+
+// rotate rotates a vector by radians, clockwise with the Y axis down
+func rotate(v tscnparser.Vec2, angle float64) tscnparser.Vec2 {
+	sin, cos := math.Sincos(angle)
+	return tscnparser.Vec2{X: v.X*cos - v.Y*sin, Y: v.X*sin + v.Y*cos}
+}
+
+func degrees(radians float64) float64 {
+	return round(radians * 180 / math.Pi)
+}
+
+// round drops floating point noise from computed coordinates
+func round(v float64) float64 {
+	return math.Round(v*1e6) / 1e6
+}
+
+// imageName returns the file name of a texture without its extension
+func imageName(texturePath string) string {
+	name := path.Base(texturePath)
+	return strings.TrimSuffix(name, path.Ext(name))
+}
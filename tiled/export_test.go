@@ -0,0 +1,140 @@
+package tiled
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/binary"
+	"io"
+	"reflect"
+	"testing"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+func TestFlipFlags(t *testing.T) {
+	tests := []struct {
+		flags int
+		want  uint32
+	}{
+		{0, 0},
+		{tscnparser.TileFlipH, 0x80000000},
+		{tscnparser.TileFlipV, 0x40000000},
+		{tscnparser.TileTranspose, 0x20000000},
+		{tscnparser.TileFlipH | tscnparser.TileFlipV | tscnparser.TileTranspose, 0xE0000000},
+	}
+	for _, tt := range tests {
+		if got := flipFlags(tt.flags); got != tt.want {
+			t.Errorf("flipFlags(%d) = %#x, want %#x", tt.flags, got, tt.want)
+		}
+	}
+}
+
+// exportMap is a 2x2 map of flipped tiles, a marker and a decorator, in y-up coordinates
+// whose origin is the scene position (-48, -32)
+func exportMap() *tscnparser.MapData {
+	return &tscnparser.MapData{
+		Coordinates: tscnparser.Coordinates{System: tscnparser.CoordinatesYUp, Origin: tscnparser.Vec2{X: -48, Y: -32}},
+		TileMap: tscnparser.TileMapData{
+			TileSize: tscnparser.TileSize{Width: 16, Height: 16},
+			TileSet: tscnparser.TileSet{Sources: []tscnparser.TileSource{{
+				TexturePath:       "res://tiles.png",
+				TextureRef:        &tscnparser.TextureRef{Path: "res://tiles.png", ImageWidth: 32, ImageHeight: 16},
+				TextureRegionSize: tscnparser.Vec2i{X: 16, Y: 16},
+			}}},
+			// The scene tiles (0, 0), (1, 0), (0, 1) and (1, 1)
+			Layers: []tscnparser.Layer{{
+				Name:      "ground",
+				TileData:  []int{0, 3, -2, 0, 0, 0, 4, -2, 1, 0, 0, 3, -3, 0, 0, 0, 4, -3, 1, 0},
+				TileFlags: []int{0, tscnparser.TileFlipH, tscnparser.TileFlipV, tscnparser.TileFlipH | tscnparser.TileFlipV | tscnparser.TileTranspose},
+			}},
+		},
+		// Both at the scene position (8, 8)
+		Markers: []tscnparser.Marker{{Name: "Spawn", Position: tscnparser.Vec2{X: 56, Y: -40}, Rotation: 0.5}},
+		Decorators: []tscnparser.DecoratorNode{{
+			Name:       "Lamp",
+			Position:   tscnparser.Vec2{X: 56, Y: -40},
+			TextureRef: &tscnparser.TextureRef{Path: "res://lamp.png", ImageWidth: 16, ImageHeight: 8},
+		}},
+	}
+}
+
+func TestExportEncodings(t *testing.T) {
+	want := []uint32{1, 2 | FlipHorizontal, 1 | FlipVertical, 2 | FlipHorizontal | FlipVertical | FlipDiagonal}
+	tests := []struct {
+		encoding, compression string
+		decompress            func(data []byte) ([]byte, error)
+	}{
+		{EncodingCSV, "", nil},
+		{EncodingBase64, "", func(data []byte) ([]byte, error) { return data, nil }},
+		{EncodingBase64Zlib, "zlib", func(data []byte) ([]byte, error) {
+			r, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			return io.ReadAll(r)
+		}},
+	}
+	for _, tt := range tests {
+		m, err := Export(exportMap(), Options{Encoding: tt.encoding})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.encoding, err)
+		}
+		if m.Width != 2 || m.Height != 2 {
+			t.Errorf("%s: map is %dx%d", tt.encoding, m.Width, m.Height)
+		}
+		layer := m.Layers[0].(*TileLayer)
+		if tt.decompress == nil {
+			if !reflect.DeepEqual(layer.Data, want) || layer.Encoding != "" {
+				t.Errorf("%s: data = %v, encoding %q", tt.encoding, layer.Data, layer.Encoding)
+			}
+			continue
+		}
+		if layer.Encoding != "base64" || layer.Compression != tt.compression {
+			t.Errorf("%s: encoding %q, compression %q", tt.encoding, layer.Encoding, layer.Compression)
+		}
+		data, err := base64.StdEncoding.DecodeString(layer.Data.(string))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.encoding, err)
+		}
+		if data, err = tt.decompress(data); err != nil {
+			t.Fatalf("%s: %v", tt.encoding, err)
+		}
+		got := make([]uint32, len(data)/4)
+		for i := range got {
+			got[i] = binary.LittleEndian.Uint32(data[4*i:])
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: data = %#x, want %#x", tt.encoding, got, want)
+		}
+	}
+
+	if _, err := Export(exportMap(), Options{Encoding: "xml"}); err == nil {
+		t.Error("unknown encoding: expected an error")
+	}
+}
+
+func TestExportObjectPlacement(t *testing.T) {
+	m, err := Export(exportMap(), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := make(map[string]*Object)
+	for _, layer := range m.Layers {
+		if group, ok := layer.(*ObjectGroup); ok {
+			for _, object := range group.Objects {
+				objects[object.Name] = object
+			}
+		}
+	}
+
+	// Tiled rotates clockwise in degrees, y-up coordinates counterclockwise in radians
+	if spawn := objects["Spawn"]; spawn == nil || !spawn.Point || spawn.X != 8 || spawn.Y != 8 || spawn.Rotation != -28.647890 {
+		t.Errorf("marker = %+v", spawn)
+	}
+	// A tile object is anchored at the bottom left corner of the centered sprite
+	lamp := objects["Lamp"]
+	if lamp == nil || lamp.GID != 3 || lamp.X != 0 || lamp.Y != 12 || lamp.Width != 16 || lamp.Height != 8 {
+		t.Errorf("decorator = %+v", lamp)
+	}
+}
@@ -0,0 +1,214 @@
+// Package tiled exports parsed scenes as Tiled 1.10 maps, both as TMX with TSX tilesets
+// (XML) and as Tiled JSON, so levels built in Godot can be opened in Tiled.
+//
+// Tile layers keep their flipped and transposed tiles, tile sources become atlas tilesets
+// (or image collections when Tiled's atlas layout cannot express them) with their collision
+// polygons, and decorators, prefab instances, markers, triggers, paths and geometry become
+// objects. Every field the parser exported is also stored in custom properties, so the
+// data survives a round trip through Tiled.
+package tiled
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Version is the Tiled map format version written, TiledVersion the editor version it matches
+const (
+	Version      = "1.10"
+	TiledVersion = "1.10.2"
+)
+
+// Encodings of tile layer data
+const (
+	EncodingCSV        = "csv"
+	EncodingBase64     = "base64"
+	EncodingBase64Zlib = "base64-zlib"
+	EncodingBase64Gzip = "base64-gzip"
+)
+
+// Flags stored in the high bits of a gid. Tiled flips diagonally (swapping the X and Y
+// axes) before flipping horizontally and vertically, like Godot's transposed tiles.
+const (
+	FlipHorizontal uint32 = 0x80000000
+	FlipVertical   uint32 = 0x40000000
+	FlipDiagonal   uint32 = 0x20000000
+)
+
+// Map is an orthogonal Tiled map. It marshals to Tiled JSON with embedded tilesets.
+type Map struct {
+	Type             string     `json:"type"` // "map"
+	Version          string     `json:"version"`
+	TiledVersion     string     `json:"tiledversion"`
+	Orientation      string     `json:"orientation"`
+	RenderOrder      string     `json:"renderorder"`
+	Width            int        `json:"width"`  // in tiles
+	Height           int        `json:"height"` // in tiles
+	TileWidth        int        `json:"tilewidth"`
+	TileHeight       int        `json:"tileheight"`
+	Infinite         bool       `json:"infinite"`
+	CompressionLevel int        `json:"compressionlevel"`
+	NextLayerID      int        `json:"nextlayerid"`
+	NextObjectID     int        `json:"nextobjectid"`
+	Layers           []Layer    `json:"layers"`
+	Tilesets         []*Tileset `json:"tilesets"`
+	Properties       []Property `json:"properties,omitempty"`
+	// Problems found while exporting, e.g. tiles of unknown sources
+	Warnings []string `json:"-"`
+}
+
+// Layer is a *TileLayer or an *ObjectGroup, listed bottom to top
+type Layer interface {
+	layerID() int
+}
+
+// TileLayer is a tile layer with one gid per cell, row by row. Zero is an empty cell.
+type TileLayer struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Type        string     `json:"type"` // "tilelayer"
+	X           int        `json:"x"`
+	Y           int        `json:"y"`
+	Width       int        `json:"width"`
+	Height      int        `json:"height"`
+	Opacity     float64    `json:"opacity"`
+	Visible     bool       `json:"visible"`
+	Data        any        `json:"data"` // []uint32 for csv, the encoded string otherwise
+	Encoding    string     `json:"encoding,omitempty"`
+	Compression string     `json:"compression,omitempty"`
+	Properties  []Property `json:"properties,omitempty"`
+}
+
+func (l *TileLayer) layerID() int { return l.ID }
+
+// ObjectGroup is an object layer
+type ObjectGroup struct {
+	ID         int        `json:"id,omitempty"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"` // "objectgroup"
+	DrawOrder  string     `json:"draworder"`
+	X          int        `json:"x"`
+	Y          int        `json:"y"`
+	Opacity    float64    `json:"opacity"`
+	Visible    bool       `json:"visible"`
+	Objects    []*Object  `json:"objects"`
+	Properties []Property `json:"properties,omitempty"`
+}
+
+func (g *ObjectGroup) layerID() int { return g.ID }
+
+// Object is a map object: a tile object when GID is set, otherwise a rectangle unless it is
+// a point, an ellipse, a polygon or a polyline. Positions are in map pixels, the rotation in
+// degrees clockwise around the object's position (the bottom left corner of tile objects).
+type Object struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	GID        uint32     `json:"gid,omitempty"`
+	X          float64    `json:"x"`
+	Y          float64    `json:"y"`
+	Width      float64    `json:"width"`
+	Height     float64    `json:"height"`
+	Rotation   float64    `json:"rotation"`
+	Visible    bool       `json:"visible"`
+	Point      bool       `json:"point,omitempty"`
+	Ellipse    bool       `json:"ellipse,omitempty"`
+	Polygon    []Point    `json:"polygon,omitempty"`  // relative to the object's position
+	Polyline   []Point    `json:"polyline,omitempty"` // relative to the object's position
+	Properties []Property `json:"properties,omitempty"`
+}
+
+// Point is a polygon or polyline point
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Tileset is an atlas tileset with a single image, or an image collection (Columns is zero)
+// whose tiles each reference an image or a sub-rectangle of one
+type Tileset struct {
+	FirstGID    int        `json:"firstgid"`
+	Source      string     `json:"-"` // TSX file the TMX map references instead of embedding the tileset
+	Name        string     `json:"name"`
+	TileWidth   int        `json:"tilewidth"`
+	TileHeight  int        `json:"tileheight"`
+	Spacing     int        `json:"spacing"`
+	Margin      int        `json:"margin"`
+	TileCount   int        `json:"tilecount"`
+	Columns     int        `json:"columns"`
+	Image       string     `json:"image,omitempty"`
+	ImageWidth  int        `json:"imagewidth,omitempty"`
+	ImageHeight int        `json:"imageheight,omitempty"`
+	Tiles       []*Tile    `json:"tiles,omitempty"`
+	Properties  []Property `json:"properties,omitempty"`
+}
+
+// Tile holds the image, collision objects and properties of a tile of a tileset
+type Tile struct {
+	ID          int          `json:"id"`
+	Image       string       `json:"image,omitempty"`
+	ImageWidth  int          `json:"imagewidth,omitempty"`
+	ImageHeight int          `json:"imageheight,omitempty"`
+	X           int          `json:"x,omitempty"`
+	Y           int          `json:"y,omitempty"`
+	Width       int          `json:"width,omitempty"`
+	Height      int          `json:"height,omitempty"`
+	ObjectGroup *ObjectGroup `json:"objectgroup,omitempty"`
+	Properties  []Property   `json:"properties,omitempty"`
+}
+
+// Property is a custom property. Type is "string", "int", "float" or "bool".
+type Property struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// WriteTMX writes the map as TMX to path and each tileset as a TSX file next to it,
+// named after the map and the tileset
+func (m *Map) WriteTMX(path string) error {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	used := make(map[string]bool)
+	for _, tileset := range m.Tilesets {
+		name := base + "_" + fileName(tileset.Name)
+		for i := 2; used[name]; i++ {
+			name = fmt.Sprintf("%s_%s_%d", base, fileName(tileset.Name), i)
+		}
+		used[name] = true
+		tileset.Source = name + ".tsx"
+		tsx, err := tileset.MarshalTSX()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(filepath.Dir(path), tileset.Source), tsx, 0644); err != nil {
+			return err
+		}
+	}
+	tmx, err := m.MarshalTMX()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, tmx, 0644)
+}
+
+// WriteJSON writes the map as Tiled JSON with its tilesets embedded
+func (m *Map) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// fileName replaces the characters of a tileset name that do not belong in a file name
+func fileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>| `, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
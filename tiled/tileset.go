@@ -0,0 +1,184 @@
+package tiled
+
+import (
+	"fmt"
+
+	tscnparser "github.com/JiepengTan/tscn_parser"
+)
+
+// sourceTileset is the tileset exported for a tile source of the scene's TileSet
+type sourceTileset struct {
+	*Tileset
+	source tscnparser.TileSource
+	// Image collections: tile IDs by atlas coordinates
+	ids map[tscnparser.Vec2i]int
+}
+
+// tilesets exports a tileset for every tile source. Sources become atlas tilesets when their
+// tiles are single cells of an image laid out with the same margin and spacing on both
+// axes, and image collections of sub-rectangles otherwise.
+func (e *exporter) tilesets() {
+	// Atlas coordinates the layers use, which need a tile even if the source does not list them
+	used := make(map[int][]tscnparser.Vec2i)
+	for _, layer := range e.data.TileMap.Layers {
+		for _, c := range e.cells(layer) {
+			used[c.source] = append(used[c.source], c.atlas)
+		}
+	}
+	for _, source := range e.data.TileMap.TileSet.Sources {
+		tileset := &sourceTileset{
+			Tileset: &Tileset{
+				FirstGID:   e.nextGID(),
+				Name:       fmt.Sprintf("source_%d", source.ID),
+				TileWidth:  source.TextureRegionSize.X,
+				TileHeight: source.TextureRegionSize.Y,
+				Properties: entryProperties(source, "tiles"),
+			},
+			source: source,
+		}
+		if tileset.TileWidth <= 0 || tileset.TileHeight <= 0 {
+			// Godot's default texture_region_size
+			tileset.TileWidth, tileset.TileHeight = 16, 16
+		}
+		if source.TexturePath != "" {
+			tileset.Name = imageName(source.TexturePath)
+		}
+		if isAtlas(source) {
+			e.atlasTileset(tileset, used[source.ID])
+		} else {
+			e.imageCollection(tileset, used[source.ID])
+		}
+		e.sources[source.ID] = tileset
+		e.m.Tilesets = append(e.m.Tilesets, tileset.Tileset)
+	}
+}
+
+// nextGID returns the first gid after the tilesets added so far
+func (e *exporter) nextGID() int {
+	if len(e.m.Tilesets) == 0 {
+		return 1
+	}
+	last := e.m.Tilesets[len(e.m.Tilesets)-1]
+	return last.FirstGID + last.TileCount
+}
+
+func isAtlas(source tscnparser.TileSource) bool {
+	if source.Margins.X != source.Margins.Y || source.Separation.X != source.Separation.Y {
+		return false
+	}
+	if source.TextureRef != nil && source.TextureRef.Region != nil {
+		return false
+	}
+	for _, tile := range source.Tiles {
+		if tile.SizeInAtlas.X > 1 || tile.SizeInAtlas.Y > 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// atlasTileset lays the source out as a grid of the whole image, sized from the image when
+// its size is known and from the tiles otherwise
+func (e *exporter) atlasTileset(tileset *sourceTileset, used []tscnparser.Vec2i) {
+	source := tileset.source
+	tileset.Image = e.opts.ImagePath(source.TexturePath)
+	tileset.Margin, tileset.Spacing = source.Margins.X, source.Separation.X
+	stepX, stepY := tileset.TileWidth+tileset.Spacing, tileset.TileHeight+tileset.Spacing
+
+	columns, rows := 1, 1
+	for _, tile := range source.Tiles {
+		columns, rows = max(columns, tile.AtlasCoords.X+1), max(rows, tile.AtlasCoords.Y+1)
+	}
+	for _, coords := range used {
+		columns, rows = max(columns, coords.X+1), max(rows, coords.Y+1)
+	}
+	if texture := source.TextureRef; texture != nil && texture.ImageWidth > 0 && texture.ImageHeight > 0 {
+		tileset.ImageWidth, tileset.ImageHeight = texture.ImageWidth, texture.ImageHeight
+		columns = max(columns, (texture.ImageWidth-tileset.Margin+tileset.Spacing)/stepX)
+		rows = max(rows, (texture.ImageHeight-tileset.Margin+tileset.Spacing)/stepY)
+	} else {
+		tileset.ImageWidth = tileset.Margin + columns*stepX - tileset.Spacing
+		tileset.ImageHeight = tileset.Margin + rows*stepY - tileset.Spacing
+	}
+	tileset.Columns, tileset.TileCount = columns, columns*rows
+
+	for _, info := range source.Tiles {
+		tile := &Tile{ID: tileset.tileID(info.AtlasCoords), Properties: entryProperties(info, "physics")}
+		tile.ObjectGroup = e.collisionObjects(info, tileset.TileWidth, tileset.TileHeight)
+		tileset.Tiles = append(tileset.Tiles, tile)
+	}
+}
+
+// imageCollection exports every tile as a sub-rectangle of the source's image
+func (e *exporter) imageCollection(tileset *sourceTileset, used []tscnparser.Vec2i) {
+	source := tileset.source
+	tileset.ids = make(map[tscnparser.Vec2i]int)
+	tiles := append([]tscnparser.TileInfo{}, source.Tiles...)
+	for _, coords := range used {
+		if !containsTile(tiles, coords) {
+			tiles = append(tiles, tscnparser.TileInfo{AtlasCoords: coords, SizeInAtlas: tscnparser.Vec2i{X: 1, Y: 1}})
+		}
+	}
+
+	var origin tscnparser.Vec2i
+	var imageWidth, imageHeight int
+	if texture := source.TextureRef; texture != nil {
+		imageWidth, imageHeight = texture.ImageWidth, texture.ImageHeight
+		if texture.Region != nil {
+			origin = tscnparser.Vec2i{X: int(texture.Region.X), Y: int(texture.Region.Y)}
+		}
+	}
+	size := tscnparser.Vec2i{X: tileset.TileWidth, Y: tileset.TileHeight}
+	for _, info := range tiles {
+		cells := info.SizeInAtlas
+		cells.X, cells.Y = max(cells.X, 1), max(cells.Y, 1)
+		tile := &Tile{
+			ID:          len(tileset.Tiles),
+			Image:       e.opts.ImagePath(source.TexturePath),
+			ImageWidth:  imageWidth,
+			ImageHeight: imageHeight,
+			X:           origin.X + source.Margins.X + info.AtlasCoords.X*(size.X+source.Separation.X),
+			Y:           origin.Y + source.Margins.Y + info.AtlasCoords.Y*(size.Y+source.Separation.Y),
+			Width:       cells.X*size.X + (cells.X-1)*source.Separation.X,
+			Height:      cells.Y*size.Y + (cells.Y-1)*source.Separation.Y,
+			Properties:  entryProperties(info, "physics"),
+		}
+		tile.ObjectGroup = e.collisionObjects(info, tile.Width, tile.Height)
+		tileset.ids[info.AtlasCoords] = tile.ID
+		tileset.Tiles = append(tileset.Tiles, tile)
+		tileset.TileWidth, tileset.TileHeight = max(tileset.TileWidth, tile.Width), max(tileset.TileHeight, tile.Height)
+	}
+	tileset.TileCount = len(tileset.Tiles)
+}
+
+func containsTile(tiles []tscnparser.TileInfo, coords tscnparser.Vec2i) bool {
+	for _, tile := range tiles {
+		if tile.AtlasCoords == coords {
+			return true
+		}
+	}
+	return false
+}
+
+// tileID returns the local ID of the tile at the given atlas coordinates
+func (t *sourceTileset) tileID(coords tscnparser.Vec2i) int {
+	if t.ids != nil {
+		return t.ids[coords]
+	}
+	return coords.Y*t.Columns + coords.X
+}
+
+// collisionObjects converts the collision polygon of a tile, whose points are relative to
+// the tile's center, to a polygon object of the tile
+func (e *exporter) collisionObjects(info tscnparser.TileInfo, width, height int) *ObjectGroup {
+	points := info.Physics.CollisionPoints
+	if len(points) < 3 {
+		return nil
+	}
+	object := &Object{ID: 1, X: float64(width) / 2, Y: float64(height) / 2, Visible: true}
+	for _, p := range points {
+		p = e.coords.Vector(p)
+		object.Polygon = append(object.Polygon, Point{X: p.X, Y: p.Y})
+	}
+	return &ObjectGroup{Type: "objectgroup", DrawOrder: "index", Opacity: 1, Visible: true, Objects: []*Object{object}}
+}
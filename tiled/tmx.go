@@ -0,0 +1,266 @@
+package tiled
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MarshalTMX returns the map as a TMX document. Tilesets with a Source are referenced,
+// the others are embedded.
+func (m *Map) MarshalTMX() ([]byte, error) {
+	w := newXMLWriter()
+	w.start("map",
+		"version", Version,
+		"tiledversion", TiledVersion,
+		"orientation", m.Orientation,
+		"renderorder", m.RenderOrder,
+		"width", strconv.Itoa(m.Width),
+		"height", strconv.Itoa(m.Height),
+		"tilewidth", strconv.Itoa(m.TileWidth),
+		"tileheight", strconv.Itoa(m.TileHeight),
+		"infinite", boolAttr(m.Infinite),
+		"nextlayerid", strconv.Itoa(m.NextLayerID),
+		"nextobjectid", strconv.Itoa(m.NextObjectID))
+	w.properties(m.Properties)
+	for _, tileset := range m.Tilesets {
+		if tileset.Source != "" {
+			w.empty("tileset", "firstgid", strconv.Itoa(tileset.FirstGID), "source", tileset.Source)
+		} else {
+			w.tileset(tileset, true)
+		}
+	}
+	for _, layer := range m.Layers {
+		switch layer := layer.(type) {
+		case *TileLayer:
+			if err := w.tileLayer(layer); err != nil {
+				return nil, err
+			}
+		case *ObjectGroup:
+			w.objectGroup(layer)
+		}
+	}
+	w.end("map")
+	return w.bytes()
+}
+
+// MarshalTSX returns the tileset as a TSX document
+func (t *Tileset) MarshalTSX() ([]byte, error) {
+	w := newXMLWriter()
+	w.tileset(t, false)
+	return w.bytes()
+}
+
+// xmlWriter writes Tiled's XML formats token by token, keeping attributes in Tiled's order
+type xmlWriter struct {
+	buf bytes.Buffer
+	enc *xml.Encoder
+	err error
+}
+
+func newXMLWriter() *xmlWriter {
+	w := &xmlWriter{}
+	w.buf.WriteString(xml.Header)
+	w.enc = xml.NewEncoder(&w.buf)
+	w.enc.Indent("", " ")
+	return w
+}
+
+// start opens an element with the given name/value attribute pairs, skipping empty values
+func (w *xmlWriter) start(name string, attrs ...string) {
+	element := xml.StartElement{Name: xml.Name{Local: name}}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		if attrs[i+1] != "" {
+			element.Attr = append(element.Attr, xml.Attr{Name: xml.Name{Local: attrs[i]}, Value: attrs[i+1]})
+		}
+	}
+	w.token(element)
+}
+
+func (w *xmlWriter) end(name string) {
+	w.token(xml.EndElement{Name: xml.Name{Local: name}})
+}
+
+func (w *xmlWriter) empty(name string, attrs ...string) {
+	w.start(name, attrs...)
+	w.end(name)
+}
+
+func (w *xmlWriter) text(text string) {
+	w.token(xml.CharData(text))
+}
+
+func (w *xmlWriter) token(token xml.Token) {
+	if w.err == nil {
+		w.err = w.enc.EncodeToken(token)
+	}
+}
+
+func (w *xmlWriter) bytes() ([]byte, error) {
+	if w.err == nil {
+		w.err = w.enc.Flush()
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+	w.buf.WriteString("\n")
+	return w.buf.Bytes(), nil
+}
+
+func (w *xmlWriter) properties(properties []Property) {
+	if len(properties) == 0 {
+		return
+	}
+	w.start("properties")
+	for _, property := range properties {
+		propertyType := property.Type
+		if propertyType == "string" {
+			propertyType = ""
+		}
+		w.empty("property", "name", property.Name, "type", propertyType, "value", formatValue(property.Value))
+	}
+	w.end("properties")
+}
+
+// tileset writes a tileset, embedded in a map or as the root of a TSX document
+func (w *xmlWriter) tileset(t *Tileset, embedded bool) {
+	attrs := []string{}
+	if embedded {
+		attrs = append(attrs, "firstgid", strconv.Itoa(t.FirstGID))
+	} else {
+		attrs = append(attrs, "version", Version, "tiledversion", TiledVersion)
+	}
+	attrs = append(attrs,
+		"name", t.Name,
+		"tilewidth", strconv.Itoa(t.TileWidth),
+		"tileheight", strconv.Itoa(t.TileHeight),
+		"spacing", nonZero(t.Spacing),
+		"margin", nonZero(t.Margin),
+		"tilecount", strconv.Itoa(t.TileCount),
+		"columns", strconv.Itoa(t.Columns))
+	w.start("tileset", attrs...)
+	w.properties(t.Properties)
+	if t.Image != "" {
+		w.empty("image", "source", t.Image, "width", nonZero(t.ImageWidth), "height", nonZero(t.ImageHeight))
+	}
+	for _, tile := range t.Tiles {
+		w.start("tile", "id", strconv.Itoa(tile.ID),
+			"x", nonZero(tile.X), "y", nonZero(tile.Y), "width", nonZero(tile.Width), "height", nonZero(tile.Height))
+		w.properties(tile.Properties)
+		if tile.Image != "" {
+			w.empty("image", "source", tile.Image, "width", nonZero(tile.ImageWidth), "height", nonZero(tile.ImageHeight))
+		}
+		if tile.ObjectGroup != nil {
+			w.objectGroup(tile.ObjectGroup)
+		}
+		w.end("tile")
+	}
+	w.end("tileset")
+}
+
+func (w *xmlWriter) tileLayer(layer *TileLayer) error {
+	w.start("layer", "id", strconv.Itoa(layer.ID), "name", layer.Name,
+		"width", strconv.Itoa(layer.Width), "height", strconv.Itoa(layer.Height))
+	w.properties(layer.Properties)
+	switch data := layer.Data.(type) {
+	case []uint32:
+		// One row per line, as Tiled writes it
+		var text strings.Builder
+		text.WriteString("\n")
+		for i, gid := range data {
+			text.WriteString(strconv.FormatUint(uint64(gid), 10))
+			if i < len(data)-1 {
+				text.WriteString(",")
+			}
+			if layer.Width > 0 && (i+1)%layer.Width == 0 {
+				text.WriteString("\n")
+			}
+		}
+		w.start("data", "encoding", EncodingCSV)
+		w.text(text.String())
+	case string:
+		w.start("data", "encoding", layer.Encoding, "compression", layer.Compression)
+		w.text("\n" + data + "\n")
+	default:
+		return fmt.Errorf("layer %q: unsupported data %T", layer.Name, layer.Data)
+	}
+	w.end("data")
+	w.end("layer")
+	return nil
+}
+
+func (w *xmlWriter) objectGroup(group *ObjectGroup) {
+	w.start("objectgroup", "id", nonZero(group.ID), "name", group.Name, "draworder", group.DrawOrder)
+	w.properties(group.Properties)
+	for _, object := range group.Objects {
+		w.object(object)
+	}
+	w.end("objectgroup")
+}
+
+func (w *xmlWriter) object(object *Object) {
+	gid := ""
+	if object.GID != 0 {
+		gid = strconv.FormatUint(uint64(object.GID), 10)
+	}
+	w.start("object", "id", strconv.Itoa(object.ID), "name", object.Name, "type", object.Type, "gid", gid,
+		"x", formatFloat(object.X), "y", formatFloat(object.Y),
+		"width", nonZeroFloat(object.Width), "height", nonZeroFloat(object.Height),
+		"rotation", nonZeroFloat(object.Rotation))
+	w.properties(object.Properties)
+	switch {
+	case object.Point:
+		w.empty("point")
+	case object.Ellipse:
+		w.empty("ellipse")
+	case len(object.Polygon) > 0:
+		w.empty("polygon", "points", formatPoints(object.Polygon))
+	case len(object.Polyline) > 0:
+		w.empty("polyline", "points", formatPoints(object.Polyline))
+	}
+	w.end("object")
+}
+
+func formatPoints(points []Point) string {
+	parts := make([]string, len(points))
+	for i, p := range points {
+		parts[i] = formatFloat(p.X) + "," + formatFloat(p.Y)
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatValue formats a property value as TMX writes it
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case float64:
+		return formatFloat(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func nonZeroFloat(v float64) string {
+	if v == 0 {
+		return ""
+	}
+	return formatFloat(v)
+}
+
+func nonZero(v int) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.Itoa(v)
+}
+
+func boolAttr(v bool) string {
+	if v {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,92 @@
+package tscnparser
+
+import (
+	"math"
+)
+
+// Transform2D represents a node transform as Godot's Node2D exposes it
+type Transform2D struct {
+	Position Vec2    `json:"position"`
+	Rotation float64 `json:"rotation,omitempty"` // radians
+	Scale    Vec2    `json:"scale"`
+	Skew     float64 `json:"skew,omitempty"` // radians
+}
+
+// IdentityTransform is the transform of a node without position, rotation, scale or skew
+var IdentityTransform = Transform2D{Scale: Vec2{X: 1, Y: 1}}
+
+// matrix2D is a transform in column form: x and y axes plus origin
+type matrix2D struct {
+	x, y, origin Vec2
+}
+
+func (t Transform2D) matrix() matrix2D {
+	return matrix2D{
+		x:      Vec2{X: math.Cos(t.Rotation) * t.Scale.X, Y: math.Sin(t.Rotation) * t.Scale.X},
+		y:      Vec2{X: -math.Sin(t.Rotation+t.Skew) * t.Scale.Y, Y: math.Cos(t.Rotation+t.Skew) * t.Scale.Y},
+		origin: t.Position,
+	}
+}
+
+func (m matrix2D) basisXform(v Vec2) Vec2 {
+	return Vec2{X: m.x.X*v.X + m.y.X*v.Y, Y: m.x.Y*v.X + m.y.Y*v.Y}
+}
+
+func (m matrix2D) transform() Transform2D {
+	det := m.x.X*m.y.Y - m.x.Y*m.y.X
+	sign := 1.0
+	if det < 0 {
+		sign = -1
+	}
+	scaleX := math.Hypot(m.x.X, m.x.Y)
+	scaleY := math.Hypot(m.y.X, m.y.Y)
+	rotation := math.Atan2(m.x.Y, m.x.X)
+	skew := 0.0
+	if scaleX != 0 && scaleY != 0 {
+		dot := (m.x.X*m.y.X + m.x.Y*m.y.Y) * sign / (scaleX * scaleY)
+		skew = math.Acos(math.Max(-1, math.Min(1, dot))) - math.Pi/2
+	}
+	return Transform2D{
+		Position: Vec2{X: roundFloat(m.origin.X), Y: roundFloat(m.origin.Y)},
+		Rotation: roundFloat(rotation),
+		Scale:    Vec2{X: roundFloat(scaleX), Y: roundFloat(sign * scaleY)},
+		Skew:     roundFloat(skew),
+	}
+}
+
+// roundFloat drops floating point noise left by composing transforms
+func roundFloat(v float64) float64 {
+	return math.Round(v*1e9) / 1e9
+}
+
+// Mul returns the transform of a child node with local transform child under this transform
+func (t Transform2D) Mul(child Transform2D) Transform2D {
+	parent := t.matrix()
+	local := child.matrix()
+	return matrix2D{
+		x:      parent.basisXform(local.x),
+		y:      parent.basisXform(local.y),
+		origin: parent.Xform(local.origin),
+	}.transform()
+}
+
+// Xform transforms a point from local to parent space
+func (t Transform2D) Xform(v Vec2) Vec2 {
+	return t.matrix().Xform(v)
+}
+
+func (m matrix2D) Xform(v Vec2) Vec2 {
+	p := m.basisXform(v)
+	return Vec2{X: roundFloat(p.X + m.origin.X), Y: roundFloat(p.Y + m.origin.Y)}
+}
+
+// XformPoints transforms a flat [x0, y0, x1, y1, ...] point list from local to parent space
+func (t Transform2D) XformPoints(points []float64) []float64 {
+	m := t.matrix()
+	result := make([]float64, 0, len(points))
+	for i := 0; i+1 < len(points); i += 2 {
+		p := m.Xform(Vec2{X: points[i], Y: points[i+1]})
+		result = append(result, p.X, p.Y)
+	}
+	return result
+}
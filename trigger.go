@@ -0,0 +1,36 @@
+package tscnparser
+
+// buildTriggers collects the Area2D nodes of the scene with their collision shapes
+func (c *TSCNConverter) buildTriggers() []Trigger {
+	var triggers []Trigger
+	for _, node := range c.nodes.order {
+		if node.Type != "Area2D" {
+			continue
+		}
+		trigger := Trigger{
+			Name:           node.Name,
+			Path:           node.Path,
+			Transform:      outputTransform(c.nodes.globalTransform(node)),
+			Shapes:         []CollisionShape{},
+			CollisionLayer: uint32(node.intProperty("collision_layer", 1)),
+			CollisionMask:  uint32(node.intProperty("collision_mask", 1)),
+			Groups:         c.nodeGroups(node),
+			NodeScript:     c.nodeScript(node),
+			Metadata:       c.nodeMetadata(node),
+		}
+		// Shapes may sit below intermediate Node2Ds but belong to the closest Area2D
+		for _, child := range c.nodes.order {
+			if child.Type != "CollisionShape2D" && child.Type != "CollisionPolygon2D" {
+				continue
+			}
+			if c.nodes.ancestor(child, isPhysicsBody) != node {
+				continue
+			}
+			shape := c.newCollisionShape(child, c.subResourceShapes)
+			shape.Transform = c.nodes.relativeTransform(node.Path, child)
+			trigger.Shapes = append(trigger.Shapes, outputShape(shape))
+		}
+		triggers = append(triggers, trigger)
+	}
+	return triggers
+}
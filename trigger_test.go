@@ -0,0 +1,46 @@
+package tscnparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTriggers(t *testing.T) {
+	data := parseNodes(t, `
+[sub_resource type="RectangleShape2D" id="Rect_1"]
+size = Vector2(16, 8)
+`, `
+[node name="Door" type="Area2D" parent="."]
+position = Vector2(40, 24)
+collision_layer = 4
+collision_mask = 2
+
+[node name="Offset" type="Node2D" parent="Door"]
+position = Vector2(0, 8)
+
+[node name="Shape" type="CollisionShape2D" parent="Door/Offset"]
+position = Vector2(8, 0)
+shape = SubResource("Rect_1")
+`)
+	if len(data.Triggers) != 1 {
+		t.Fatalf("triggers = %+v", data.Triggers)
+	}
+	door := data.Triggers[0]
+	if door.Path != "Door" || door.CollisionLayer != 4 || door.CollisionMask != 2 {
+		t.Errorf("trigger = %+v", door)
+	}
+	if want := (Transform2D{Position: Vec2{X: 88, Y: -56}, Scale: Vec2{X: 1, Y: 1}}); door.Transform != want {
+		t.Errorf("transform = %+v, want %+v", door.Transform, want)
+	}
+	// The shape below an intermediate node belongs to the area, relative to it
+	want := []CollisionShape{{
+		Name:         "Shape",
+		Transform:    Transform2D{Position: Vec2{X: 8, Y: -8}, Scale: Vec2{X: 1, Y: 1}},
+		Type:         ColliderRect,
+		Params:       []float64{16, 8},
+		OneWayMargin: 1,
+	}}
+	if !reflect.DeepEqual(door.Shapes, want) {
+		t.Errorf("shapes = %+v, want %+v", door.Shapes, want)
+	}
+}
@@ -23,6 +23,48 @@ func (v *Vec2) InvertY() {
 	v.Y = -v.Y
 }
 
+// Rect2 represents an axis-aligned rectangle in pixels
+type Rect2 struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// TextureRef references an image file, optionally restricted to a sub-region of it
+// (AtlasTexture resources and Sprite2D regions)
+type TextureRef struct {
+	Path       string `json:"path"`
+	Region     *Rect2 `json:"region,omitempty"`
+	Margin     *Rect2 `json:"margin,omitempty"`
+	FilterClip bool   `json:"filter_clip,omitempty"`
+	// Image dimensions in pixels, read from disk when a project root is set
+	ImageWidth  int `json:"image_width,omitempty"`
+	ImageHeight int `json:"image_height,omitempty"`
+}
+
+// SpriteFrame represents a single frame of a sprite animation
+type SpriteFrame struct {
+	Texture  TextureRef `json:"texture"`
+	Duration float64    `json:"duration"`
+}
+
+// SpriteAnimation represents an animation of a SpriteFrames resource
+type SpriteAnimation struct {
+	Name   string        `json:"name"`
+	Speed  float64       `json:"speed"`
+	Loop   bool          `json:"loop"`
+	Frames []SpriteFrame `json:"frames"`
+}
+
+// Color represents an RGBA color with components in the 0..1 range
+type Color struct {
+	R float64 `json:"r"`
+	G float64 `json:"g"`
+	B float64 `json:"b"`
+	A float64 `json:"a"`
+}
+
 // TileSize represents the dimensions of a tile
 type TileSize struct {
 	Width  int `json:"width"`
@@ -37,14 +79,20 @@ type PhysicsData struct {
 // TileInfo represents information about a single tile in the tileset
 type TileInfo struct {
 	AtlasCoords Vec2i       `json:"atlas_coords"`
+	SizeInAtlas Vec2i       `json:"size_in_atlas,omitempty"`
 	Physics     PhysicsData `json:"physics,omitempty"`
 }
 
 // TileSource represents a tileset source
 type TileSource struct {
-	ID          int        `json:"id"`
-	TexturePath string     `json:"texture_path"`
-	Tiles       []TileInfo `json:"tiles"`
+	ID          int         `json:"id"`
+	TexturePath string      `json:"texture_path"`
+	TextureRef  *TextureRef `json:"texture_ref,omitempty"`
+	// Atlas layout: tile size in pixels, outer margins and spacing between tiles
+	TextureRegionSize Vec2i      `json:"texture_region_size"`
+	Margins           Vec2i      `json:"margins,omitempty"`
+	Separation        Vec2i      `json:"separation,omitempty"`
+	Tiles             []TileInfo `json:"tiles"`
 }
 
 // TileSet represents the complete tileset information
@@ -61,68 +109,355 @@ type TileInstance struct {
 
 // Layer represents a tilemap layer
 type Layer struct {
-	ID       int            `json:"id"`
-	Name     string         `json:"name"`
-	Tiles    []TileInstance `-`
-	ZIndex   int            `json:"z_index"`
-	TileData []int          `json:"tile_data"`
+	ID        int            `json:"id"`
+	Name      string         `json:"name"`
+	Tiles     []TileInstance `json:"-"`
+	ZIndex    int            `json:"z_index"`
+	TileData  []int          `json:"tile_data"`
+	TileFlags []int          `json:"tile_flags,omitempty"` // per tile of TileData when any tile is flipped or transposed, see TileFlipH
+	DrawOrder DrawOrder      `json:"draw_order"`
+}
+
+// Flags of a flipped or transposed tile. Transposing swaps the X and Y axes of the tile
+// and is applied before flipping.
+const (
+	TileFlipH     = 1
+	TileFlipV     = 2
+	TileTranspose = 4
+)
+
+// DrawOrder is where a drawable ends up in Godot's draw order. Godot draws canvas layers
+// in ascending order, then items by ascending absolute z index; items of the same
+// y-sort group are drawn by ascending Y position, everything else in tree order.
+type DrawOrder struct {
+	CanvasLayer int64  `json:"canvas_layer"`
+	ZIndex      int64  `json:"z_index"`                // absolute z index with z_as_relative applied
+	YSortGroup  string `json:"y_sort_group,omitempty"` // path of the node whose y-sort includes the drawable
+	TreeOrder   int    `json:"tree_order"`             // position of the node in the scene tree
 }
 
 // TileMapData represents the complete tilemap data
 type TileMapData struct {
-	Format        int      `json:"format"`
+	Format        int      `json:"format"` // format number of the Godot TileMap, not the version of this layout, see SchemaVersion
 	TileSize      TileSize `json:"tile_size"`
 	TileSet       TileSet  `json:"tileset"`
 	Layers        []Layer  `json:"layers"`
 	WorldTileSize TileSize `json:"world_tile_size"`
+	// Groups and metadata of the TileMap node
+	Groups   []string   `json:"groups,omitempty"`
+	Metadata Properties `json:"metadata,omitempty"`
+}
+
+// CollisionShape represents a CollisionShape2D or CollisionPolygon2D node
+type CollisionShape struct {
+	Name         string      `json:"name"`
+	Transform    Transform2D `json:"transform"`
+	Type         string      `json:"type"`             // see the Collider* constants
+	Params       []float64   `json:"params,omitempty"` // layout depends on Type
+	Disabled     bool        `json:"disabled,omitempty"`
+	OneWay       bool        `json:"one_way,omitempty"`
+	OneWayMargin float64     `json:"one_way_margin,omitempty"`
+}
+
+// Collider represents a collision shape of a prefab together with the physics body that owns it.
+// The shape transform is relative to the body, the body transform relative to the prefab root.
+type Collider struct {
+	CollisionShape
+	Body           string      `json:"body,omitempty"`      // StaticBody2D, AnimatableBody2D, RigidBody2D, CharacterBody2D or Area2D
+	BodyPath       string      `json:"body_path,omitempty"` // node path of the body within the prefab
+	BodyTransform  Transform2D `json:"body_transform"`
+	CollisionLayer uint32      `json:"collision_layer"`
+	CollisionMask  uint32      `json:"collision_mask"`
 }
 
 // DecoratorNode represents a Sprite2D node in the scene
 type DecoratorNode struct {
-	Name           string    `json:"name"`
-	Path           string    `json:"path"`
-	Position       Vec2      `json:"position"`
-	Scale          Vec2      `json:"scale,omitempty"`
-	Ratation       float64   `json:"rotation,omitempty"`
-	ZIndex         int32     `json:"z_index,omitempty"`
-	Pivot          Vec2      `json:"pivot,omitempty"`
-	ColliderType   string    `json:"collider_type,omitempty"` //"none","auto","circle","rect","capsule","polygon",
-	ColliderPivot  Vec2      `json:"collider_pivot,omitempty"`
-	ColliderParams []float64 `json:"collider_params,omitempty"`
-	Parent         string    `json:"parent,omitempty"`
+	Name     string  `json:"name"`
+	Path     string  `json:"path"`
+	Position Vec2    `json:"position"`
+	Scale    Vec2    `json:"scale,omitempty"`
+	Rotation float64 `json:"rotation,omitempty"`
+	ZIndex   int32   `json:"z_index,omitempty"`
+	Pivot    Vec2    `json:"pivot,omitempty"`
+	PrefabColliders
+	Parent     string            `json:"parent,omitempty"`
+	TextureRef *TextureRef       `json:"texture_ref,omitempty"`
+	Hframes    int               `json:"hframes,omitempty"`
+	Vframes    int               `json:"vframes,omitempty"`
+	Frame      int               `json:"frame,omitempty"`
+	Animations []SpriteAnimation `json:"animations,omitempty"`
+	DrawOrder  DrawOrder         `json:"draw_order"`
+	NodePath   string            `json:"node_path,omitempty"` // path of the Sprite2D or instance node in the scene
+	Groups     []string          `json:"groups,omitempty"`
+	Metadata   Properties        `json:"metadata,omitempty"`
+	NodeScript
 }
 
 // SpriteNode represents an instantiated prefab node in the scene
 type SpriteNode struct {
-	Name       string         `json:"name"`
-	Parent     string         `json:"parent"`
-	Position   Vec2           `json:"position"`
-	Scale      Vec2           `json:"scale,omitempty"`
-	Ratation   float64        `json:"rotation,omitempty"`
-	Path       string         `json:"path"`
-	Properties map[string]any `json:"properties,omitempty"`
+	Name       string     `json:"name"`
+	Parent     string     `json:"parent"`
+	Position   Vec2       `json:"position"`
+	Scale      Vec2       `json:"scale,omitempty"`
+	Rotation   float64    `json:"rotation,omitempty"`
+	Path       string     `json:"path"`
+	Properties Properties `json:"properties,omitempty"`
+	DrawOrder  DrawOrder  `json:"draw_order"`
+	Groups     []string   `json:"groups,omitempty"`
+	Metadata   Properties `json:"metadata,omitempty"`
+	NodeScript
 }
 
 type PrefabNode struct {
-	Name           string                 `json:"name"`
-	Path           string                 `json:"path"`
-	Texture        string                 `json:"texture,omitempty"`
-	Position       Vec2                   `json:"position"`
-	Scale          Vec2                   `json:"scale,omitempty"`
-	Ratation       float64                `json:"rotation,omitempty"`
-	ZIndex         int32                  `json:"z_index,omitempty"`
-	Pivot          Vec2                   `json:"pivot,omitempty"`
-	ColliderType   string                 `json:"collider_type,omitempty"` //"none","auto","circle","rect","capsule","polygon",
-	ColliderPivot  Vec2                   `json:"collider_pivot,omitempty"`
-	ColliderParams []float64              `json:"collider_params,omitempty"`
-	ColliderParent string                 `json:"collider_parent,omitempty"`
-	Properties     map[string]interface{} `json:"properties,omitempty"`
+	Name           string  `json:"name"`
+	Path           string  `json:"path"`
+	Texture        string  `json:"texture,omitempty"`
+	Position       Vec2    `json:"position"`
+	Scale          Vec2    `json:"scale,omitempty"`
+	Rotation       float64 `json:"rotation,omitempty"`
+	ZIndex         int32   `json:"z_index,omitempty"`
+	Pivot          Vec2    `json:"pivot,omitempty"`
+	ColliderParent string  `json:"collider_parent,omitempty"`
+	PrefabColliders
+	TextureRef *TextureRef       `json:"texture_ref,omitempty"`
+	Hframes    int               `json:"hframes,omitempty"`
+	Vframes    int               `json:"vframes,omitempty"`
+	Frame      int               `json:"frame,omitempty"`
+	Animations []SpriteAnimation `json:"animations,omitempty"`
+	Properties Properties        `json:"properties,omitempty"`
+	Groups     []string          `json:"groups,omitempty"`   // groups of the prefab's root node
+	Metadata   Properties        `json:"metadata,omitempty"` // metadata of the prefab's root node
+	NodeScript
+}
+
+// PrefabColliders holds the collision shapes of a prefab, shared by the decorators
+// instanced from it. The Collider* fields describe the first shape, Colliders all of them.
+type PrefabColliders struct {
+	ColliderType   string    `json:"collider_type,omitempty"` // see the Collider* constants for types and ColliderParams layouts
+	ColliderPivot  Vec2      `json:"collider_pivot,omitempty"`
+	ColliderParams []float64 `json:"collider_params,omitempty"`
+	// Collision node transform and flags
+	ColliderRotation     float64    `json:"collider_rotation,omitempty"`
+	ColliderScale        Vec2       `json:"collider_scale,omitempty"`
+	ColliderDisabled     bool       `json:"collider_disabled,omitempty"`
+	ColliderOneWay       bool       `json:"collider_one_way,omitempty"`
+	ColliderOneWayMargin float64    `json:"collider_one_way_margin,omitempty"`
+	Colliders            []Collider `json:"colliders,omitempty"`
+}
+
+// Trigger represents an Area2D node placed in the level, e.g. a room entry or cutscene zone
+type Trigger struct {
+	Name           string           `json:"name"`
+	Path           string           `json:"path"`      // node path in the scene
+	Transform      Transform2D      `json:"transform"` // world transform
+	Shapes         []CollisionShape `json:"shapes"`    // transforms relative to the trigger
+	CollisionLayer uint32           `json:"collision_layer"`
+	CollisionMask  uint32           `json:"collision_mask"`
+	Groups         []string         `json:"groups,omitempty"`
+	Metadata       Properties       `json:"metadata,omitempty"`
+	NodeScript
+}
+
+// Marker represents a Marker2D node, e.g. a spawn point
+type Marker struct {
+	Name     string     `json:"name"`
+	Path     string     `json:"path"`     // node path in the scene
+	Position Vec2       `json:"position"` // world position
+	Rotation float64    `json:"rotation,omitempty"`
+	Groups   []string   `json:"groups,omitempty"`
+	Metadata Properties `json:"metadata,omitempty"`
+	NodeScript
+}
+
+// PathPoint is a Curve2D point with its Bezier control points, all in world space
+type PathPoint struct {
+	Position Vec2 `json:"position"`
+	In       Vec2 `json:"in"`  // control point of the segment ending at this point
+	Out      Vec2 `json:"out"` // control point of the segment starting at this point
+}
+
+// CurvePath represents a Path2D node, e.g. a patrol route
+type CurvePath struct {
+	Name     string      `json:"name"`
+	Path     string      `json:"path"` // node path in the scene
+	Points   []PathPoint `json:"points"`
+	Baked    []Vec2      `json:"baked,omitempty"` // polyline approximation within the bake tolerance
+	Groups   []string    `json:"groups,omitempty"`
+	Metadata Properties  `json:"metadata,omitempty"`
+	NodeScript
+}
+
+// Geometry represents level geometry drawn with Polygon2D or Line2D nodes, or an
+// invisible wall made of a CollisionPolygon2D under a physics body
+type Geometry struct {
+	Name      string      `json:"name"`
+	Path      string      `json:"path"` // node path in the scene
+	Type      string      `json:"type"` // Polygon2D, Line2D or CollisionPolygon2D
+	Points    []Vec2      `json:"points"`
+	Closed    bool        `json:"closed"`
+	Color     *Color      `json:"color,omitempty"`      // Polygon2D color, Line2D default_color
+	Texture   *TextureRef `json:"texture,omitempty"`    // Polygon2D and Line2D texture
+	Width     float64     `json:"width,omitempty"`      // Line2D width in the node's local units
+	BuildMode string      `json:"build_mode,omitempty"` // CollisionPolygon2D: "solids" or "segments"
+	// Physics body owning a CollisionPolygon2D
+	Body           string     `json:"body,omitempty"`
+	CollisionLayer uint32     `json:"collision_layer,omitempty"`
+	CollisionMask  uint32     `json:"collision_mask,omitempty"`
+	Disabled       bool       `json:"disabled,omitempty"`
+	OneWay         bool       `json:"one_way,omitempty"`
+	Groups         []string   `json:"groups,omitempty"`
+	Metadata       Properties `json:"metadata,omitempty"`
+	NodeScript
+}
+
+// Light represents a PointLight2D or DirectionalLight2D node
+type Light struct {
+	Name      string      `json:"name"`
+	Path      string      `json:"path"`
+	Type      string      `json:"type"` // PointLight2D or DirectionalLight2D
+	Transform Transform2D `json:"transform"`
+	Enabled   bool        `json:"enabled"`
+	Color     Color       `json:"color"`
+	Energy    float64     `json:"energy"`
+	Height    float64     `json:"height,omitempty"`
+	// PointLight2D only
+	Texture      *TextureRef `json:"texture,omitempty"`
+	TextureScale float64     `json:"texture_scale,omitempty"`
+	Offset       Vec2        `json:"offset"`
+	// DirectionalLight2D only
+	MaxDistance float64 `json:"max_distance,omitempty"`
+	// Shadows
+	ShadowEnabled      bool       `json:"shadow_enabled"`
+	ShadowColor        Color      `json:"shadow_color"`
+	ShadowFilter       string     `json:"shadow_filter"` // none, pcf5 or pcf13
+	ShadowFilterSmooth float64    `json:"shadow_filter_smooth,omitempty"`
+	ShadowItemCullMask uint32     `json:"shadow_item_cull_mask"`
+	Groups             []string   `json:"groups,omitempty"`
+	Metadata           Properties `json:"metadata,omitempty"`
+	NodeScript
+}
+
+// LightOccluder represents a LightOccluder2D node with its OccluderPolygon2D in world space
+type LightOccluder struct {
+	Name         string     `json:"name"`
+	Path         string     `json:"path"`
+	Points       []Vec2     `json:"points"`
+	Closed       bool       `json:"closed"`
+	CullMode     string     `json:"cull_mode"` // disabled, clockwise or counter_clockwise
+	SDFCollision bool       `json:"sdf_collision"`
+	LightMask    uint32     `json:"occluder_light_mask"`
+	Groups       []string   `json:"groups,omitempty"`
+	Metadata     Properties `json:"metadata,omitempty"`
+	NodeScript
+}
+
+// Lighting holds the 2D lights, light occluders and ambient color of a scene
+type Lighting struct {
+	Ambient   *Color          `json:"ambient,omitempty"` // CanvasModulate color
+	Lights    []Light         `json:"lights,omitempty"`
+	Occluders []LightOccluder `json:"occluders,omitempty"`
+}
+
+// Camera represents a Camera2D node, declared in the scene or instanced from a camera scene
+type Camera struct {
+	Name           string          `json:"name"`
+	Path           string          `json:"path"`
+	Scene          string          `json:"scene,omitempty"` // res:// path of an instanced camera scene
+	Transform      Transform2D     `json:"transform"`
+	Enabled        bool            `json:"enabled"`
+	AnchorMode     string          `json:"anchor_mode"` // fixed_top_left or drag_center
+	IgnoreRotation bool            `json:"ignore_rotation"`
+	Zoom           Vec2            `json:"zoom"`
+	Offset         Vec2            `json:"offset"`
+	Limits         CameraLimits    `json:"limits"`
+	Smoothing      CameraSmoothing `json:"smoothing"`
+	Drag           CameraDrag      `json:"drag"`
+	Groups         []string        `json:"groups,omitempty"`
+	Metadata       Properties      `json:"metadata,omitempty"`
+	NodeScript
+}
+
+// CameraLimits are the scroll limits of a camera in output coordinates
+type CameraLimits struct {
+	Left     float64 `json:"left"`
+	Top      float64 `json:"top"`
+	Right    float64 `json:"right"`
+	Bottom   float64 `json:"bottom"`
+	Smoothed bool    `json:"smoothed"`
+}
+
+// CameraSmoothing holds the position and rotation smoothing settings of a camera
+type CameraSmoothing struct {
+	PositionEnabled bool    `json:"position_enabled"`
+	PositionSpeed   float64 `json:"position_speed"`
+	RotationEnabled bool    `json:"rotation_enabled"`
+	RotationSpeed   float64 `json:"rotation_speed"`
+}
+
+// CameraDrag holds the drag margins of a camera as fractions of the screen size
+type CameraDrag struct {
+	HorizontalEnabled bool    `json:"horizontal_enabled"`
+	VerticalEnabled   bool    `json:"vertical_enabled"`
+	HorizontalOffset  float64 `json:"horizontal_offset"`
+	VerticalOffset    float64 `json:"vertical_offset"`
+	Left              float64 `json:"left"`
+	Top               float64 `json:"top"`
+	Right             float64 `json:"right"`
+	Bottom            float64 `json:"bottom"`
+}
+
+// ParallaxLayer represents a ParallaxLayer under a ParallaxBackground, or a Parallax2D node
+type ParallaxLayer struct {
+	Name         string           `json:"name"`
+	Path         string           `json:"path"`
+	Type         string           `json:"type"`                 // ParallaxLayer or Parallax2D
+	Background   string           `json:"background,omitempty"` // path of the owning ParallaxBackground
+	CanvasLayer  int64            `json:"canvas_layer"`
+	Transform    Transform2D      `json:"transform"`
+	MotionScale  Vec2             `json:"motion_scale"`  // motion_scale, or scroll_scale of Parallax2D
+	MotionOffset Vec2             `json:"motion_offset"` // motion_offset, or scroll_offset of Parallax2D
+	RepeatSize   Vec2             `json:"repeat_size"`   // motion_mirroring, or repeat_size of Parallax2D; zero does not repeat
+	RepeatTimes  int64            `json:"repeat_times"`
+	Autoscroll   Vec2             `json:"autoscroll"` // pixels per second, Parallax2D only
+	Sprites      []ParallaxSprite `json:"sprites"`    // a NodeRef to a sprite has the index of its layer in Layer
+	Groups       []string         `json:"groups,omitempty"`
+	Metadata     Properties       `json:"metadata,omitempty"`
+	NodeScript
+}
+
+// ParallaxSprite is a Sprite2D or AnimatedSprite2D inside a parallax layer
+type ParallaxSprite struct {
+	Name       string            `json:"name"`
+	Path       string            `json:"path"`
+	Transform  Transform2D       `json:"transform"` // relative to the layer
+	Texture    *TextureRef       `json:"texture,omitempty"`
+	Animations []SpriteAnimation `json:"animations,omitempty"`
+	Centered   bool              `json:"centered"`
+	Offset     Vec2              `json:"offset"`
+	FlipH      bool              `json:"flip_h,omitempty"`
+	FlipV      bool              `json:"flip_v,omitempty"`
+	ZIndex     int64             `json:"z_index,omitempty"`
+	Groups     []string          `json:"groups,omitempty"`
+	Metadata   Properties        `json:"metadata,omitempty"`
+	NodeScript
 }
 
 // Root structure for JSON output
 type MapData struct {
-	TileMap    TileMapData     `json:"tilemap"`
-	Decorators []DecoratorNode `json:"decorators"`
-	Sprites    []SpriteNode    `json:"sprites"`
-	Prefabs    []PrefabNode    `json:"prefabs"`
+	SchemaVersion int             `json:"schema_version"` // see SchemaVersion
+	TileMap       TileMapData     `json:"tilemap"`
+	Coordinates   Coordinates     `json:"coordinates"` // coordinate system of every position
+	Decorators    []DecoratorNode `json:"decorators"`
+	Sprites       []SpriteNode    `json:"sprites"`
+	Prefabs       []PrefabNode    `json:"prefabs"`
+	Triggers      []Trigger       `json:"triggers,omitempty"`
+	Markers       []Marker        `json:"markers,omitempty"`
+	Paths         []CurvePath     `json:"paths,omitempty"`
+	Geometry      []Geometry      `json:"geometry,omitempty"`
+	Lighting      *Lighting       `json:"lighting,omitempty"`
+	Cameras       []Camera        `json:"cameras,omitempty"`
+	Parallax      []ParallaxLayer `json:"parallax,omitempty"`
+	Connections   []Connection    `json:"connections,omitempty"`
+	Scripts       []Script        `json:"scripts,omitempty"`
+	Warnings      []string        `json:"warnings,omitempty"`
 }
@@ -0,0 +1,347 @@
+package tscnparser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// resourceRef is a parsed ExtResource("id") or SubResource("id") value
+type resourceRef struct {
+	Kind string // "ExtResource" or "SubResource"
+	ID   string
+}
+
+// variantCall is a parsed constructor value such as Vector2(1, 2) or Rect2(0, 0, 16, 16)
+type variantCall struct {
+	Name string
+	Args []any
+}
+
+// parseVariant parses a property value written in Godot's text resource format.
+// Values map to Go types as follows:
+//   - null -> nil, true/false -> bool
+//   - integers -> int, reals -> float64
+//   - "string", &"StringName", ^"NodePath" -> string
+//   - [...] and Array[T]([...]) -> []any
+//   - {...} -> map[string]any
+//   - ExtResource("id"), SubResource("id") -> resourceRef
+//   - any other Name(args) -> variantCall
+func parseVariant(text string) (any, error) {
+	p := &variantParser{src: text}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected %q at offset %d", p.src[p.pos:], p.pos)
+	}
+	return value, nil
+}
+
+type variantParser struct {
+	src string
+	pos int
+}
+
+func (p *variantParser) skipSpace() {
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (p *variantParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *variantParser) expect(ch byte) error {
+	p.skipSpace()
+	if p.peek() != ch {
+		return fmt.Errorf("expected %q at offset %d", ch, p.pos)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *variantParser) parseValue() (any, error) {
+	p.skipSpace()
+	switch ch := p.peek(); {
+	case ch == 0:
+		return nil, fmt.Errorf("unexpected end of value")
+	case ch == '"':
+		return p.parseString()
+	case ch == '&' || ch == '^':
+		// StringName and NodePath literals
+		p.pos++
+		return p.parseString()
+	case ch == '[':
+		return p.parseArray()
+	case ch == '{':
+		return p.parseDictionary()
+	case ch == '-' || ch == '+' || ch == '.' || (ch >= '0' && ch <= '9'):
+		return p.parseNumber()
+	case isIdentStart(ch):
+		return p.parseIdentifier()
+	default:
+		return nil, fmt.Errorf("unexpected %q at offset %d", ch, p.pos)
+	}
+}
+
+func (p *variantParser) parseString() (string, error) {
+	if p.peek() != '"' {
+		return "", fmt.Errorf("expected string at offset %d", p.pos)
+	}
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		p.pos++
+		switch ch {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if p.pos >= len(p.src) {
+				return "", fmt.Errorf("unterminated escape")
+			}
+			esc := p.src[p.pos]
+			p.pos++
+			switch esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'u':
+				if p.pos+4 > len(p.src) {
+					return "", fmt.Errorf("invalid unicode escape")
+				}
+				code, err := strconv.ParseUint(p.src[p.pos:p.pos+4], 16, 32)
+				if err != nil {
+					return "", fmt.Errorf("invalid unicode escape: %w", err)
+				}
+				sb.WriteRune(rune(code))
+				p.pos += 4
+			default:
+				sb.WriteByte(esc)
+			}
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return "", fmt.Errorf("unterminated string")
+}
+
+func (p *variantParser) parseNumber() (any, error) {
+	start := p.pos
+	for p.pos < len(p.src) {
+		ch := p.src[p.pos]
+		if (ch >= '0' && ch <= '9') || ch == '-' || ch == '+' || ch == '.' || ch == 'e' || ch == 'E' {
+			p.pos++
+			continue
+		}
+		break
+	}
+	text := p.src[start:p.pos]
+	if !strings.ContainsAny(text, ".eE") {
+		if v, err := strconv.Atoi(text); err == nil {
+			return v, nil
+		}
+	}
+	v, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number %q", text)
+	}
+	return v, nil
+}
+
+func (p *variantParser) parseArray() ([]any, error) {
+	if err := p.expect('['); err != nil {
+		return nil, err
+	}
+	items := []any{}
+	for {
+		p.skipSpace()
+		if p.peek() == ']' {
+			p.pos++
+			return items, nil
+		}
+		item, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		p.skipSpace()
+		if p.peek() == ',' {
+			p.pos++
+		}
+	}
+}
+
+func (p *variantParser) parseDictionary() (map[string]any, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	dict := map[string]any{}
+	for {
+		p.skipSpace()
+		if p.peek() == '}' {
+			p.pos++
+			return dict, nil
+		}
+		key, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		dict[fmt.Sprint(key)] = value
+		p.skipSpace()
+		if p.peek() == ',' {
+			p.pos++
+		}
+	}
+}
+
+func (p *variantParser) parseIdentifier() (any, error) {
+	start := p.pos
+	for p.pos < len(p.src) && isIdentPart(p.src[p.pos]) {
+		p.pos++
+	}
+	name := p.src[start:p.pos]
+	switch name {
+	case "null":
+		return nil, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf":
+		return math.Inf(1), nil
+	case "inf_neg":
+		return math.Inf(-1), nil
+	case "nan":
+		return math.NaN(), nil
+	}
+
+	p.skipSpace()
+	// Typed arrays and dictionaries: Array[int]([1, 2]), Dictionary[String, int]({...})
+	if p.peek() == '[' && (name == "Array" || name == "Dictionary") {
+		depth := 0
+		for p.pos < len(p.src) {
+			ch := p.src[p.pos]
+			p.pos++
+			if ch == '[' {
+				depth++
+			} else if ch == ']' {
+				depth--
+				if depth == 0 {
+					break
+				}
+			}
+		}
+		if err := p.expect('('); err != nil {
+			return nil, err
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		return value, p.expect(')')
+	}
+
+	if p.peek() != '(' {
+		return nil, fmt.Errorf("unexpected identifier %q", name)
+	}
+	p.pos++
+	var args []any
+	for {
+		p.skipSpace()
+		if p.peek() == ')' {
+			p.pos++
+			break
+		}
+		arg, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		p.skipSpace()
+		if p.peek() == ',' {
+			p.pos++
+		}
+	}
+
+	if (name == "ExtResource" || name == "SubResource") && len(args) == 1 {
+		if id, ok := args[0].(string); ok {
+			return resourceRef{Kind: name, ID: id}, nil
+		}
+	}
+	return variantCall{Name: name, Args: args}, nil
+}
+
+func isIdentStart(ch byte) bool {
+	return ch == '_' || (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z')
+}
+
+func isIdentPart(ch byte) bool {
+	return isIdentStart(ch) || (ch >= '0' && ch <= '9')
+}
+
+// floatArgs returns the call arguments as float64 values, or false if any argument is not numeric
+func (v variantCall) floatArgs() ([]float64, bool) {
+	values := make([]float64, len(v.Args))
+	for i, arg := range v.Args {
+		switch n := arg.(type) {
+		case int:
+			values[i] = float64(n)
+		case float64:
+			values[i] = n
+		default:
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// valueComplete reports whether a property value has balanced brackets and
+// quotes, i.e. whether it needs no continuation lines
+func valueComplete(text string) bool {
+	depth := 0
+	inString := false
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		if inString {
+			if ch == '\\' {
+				i++
+			} else if ch == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch ch {
+		case '"':
+			inString = true
+		case '[', '{', '(':
+			depth++
+		case ']', '}', ')':
+			depth--
+		}
+	}
+	return depth <= 0 && !inString
+}